@@ -0,0 +1,68 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/audit"
+)
+
+// configureAudit sets up the audit sinks selected by the HELM_AUDIT_*
+// environment variables and records the flags of the command being run.
+func configureAudit(actionConfig *action.Configuration, cmd *cobra.Command) {
+	var sinks []audit.Sink
+	if path := os.Getenv("HELM_AUDIT_LOG"); path != "" {
+		sinks = append(sinks, audit.NewFileSink(path))
+	}
+	if url := os.Getenv("HELM_AUDIT_WEBHOOK"); url != "" {
+		sinks = append(sinks, audit.NewWebhookSink(url))
+	}
+	if events, _ := strconv.ParseBool(os.Getenv("HELM_AUDIT_EVENTS")); events {
+		clientset, err := actionConfig.KubernetesClientSet()
+		if err != nil {
+			warning("audit events are disabled: %s", err)
+		} else {
+			sinks = append(sinks, audit.NewEventSink(clientset))
+		}
+	}
+	if len(sinks) == 0 {
+		return
+	}
+
+	actionConfig.Audit = audit.MultiSink(sinks...)
+	actionConfig.AuditFlags = changedFlags(cmd)
+}
+
+// changedFlags returns the flags set on the command line of the command that
+// cmd runs.
+func changedFlags(cmd *cobra.Command) map[string]string {
+	flags := map[string]string{}
+	c, _, err := cmd.Find(os.Args[1:])
+	if err != nil {
+		return flags
+	}
+	c.Flags().Visit(func(f *pflag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
+}
@@ -0,0 +1,90 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main // import "helm.sh/helm/v3/cmd/helm"
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/errdefs"
+)
+
+// exitCodes are the exit codes of helm for each type of error. Scripts rely
+// on them, so existing codes must not change.
+var exitCodes = map[errdefs.Type]int{
+	errdefs.TypeUnknown:          1,
+	errdefs.TypeReleaseNotFound:  2,
+	errdefs.TypeTimeout:          3,
+	errdefs.TypeResourceConflict: 4,
+	errdefs.TypeRenderError:      5,
+	errdefs.TypeSchemaViolation:  6,
+	errdefs.TypeHookFailed:       7,
+	errdefs.TypeAuthError:        8,
+}
+
+// exitCode returns the exit code of helm for err. Plugins exit with their
+// own code.
+func exitCode(err error) int {
+	if e, ok := err.(pluginError); ok {
+		return e.code
+	}
+	if code, ok := exitCodes[errdefs.TypeOf(err)]; ok {
+		return code
+	}
+	return 1
+}
+
+// errorEnvelope is the document written for an error when the output format
+// is JSON.
+type errorEnvelope struct {
+	Error errorElement `json:"error"`
+}
+
+type errorElement struct {
+	Type     errdefs.Type           `json:"type"`
+	Message  string                 `json:"message"`
+	ExitCode int                    `json:"exitCode"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+func newErrorEnvelope(err error) errorEnvelope {
+	details := errdefs.DetailsOf(err)
+	if len(details) == 0 {
+		details = nil
+	}
+	return errorEnvelope{Error: errorElement{
+		Type:     errdefs.TypeOf(err),
+		Message:  err.Error(),
+		ExitCode: exitCode(err),
+		Details:  details,
+	}}
+}
+
+// writeErrorEnvelope writes err as a JSON document to out if the command
+// that failed was asked for JSON output. It returns false otherwise.
+func writeErrorEnvelope(out io.Writer, cmd *cobra.Command, err error) bool {
+	if cmd == nil {
+		return false
+	}
+	f := cmd.Flags().Lookup(outputFlag)
+	if f == nil || f.Value.String() != output.JSON.String() {
+		return false
+	}
+	return output.EncodeJSON(out, newErrorEnvelope(err)) == nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/errdefs"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("boom"), 1},
+		{errdefs.New(errdefs.TypeReleaseNotFound, "not found"), 2},
+		{errors.Wrap(errdefs.New(errdefs.TypeTimeout, "timed out"), "install"), 3},
+		{errdefs.New(errdefs.TypeResourceConflict, "exists"), 4},
+		{errdefs.New(errdefs.TypeRenderError, "bad template"), 5},
+		{errdefs.New(errdefs.TypeSchemaViolation, "invalid"), 6},
+		{errdefs.New(errdefs.TypeHookFailed, "hook failed"), 7},
+		{errdefs.New(errdefs.TypeAuthError, "unauthorized"), 8},
+		{pluginError{error: errors.New("plugin"), code: 42}, 42},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%v: expected exit code %d, got %d", tt.err, tt.want, got)
+		}
+	}
+
+	// Every type must have its own exit code.
+	seen := map[int]errdefs.Type{}
+	for _, typ := range append(errdefs.Types(), errdefs.TypeUnknown) {
+		code, ok := exitCodes[typ]
+		if !ok {
+			t.Errorf("no exit code for %q", typ)
+		}
+		if other, ok := seen[code]; ok {
+			t.Errorf("%q and %q share exit code %d", typ, other, code)
+		}
+		seen[code] = typ
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	c, _, err := executeActionCommandC(storageFixture(), "status missing --output json")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var out bytes.Buffer
+	if !writeErrorEnvelope(&out, c, err) {
+		t.Fatal("expected an error envelope for JSON output")
+	}
+	var envelope errorEnvelope
+	if err := json.Unmarshal(out.Bytes(), &envelope); err != nil {
+		t.Fatalf("invalid envelope %q: %s", out.String(), err)
+	}
+	if envelope.Error.Type != errdefs.TypeReleaseNotFound {
+		t.Errorf("expected type %q, got %q", errdefs.TypeReleaseNotFound, envelope.Error.Type)
+	}
+	if envelope.Error.ExitCode != 2 {
+		t.Errorf("expected exit code 2, got %d", envelope.Error.ExitCode)
+	}
+	if envelope.Error.Message != err.Error() {
+		t.Errorf("expected message %q, got %q", err.Error(), envelope.Error.Message)
+	}
+
+	c, _, err = executeActionCommandC(storageFixture(), "status missing")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if writeErrorEnvelope(&out, c, err) {
+		t.Error("expected no error envelope for table output")
+	}
+}
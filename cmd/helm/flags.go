@@ -33,6 +33,7 @@ import (
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/helmpath"
 	"helm.sh/helm/v3/pkg/postrender"
+	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/repo"
 )
 
@@ -40,10 +41,44 @@ const outputFlag = "output"
 const postRenderFlag = "post-renderer"
 
 func addValueOptionsFlags(f *pflag.FlagSet, v *values.Options) {
-	f.StringSliceVarP(&v.ValueFiles, "values", "f", []string{}, "specify values in a YAML file or a URL (can specify multiple)")
+	f.StringSliceVarP(&v.ValueFiles, "values", "f", []string{}, "specify values in a YAML file, a URL, or a key of a Secret or ConfigMap like secret://namespace/name/key (can specify multiple)")
 	f.StringArrayVar(&v.Values, "set", []string{}, "set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
 	f.StringArrayVar(&v.StringValues, "set-string", []string{}, "set STRING values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
 	f.StringArrayVar(&v.FileValues, "set-file", []string{}, "set values from respective files specified via the command line (can specify multiple or separate values with commas: key1=path1,key2=path2)")
+	f.StringArrayVar(&v.JSONValues, "set-json", []string{}, "set JSON values on the command line (can specify multiple or separate values with commas: key1=jsonval1,key2=jsonval2)")
+	f.StringArrayVar(&v.LiteralValues, "set-literal", []string{}, "set a literal STRING value on the command line")
+	f.StringArrayVar(&v.AgeIdentityFiles, "age-identity", []string{}, "decrypt encrypted values files with the age identities in a file (can specify multiple)")
+	f.StringVar(&v.PGPKeyring, "values-keyring", "", "decrypt encrypted values files with the secret keys of an OpenPGP keyring")
+	var passphraseFile string
+	f.StringVar(&passphraseFile, "values-passphrase-file", "", `location of a file which contains the passphrase for the secret keys of --values-keyring, which is prompted for if it is needed and not given. Use "-" in order to read from stdin.`)
+	// The flags are parsed after this, so the file is only known when a
+	// passphrase is needed.
+	v.PGPPassphrase = func(name string) ([]byte, error) {
+		fetch, err := action.NewPassphraseFetcher(passphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		return fetch(name)
+	}
+}
+
+// clusterValues lets values files refer to the Secrets and ConfigMaps of the
+// cluster, if the kube client of cfg can read them.
+func clusterValues(v *values.Options, cfg *action.Configuration) {
+	if r, ok := cfg.KubeClient.(values.ClusterReader); ok {
+		v.Cluster = r
+	}
+}
+
+// redactValues returns a copy of a release to print, in which the values read
+// from the cluster or decrypted are redacted.
+func redactValues(rel *release.Release, v *values.Options) *release.Release {
+	if rel == nil {
+		return nil
+	}
+	r := *rel
+	r.Config = v.Redact(rel.Config)
+	return &r
 }
 
 func addChartPathOptionsFlags(f *pflag.FlagSet, c *action.ChartPathOptions) {
@@ -31,10 +31,13 @@ const getHooksHelp = `
 This command downloads hooks for a given release.
 
 Hooks are formatted in YAML and separated by the YAML '---\n' separator.
+The attempts of the last run of each hook are listed in comments, followed
+by the logs of its containers if '--logs' is set.
 `
 
 func newGetHooksCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewGet(cfg)
+	var showLogs bool
 
 	cmd := &cobra.Command{
 		Use:   "hooks RELEASE_NAME",
@@ -53,13 +56,23 @@ func newGetHooksCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				return err
 			}
 			for _, hook := range res.Hooks {
-				fmt.Fprintf(out, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+				fmt.Fprintf(out, "---\n# Source: %s\n", hook.Path)
+				for _, line := range hookAttemptLines(hook) {
+					fmt.Fprintf(out, "# %s\n", line)
+				}
+				if showLogs {
+					for _, line := range hookLogLines(hook) {
+						fmt.Fprintf(out, "# %s\n", line)
+					}
+				}
+				fmt.Fprintf(out, "%s\n", hook.Manifest)
 			}
 			return nil
 		},
 	}
 
 	cmd.Flags().IntVar(&client.Version, "revision", 0, "get the named release with revision")
+	cmd.Flags().BoolVar(&showLogs, "logs", false, "show the logs captured in the last run of each hook")
 	err := cmd.RegisterFlagCompletionFunc("revision", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 1 {
 			return compListRevisions(toComplete, cfg, args[0])
@@ -28,6 +28,26 @@ func TestGetHooks(t *testing.T) {
 		cmd:    "get hooks aeneas",
 		golden: "output/get-hooks.txt",
 		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "aeneas"})},
+	}, {
+		name:   "get hooks with a retried hook",
+		cmd:    "get hooks flummoxed-chickadee",
+		golden: "output/get-hooks-retried.txt",
+		rels: []*release.Release{{
+			Name:      "flummoxed-chickadee",
+			Namespace: "default",
+			Info:      &release.Info{Status: release.StatusDeployed},
+			Hooks:     []*release.Hook{retriedHook()},
+		}},
+	}, {
+		name:   "get hooks with logs",
+		cmd:    "get hooks flummoxed-chickadee --logs",
+		golden: "output/get-hooks-logs.txt",
+		rels: []*release.Release{{
+			Name:      "flummoxed-chickadee",
+			Namespace: "default",
+			Info:      &release.Info{Status: release.StatusDeployed},
+			Hooks:     []*release.Hook{retriedHook()},
+		}},
 	}, {
 		name:      "get hooks without args",
 		cmd:       "get hooks",
@@ -44,10 +44,7 @@ func init() {
 }
 
 func debug(format string, v ...interface{}) {
-	if settings.Debug {
-		format = fmt.Sprintf("[debug] %s\n", format)
-		log.Output(2, fmt.Sprintf(format, v...))
-	}
+	logger.Debug(fmt.Sprintf(format, v...))
 }
 
 func warning(format string, v ...interface{}) {
@@ -72,22 +69,26 @@ func main() {
 	// run when each command's execute method is called
 	cobra.OnInitialize(func() {
 		helmDriver := os.Getenv("HELM_DRIVER")
+		actionConfig.PluginsDirectory = settings.PluginsDirectory
+		actionConfig.PluginEnv = settings.EnvVars()
 		if err := actionConfig.Init(settings.RESTClientGetter(), settings.Namespace(), helmDriver, debug); err != nil {
 			log.Fatal(err)
 		}
+		actionConfig.HookConcurrency = settings.HookConcurrency
 		if helmDriver == "memory" {
 			loadReleasesInMemory(actionConfig)
 		}
+		configureAudit(actionConfig, cmd)
+		configureNotifications(actionConfig)
 	})
 
-	if err := cmd.Execute(); err != nil {
+	ctx, endTracing := startTracing(cmd)
+	c, err := cmd.ExecuteContextC(ctx)
+	endTracing(err)
+	if err != nil {
 		debug("%+v", err)
-		switch e := err.(type) {
-		case pluginError:
-			os.Exit(e.code)
-		default:
-			os.Exit(1)
-		}
+		writeErrorEnvelope(os.Stdout, c, err)
+		os.Exit(exitCode(err))
 	}
 }
 
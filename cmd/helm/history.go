@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gosuri/uitable"
@@ -43,11 +44,14 @@ configures the maximum length of the revision list returned.
 The historical release set is printed as a formatted table, e.g:
 
     $ helm history angry-bird
-    REVISION    UPDATED                     STATUS          CHART             APP VERSION     DESCRIPTION
-    1           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Initial install
-    2           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Upgraded successfully
-    3           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Rolled back to 2
-    4           Mon Oct 3 10:15:13 2016     deployed        alpine-0.1.0      1.0             Upgraded successfully
+    REVISION    UPDATED                     STATUS          PINNED    CHART             APP VERSION     DESCRIPTION
+    1           Mon Oct 3 10:15:13 2016     superseded                alpine-0.1.0      1.0             Initial install
+    2           Mon Oct 3 10:15:13 2016     superseded      true      alpine-0.1.0      1.0             Upgraded successfully
+    3           Mon Oct 3 10:15:13 2016     superseded                alpine-0.1.0      1.0             Rolled back to 2
+    4           Mon Oct 3 10:15:13 2016     deployed                  alpine-0.1.0      1.0             Upgraded successfully
+
+Pinned revisions are never pruned from the history. Use 'helm history pin' and
+'helm history unpin' to manage them.
 `
 
 func newHistoryCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -80,6 +84,11 @@ func newHistoryCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.IntVar(&client.Max, "max", 256, "maximum number of revision to include in history")
 	bindOutputFlag(cmd, &outfmt)
 
+	cmd.AddCommand(
+		newHistoryPinCmd(cfg, out),
+		newHistoryUnpinCmd(cfg, out),
+	)
+
 	return cmd
 }
 
@@ -87,6 +96,7 @@ type releaseInfo struct {
 	Revision    int           `json:"revision"`
 	Updated     helmtime.Time `json:"updated"`
 	Status      string        `json:"status"`
+	Pinned      bool          `json:"pinned,omitempty"`
 	Chart       string        `json:"chart"`
 	AppVersion  string        `json:"app_version"`
 	Description string        `json:"description"`
@@ -104,9 +114,15 @@ func (r releaseHistory) WriteYAML(out io.Writer) error {
 
 func (r releaseHistory) WriteTable(out io.Writer) error {
 	tbl := uitable.New()
-	tbl.AddRow("REVISION", "UPDATED", "STATUS", "CHART", "APP VERSION", "DESCRIPTION")
+	tbl.AddRow("REVISION", "UPDATED", "STATUS", "PINNED", "CHART", "APP VERSION", "DESCRIPTION")
 	for _, item := range r {
-		tbl.AddRow(item.Revision, item.Updated.Format(time.ANSIC), item.Status, item.Chart, item.AppVersion, item.Description)
+		pinned := ""
+		if item.Pinned {
+			pinned = "true"
+		}
+		// Only the first line of the description fits in the table.
+		desc := strings.SplitN(item.Description, "\n", 2)[0]
+		tbl.AddRow(item.Revision, item.Updated.Format(time.ANSIC), item.Status, pinned, item.Chart, item.AppVersion, desc)
 	}
 	return output.EncodeTable(out, tbl)
 }
@@ -145,6 +161,7 @@ func getReleaseHistory(rls []*release.Release) (history releaseHistory) {
 		rInfo := releaseInfo{
 			Revision:    v,
 			Status:      s,
+			Pinned:      r.Info.Pinned,
 			Chart:       c,
 			AppVersion:  a,
 			Description: d,
@@ -0,0 +1,92 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const historyPinDesc = `
+This command pins a revision of a release.
+
+Pinned revisions are never pruned from the release history, regardless of
+'--history-max' and '--history-max-age'. Use it to keep a known-good revision
+around to roll back to.
+`
+
+const historyUnpinDesc = `
+This command unpins a revision of a release, so that it can be pruned from the
+release history again.
+`
+
+func newHistoryPinCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	return newPinCmd(cfg, out, false)
+}
+
+func newHistoryUnpinCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	return newPinCmd(cfg, out, true)
+}
+
+func newPinCmd(cfg *action.Configuration, out io.Writer, unpin bool) *cobra.Command {
+	client := action.NewPin(cfg)
+	client.Unpin = unpin
+
+	use, done, short, long := "pin", "pinned", "protect a revision from being pruned from the release history", historyPinDesc
+	if unpin {
+		use, done, short, long = "unpin", "unpinned", "allow a pinned revision to be pruned from the release history", historyUnpinDesc
+	}
+
+	cmd := &cobra.Command{
+		Use:   use + " RELEASE_NAME REVISION",
+		Short: short,
+		Long:  long,
+		Args:  require.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return compListReleases(toComplete, args, cfg)
+			}
+
+			if len(args) == 1 {
+				return compListRevisions(toComplete, cfg, args[0])
+			}
+
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			revision, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("could not convert revision to a number: %v", err)
+			}
+
+			if err := client.Run(args[0], revision); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "Revision %d of release %q has been %s\n", revision, args[0], done)
+			return nil
+		},
+	}
+
+	return cmd
+}
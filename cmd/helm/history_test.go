@@ -115,7 +115,76 @@ func revisionFlagCompletionTest(t *testing.T, cmdName string) {
 }
 
 func TestHistoryCompletion(t *testing.T) {
-	checkReleaseCompletion(t, "history", false)
+	rels := []*release.Release{
+		release.Mock(&release.MockReleaseOptions{Name: "athos"}),
+		release.Mock(&release.MockReleaseOptions{Name: "porthos"}),
+		release.Mock(&release.MockReleaseOptions{Name: "aramis"}),
+	}
+
+	// The pin and unpin subcommands are completed along with the releases.
+	tests := []cmdTestCase{{
+		name:   "completion for history",
+		cmd:    "__complete history ''",
+		golden: "output/history-comp.txt",
+		rels:   rels,
+	}, {
+		name:   "completion for history repetition",
+		cmd:    "__complete history porthos ''",
+		golden: "output/empty_nofile_comp.txt",
+		rels:   rels,
+	}, {
+		name:   "completion for history pin",
+		cmd:    "__complete history pin ''",
+		golden: "output/release_list_comp.txt",
+		rels:   rels,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestHistoryPinCmd(t *testing.T) {
+	rels := []*release.Release{
+		release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 2, Status: release.StatusDeployed}),
+		release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 1, Status: release.StatusSuperseded}),
+	}
+
+	tests := []cmdTestCase{{
+		name:   "pin a revision",
+		cmd:    "history pin angry-bird 1",
+		rels:   rels,
+		golden: "output/history-pin.txt",
+	}, {
+		name:   "unpin a revision",
+		cmd:    "history unpin angry-bird 1",
+		rels:   rels,
+		golden: "output/history-unpin.txt",
+	}, {
+		name:      "pin a missing revision",
+		cmd:       "history pin angry-bird 3",
+		rels:      rels,
+		wantError: true,
+	}, {
+		name:      "pin with an invalid revision",
+		cmd:       "history pin angry-bird latest",
+		rels:      rels,
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestHistoryPinnedCmd(t *testing.T) {
+	pinned := release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 1, Status: release.StatusSuperseded})
+	pinned.Info.Pinned = true
+
+	tests := []cmdTestCase{{
+		name: "get history with a pinned revision",
+		cmd:  "history angry-bird",
+		rels: []*release.Release{
+			release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 2, Status: release.StatusDeployed}),
+			pinned,
+		},
+		golden: "output/history-pinned.txt",
+	}}
+	runTestCmd(t, tests)
 }
 
 func TestHistoryFileCompletion(t *testing.T) {
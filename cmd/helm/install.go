@@ -51,7 +51,9 @@ To override values in a chart, use either the '--values' flag and pass in a file
 or use the '--set' flag and pass configuration from the command line, to force
 a string value use '--set-string'. You can use '--set-file' to set individual
 values from a file when the value itself is too long for the command line
-or is dynamically generated.
+or is dynamically generated. You can also use '--set-json' to set json values
+(scalars/objects/arrays) from the command line, and '--set-literal' to set a
+value as is, with no escaping or type conversion.
 
     $ helm install -f myvalues.yaml myredis ./redis
 
@@ -67,6 +69,25 @@ or
 
     $ helm install --set-file my_script=dothings.sh myredis ./redis
 
+or
+
+    $ helm install --set-json 'master.sidecars=[{"name":"sidecar","image":"myImage"}]' myredis ./redis
+
+or
+
+    $ helm install --set-literal password='p@ss,w{0}rd\1' myredis ./redis
+
+A values file may also be a key of a Secret or a ConfigMap of the cluster.
+The values read from them are not printed:
+
+    $ helm install -f secret://prod/redis-values/values.yaml myredis ./redis
+
+Values files encrypted with 'helm values encrypt' are decrypted in memory with
+the age identities of '--age-identity' or the secret keys of '--values-keyring'.
+The decrypted values are not printed either:
+
+    $ helm install -f secrets.yaml --age-identity ~/.config/age/keys.txt myredis ./redis
+
 You can specify the '--values'/'-f' flag multiple times. The priority will be given to the
 last (right-most) file specified. For example, if both myvalues.yaml and override.yaml
 contained a key called 'Test', the value set in override.yaml would take precedence:
@@ -79,6 +100,9 @@ set for a key called 'foo', the 'newbar' value would take precedence:
 
     $ helm install --set foo=bar --set foo=newbar  myredis ./redis
 
+Values are merged in this order, later ones taking precedence: '--values',
+'--set-json', '--set', '--set-string', '--set-file' and '--set-literal'.
+
 
 To check the generated manifests of a release without installing the chart,
 the '--debug' and '--dry-run' flags can be combined.
@@ -121,13 +145,14 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return compInstall(args, toComplete, client)
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
-			rel, err := runInstall(args, client, valueOpts, out)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clusterValues(valueOpts, cfg)
+			rel, err := runInstall(cmd.Context(), args, client, valueOpts, out)
 			if err != nil {
 				return errors.Wrap(err, "INSTALLATION FAILED")
 			}
 
-			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false})
+			return outfmt.Write(out, &statusPrinter{redactValues(rel, valueOpts), settings.Debug, false, nil})
 		},
 	}
 
@@ -174,7 +199,7 @@ func addInstallFlags(cmd *cobra.Command, f *pflag.FlagSet, client *action.Instal
 	}
 }
 
-func runInstall(args []string, client *action.Install, valueOpts *values.Options, out io.Writer) (*release.Release, error) {
+func runInstall(ctx context.Context, args []string, client *action.Install, valueOpts *values.Options, out io.Writer) (*release.Release, error) {
 	debug("Original chart version: %q", client.Version)
 	if client.Version == "" && client.Devel {
 		debug("setting version to >0.0.0-0")
@@ -187,7 +212,7 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 	}
 	client.ReleaseName = name
 
-	cp, err := client.ChartPathOptions.LocateChart(chart, settings)
+	cp, err := client.ChartPathOptions.LocateChartWithContext(ctx, chart, settings)
 	if err != nil {
 		return nil, err
 	}
@@ -247,7 +272,6 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 	client.Namespace = settings.Namespace()
 
 	// Create context and prepare the handle of SIGTERM
-	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 
 	// Set up channel on which to send signal notifications.
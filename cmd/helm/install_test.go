@@ -89,6 +89,13 @@ func TestInstall(t *testing.T) {
 			cmd:    "install virgil testdata/testcharts/alpine -f testdata/testcharts/alpine/extra_values.yaml -f testdata/testcharts/alpine/more_values.yaml",
 			golden: "output/install-with-multiple-values-files.txt",
 		},
+		// Install, values from a secret that does not exist
+		{
+			name:      "install with values from a missing secret",
+			cmd:       "install virgil testdata/testcharts/alpine -f secret://default/virgil/values.yaml",
+			golden:    "output/install-with-missing-secret-values.txt",
+			wantError: true,
+		},
 		// Install, no charts
 		{
 			name:      "install with no chart specified",
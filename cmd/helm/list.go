@@ -17,18 +17,24 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"text/tabwriter"
 
 	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v3/cmd/helm/require"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli/output"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
 )
 
 var listHelp = `
@@ -56,11 +62,23 @@ By default, up to 256 items may be returned. To limit this, use the '--max' flag
 Setting '--max' to 0 will not return all results. Rather, it will return the
 server's default, which may be much higher than 256. Pairing the '--max'
 flag with the '--offset' flag allows you to page through results.
+
+If the --watch flag is provided, 'helm list' does not list the releases but
+waits for releases to be created, updated or deleted, and prints each change
+until interrupted. The state flags, '--filter' and '--selector' restrict the
+releases that are reported. Changes are printed as table rows, as lines of
+JSON or as YAML documents depending on '--output'.
+
+    $ helm list --watch --all
+    TYPE      NAME               NAMESPACE   REVISION   UPDATED   STATUS       CHART          APP VERSION
+    updated   maudlin-arachnid   default     1          ...       superseded   alpine-0.1.0   0.1.0
+    created   maudlin-arachnid   default     2          ...       deployed     alpine-0.1.0   0.1.0
 `
 
 func newListCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewList(cfg)
 	var outfmt output.Format
+	var watch bool
 
 	cmd := &cobra.Command{
 		Use:               "list",
@@ -77,6 +95,15 @@ func newListCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			client.SetStateMask()
 
+			if watch {
+				for _, name := range []string{"short", "date", "reverse", "max", "offset"} {
+					if cmd.Flags().Changed(name) {
+						return errors.Errorf("--%s cannot be used with --watch", name)
+					}
+				}
+				return watchReleases(client, out, outfmt)
+			}
+
 			results, err := client.Run()
 			if err != nil {
 				return err
@@ -129,11 +156,64 @@ func newListCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.IntVar(&client.Offset, "offset", 0, "next release index in the list, used to offset from start value")
 	f.StringVarP(&client.Filter, "filter", "f", "", "a regular expression (Perl compatible). Any releases that match the expression will be included in the results")
 	f.StringVarP(&client.Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2). Works only for secret(default) and configmap storage backends.")
+	f.BoolVarP(&watch, "watch", "w", false, "watch for releases being created, updated or deleted and print each change")
 	bindOutputFlag(cmd, &outfmt)
 
 	return cmd
 }
 
+// releaseEventElement is a release change printed by 'helm list --watch'.
+type releaseEventElement struct {
+	Type string `json:"type"`
+	releaseElement
+}
+
+// watchReleases prints the release changes reported by client until
+// interrupted. Tables get their header once and a row per change, JSON a line
+// per change and YAML a document per change.
+func watchReleases(client *action.List, out io.Writer, outfmt output.Format) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Set up channel on which to send signal notifications.
+	// We must use a buffered channel or risk missing the signal
+	// if we're not ready to receive when the signal is sent.
+	cSignal := make(chan os.Signal, 2)
+	signal.Notify(cSignal, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(cSignal)
+	go func() {
+		<-cSignal
+		cancel()
+	}()
+
+	tw := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	if outfmt == output.Table {
+		fmt.Fprintln(tw, "TYPE\tNAME\tNAMESPACE\tREVISION\tUPDATED\tSTATUS\tCHART\tAPP VERSION")
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return client.Watch(ctx, func(ev driver.Event) error {
+		w := newReleaseListWriter([]*release.Release{ev.Release}, client.TimeFormat)
+		e := releaseEventElement{
+			Type:           string(ev.Type),
+			releaseElement: w.releases[0],
+		}
+		switch outfmt {
+		case output.Table:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Type, e.Name, e.Namespace, e.Revision, e.Updated, e.Status, e.Chart, e.AppVersion)
+			return tw.Flush()
+		case output.JSON:
+			return output.EncodeJSON(out, e)
+		case output.YAML:
+			fmt.Fprintln(out, "---")
+			return output.EncodeYAML(out, e)
+		}
+		return output.ErrInvalidFormatType
+	})
+}
+
 type releaseElement struct {
 	Name       string `json:"name"`
 	Namespace  string `json:"namespace"`
@@ -228,6 +228,11 @@ func TestListCmd(t *testing.T) {
 		cmd:    "list -n milano",
 		golden: "output/list-namespace.txt",
 		rels:   releaseFixture,
+	}, {
+		name:      "watch releases with a limit",
+		cmd:       "list --watch --max 10",
+		golden:    "output/list-watch-max.txt",
+		wantError: true,
 	}}
 	runTestCmd(t, tests)
 }
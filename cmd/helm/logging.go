@@ -0,0 +1,50 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"os"
+
+	"helm.sh/helm/v3/pkg/logging"
+)
+
+// logger writes the diagnostic output of helm to stderr. It is replaced by
+// newRootCmd with a logger configured by --log-level and --log-format.
+var logger = logging.New(os.Stderr, logging.LevelError, logging.FormatText)
+
+// newLogger returns a logger writing to w at the level and in the format
+// selected by the settings. Without --log-level, the level is debug with
+// --debug and error otherwise, so that warnings are only shown on request.
+func newLogger(w io.Writer) (logging.Logger, error) {
+	level := logging.LevelError
+	if settings.Debug {
+		level = logging.LevelDebug
+	}
+	if settings.LogLevel != "" {
+		l, err := logging.ParseLevel(settings.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		level = l
+	}
+	format, err := logging.ParseFormat(settings.LogFormat)
+	if err != nil {
+		return nil, err
+	}
+	return logging.New(w, level, format), nil
+}
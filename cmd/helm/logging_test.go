@@ -0,0 +1,81 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	defer func(debug bool, level, format string) {
+		settings.Debug, settings.LogLevel, settings.LogFormat = debug, level, format
+	}(settings.Debug, settings.LogLevel, settings.LogFormat)
+
+	tests := []struct {
+		name   string
+		debug  bool
+		level  string
+		format string
+		expect string
+	}{
+		{"default", false, "", "text", ""},
+		{"warn", false, "warn", "text", "[warn] warning release=foo\n"},
+		{"debug", true, "", "text", "[debug] debugging\n[info] info\n[warn] warning release=foo\n"},
+		{"level overrides debug", true, "error", "text", ""},
+		{"info", false, "info", "text", "[info] info\n[warn] warning release=foo\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings.Debug, settings.LogLevel, settings.LogFormat = tt.debug, tt.level, tt.format
+			var buf bytes.Buffer
+			l, err := newLogger(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Debug("debugging")
+			l.Info("info")
+			l.Warn("warning", "release", "foo")
+			if buf.String() != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, buf.String())
+			}
+		})
+	}
+
+	settings.Debug, settings.LogLevel, settings.LogFormat = false, "info", "json"
+	var buf bytes.Buffer
+	l, err := newLogger(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("installing", "release", "foo")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON entry, got %q: %s", buf.String(), err)
+	}
+	if entry["level"] != "info" || entry["msg"] != "installing" || entry["release"] != "foo" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	for _, s := range [][2]string{{"verbose", "text"}, {"info", "xml"}} {
+		settings.LogLevel, settings.LogFormat = s[0], s[1]
+		if _, err := newLogger(&buf); err == nil {
+			t.Errorf("expected an error for level %q and format %q", s[0], s[1])
+		}
+	}
+}
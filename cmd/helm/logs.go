@@ -0,0 +1,85 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const logsHelp = `
+This command prints the logs of the pods of a release.
+
+The pods are those of the workloads in the manifest of the release, such as
+Deployments, StatefulSets, DaemonSets, Jobs and bare Pods. Each line is
+prefixed with the pod and the container it comes from:
+
+    $ helm logs my-release --kind deployment --name web --since 10m
+    [web-7d9c6b5f4-x2lmp/nginx] 10.0.0.12 - - "GET / HTTP/1.1" 200
+
+Use '--follow' to keep streaming new lines until interrupted.
+`
+
+func newLogsCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewLogs(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "logs RELEASE_NAME",
+		Short: "print the logs of the pods of a release",
+		Long:  logsHelp,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Set up channel on which to send signal notifications.
+			// We must use a buffered channel or risk missing the signal
+			// if we're not ready to receive when the signal is sent.
+			cSignal := make(chan os.Signal, 2)
+			signal.Notify(cSignal, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(cSignal)
+			go func() {
+				<-cSignal
+				cancel()
+			}()
+
+			return client.Run(ctx, out, args[0])
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&client.Follow, "follow", "f", false, "stream new log lines until interrupted")
+	f.DurationVar(&client.Since, "since", 0, "only print the log lines newer than a relative duration like 5s, 2m, or 3h")
+	f.StringVar(&client.Kind, "kind", "", "only print the logs of the pods of resources of this kind, e.g. deployment")
+	f.StringVar(&client.Name, "name", "", "only print the logs of the pods of the resource with this name")
+
+	return cmd
+}
@@ -0,0 +1,45 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestLogsCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:      "logs without args",
+		cmd:       "logs",
+		golden:    "output/logs-no-args.txt",
+		wantError: true,
+	}, {
+		name:      "logs of a missing release",
+		cmd:       "logs missing --follow --since 5m",
+		golden:    "output/logs-missing.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestLogsCompletion(t *testing.T) {
+	checkReleaseCompletion(t, "logs", false)
+}
+
+func TestLogsFileCompletion(t *testing.T) {
+	checkFileCompletion(t, "logs", false)
+	checkFileCompletion(t, "logs myrelease", false)
+}
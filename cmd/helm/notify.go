@@ -0,0 +1,42 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/notify"
+)
+
+// configureNotifications sets up the release notifications configured in
+// the notifications config file, if it exists.
+func configureNotifications(actionConfig *action.Configuration) {
+	c, err := notify.LoadConfig(settings.NotificationsConfig)
+	if err != nil {
+		warning("release notifications are disabled: %s", err)
+		return
+	}
+	if c == nil || len(c.Endpoints) == 0 {
+		return
+	}
+	n, err := notify.New(c)
+	if err != nil {
+		warning("release notifications are disabled: %s", err)
+		return
+	}
+	n.Log = debug
+	actionConfig.Notifier = n
+}
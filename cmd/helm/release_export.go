@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const releaseExportDesc = `
+This command writes all revisions of a release, including their chart, values,
+manifest and hooks, to a portable archive.
+
+The archive can be imported into another cluster, namespace or storage driver
+with 'helm release import'. By default it is written to RELEASE_NAME-release.tgz;
+use '--file -' to write it to stdout.
+`
+
+func newReleaseExportCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewExport(cfg)
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "export RELEASE_NAME",
+		Short: "export the history of a release to an archive",
+		Long:  releaseExportDesc,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if file == "" {
+				file = name + "-release.tgz"
+			}
+
+			if file == "-" {
+				_, err := client.Run(name, out)
+				return err
+			}
+
+			f, err := os.Create(file)
+			if err != nil {
+				return err
+			}
+			rels, err := client.Run(name, f)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(file)
+				return err
+			}
+
+			fmt.Fprintf(out, "Exported %d revisions of release %q to %s\n", len(rels), name, file)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&file, "file", "", "write the archive to this file, or to stdout if set to '-'")
+
+	return cmd
+}
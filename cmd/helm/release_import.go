@@ -0,0 +1,71 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const releaseImportDesc = `
+This command writes the revisions of a release read from an archive created by
+'helm release export' into the current storage driver and namespace.
+
+The release must not exist yet. A release exported from another namespace is
+only imported if '--rewrite-namespace' is set. With '--validate', the live
+objects are compared with the manifest of the last deployed revision, and the
+release is not imported if they are missing or differ from it.
+`
+
+func newReleaseImportCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewImport(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "import ARCHIVE",
+		Short: "import the history of a release from an archive",
+		Long:  releaseImportDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			client.Namespace = settings.Namespace()
+			rels, err := client.Run(f)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "Imported %d revisions of release %q into namespace %q\n", len(rels), rels[0].Name, client.Namespace)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&client.RewriteNamespace, "rewrite-namespace", false, "import a release exported from another namespace into the current namespace")
+	f.BoolVar(&client.Validate, "validate", false, "check that the live objects match the manifest of the last deployed revision before importing")
+
+	return cmd
+}
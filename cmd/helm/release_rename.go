@@ -0,0 +1,105 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const releaseRenameDesc = `
+This command renames a release without reinstalling it.
+
+All revisions of the release are stored under the new name and the
+'meta.helm.sh/release-name' annotation of the release's resources is updated.
+If any step fails, the changes are rolled back.
+
+The resources themselves are not renamed. Charts that derive resource names
+from .Release.Name will render different names on the next upgrade, which
+replaces those resources.
+`
+
+const releaseMoveDesc = `
+This command moves the stored history of a release to another namespace without
+reinstalling it.
+
+Only releases whose resources are all cluster-scoped can be moved. All
+revisions of the release are stored in the target namespace and the
+'meta.helm.sh/release-namespace' annotation of the release's resources is
+updated. If any step fails, the changes are rolled back.
+`
+
+func newReleaseRenameCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewRename(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "rename RELEASE_NAME NEW_NAME",
+		Short: "rename a release",
+		Long:  releaseRenameDesc,
+		Args:  require.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client.NewName = args[1]
+			rels, err := client.Run(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Renamed release %q to %q (%d revisions)\n", args[0], args[1], len(rels))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newReleaseMoveCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewRename(cfg)
+
+	cmd := &cobra.Command{
+		Use:   "move RELEASE_NAME NAMESPACE",
+		Short: "move the history of a release to another namespace",
+		Long:  releaseMoveDesc,
+		Args:  require.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return compListReleases(toComplete, args, cfg)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client.NewNamespace = args[1]
+			rels, err := client.Run(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Moved release %q to namespace %q (%d revisions)\n", args[0], args[1], len(rels))
+			return nil
+		},
+	}
+
+	return cmd
+}
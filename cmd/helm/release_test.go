@@ -0,0 +1,135 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestReleaseExportImportCmd(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "angry-bird.tgz")
+
+	store := storageFixture()
+	for _, rel := range []*release.Release{
+		release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 2, Status: release.StatusDeployed}),
+		release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 1, Status: release.StatusSuperseded}),
+	} {
+		if err := store.Create(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, out, err := executeActionCommandC(store, "release export angry-bird --file "+archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := fmt.Sprintf("Exported 2 revisions of release \"angry-bird\" to %s\n", archive); out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+
+	tests := []cmdTestCase{{
+		name:   "import a release",
+		cmd:    "release import " + archive,
+		golden: "output/release-import.txt",
+	}, {
+		name:      "import an existing release",
+		cmd:       "release import " + archive,
+		rels:      []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "angry-bird"})},
+		wantError: true,
+	}, {
+		name:      "import into another namespace",
+		cmd:       "release import " + archive + " --namespace other",
+		wantError: true,
+	}, {
+		name:   "import into another namespace rewriting the namespace",
+		cmd:    "release import " + archive + " --namespace other --rewrite-namespace",
+		golden: "output/release-import-rewrite-namespace.txt",
+	}, {
+		name:      "import a missing archive",
+		cmd:       "release import " + filepath.Join(t.TempDir(), "missing.tgz"),
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestReleaseExportCmdStdout(t *testing.T) {
+	store := storageFixture()
+	if err := store.Create(release.Mock(&release.MockReleaseOptions{Name: "angry-bird"})); err != nil {
+		t.Fatal(err)
+	}
+
+	_, out, err := executeActionCommandC(store, "release export angry-bird --file -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The archive is gzipped.
+	if len(out) < 2 || out[0] != 0x1f || out[1] != 0x8b {
+		t.Errorf("expected a gzipped archive on stdout, got %q", out)
+	}
+
+	_, _, err = executeActionCommandC(store, "release export missing --file -")
+	if err == nil {
+		t.Error("expected an error exporting a missing release")
+	}
+}
+
+func TestReleaseFileCompletion(t *testing.T) {
+	checkFileCompletion(t, "release export", false)
+	checkFileCompletion(t, "release export myrelease", false)
+	checkFileCompletion(t, "release import", true)
+	checkFileCompletion(t, "release rename", false)
+	checkFileCompletion(t, "release rename myrelease", false)
+	checkFileCompletion(t, "release move", false)
+}
+
+func TestReleaseRenameCmd(t *testing.T) {
+	rels := []*release.Release{
+		release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 2, Status: release.StatusDeployed}),
+		release.Mock(&release.MockReleaseOptions{Name: "angry-bird", Version: 1, Status: release.StatusSuperseded}),
+	}
+
+	tests := []cmdTestCase{{
+		name:   "rename a release",
+		cmd:    "release rename angry-bird happy-bird",
+		rels:   rels,
+		golden: "output/release-rename.txt",
+	}, {
+		name:      "rename a missing release",
+		cmd:       "release rename missing happy-bird",
+		rels:      rels,
+		wantError: true,
+	}, {
+		name:      "rename to an existing release",
+		cmd:       "release rename angry-bird happy-bird",
+		rels:      append(rels, release.Mock(&release.MockReleaseOptions{Name: "happy-bird"})),
+		wantError: true,
+	}, {
+		name:   "move a release",
+		cmd:    "release move angry-bird other",
+		rels:   rels,
+		golden: "output/release-move.txt",
+	}, {
+		name:      "rename without a new name",
+		cmd:       "release rename angry-bird",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
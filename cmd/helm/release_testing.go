@@ -28,6 +28,8 @@ import (
 	"helm.sh/helm/v3/cmd/helm/require"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/junit"
+	"helm.sh/helm/v3/pkg/release"
 )
 
 const releaseTestHelp = `
@@ -35,6 +37,16 @@ The test command runs the tests for a release.
 
 The argument this command takes is the name of a deployed release.
 The tests to be run are defined in the chart that was installed.
+
+Hooks with the 'pre-test' event run before the tests, and hooks with the
+'post-test' event run after them, even if they failed. '--filter' only
+selects the tests.
+
+Every selected test runs, even if another one failed; earlier versions of
+Helm stopped at the first failed test. Tests of the same weight run up to
+'--parallel' at a time, and a failed test is run again up to '--retries'
+times, or more if its 'helm.sh/hook-retries' annotation allows more. Use '--junit-report' to write the result of each test
+for a CI system.
 `
 
 func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -42,6 +54,7 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 	var outfmt = output.Table
 	var outputLogs bool
 	var filter []string
+	var junitReport string
 
 	cmd := &cobra.Command{
 		Use:   "test [RELEASE]",
@@ -64,7 +77,7 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 					client.Filters["!name"] = append(client.Filters["!name"], notName.ReplaceAllLiteralString(f, ""))
 				}
 			}
-			rel, runErr := client.Run(args[0])
+			rel, runErr := client.RunWithContext(cmd.Context(), args[0])
 			// We only return an error if we weren't even able to get the
 			// release, otherwise we keep going so we can print status and logs
 			// if requested
@@ -72,7 +85,13 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 				return runErr
 			}
 
-			if err := outfmt.Write(out, &statusPrinter{rel, settings.Debug, false}); err != nil {
+			if junitReport != "" {
+				if err := writeTestReport(junitReport, rel, client.Results(rel)); err != nil {
+					return err
+				}
+			}
+
+			if err := outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, nil}); err != nil {
 				return err
 			}
 
@@ -91,7 +110,35 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 	f := cmd.Flags()
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.BoolVar(&outputLogs, "logs", false, "dump the logs from test pods (this runs after all tests are complete, but before any cleanup)")
+	f.IntVar(&client.Parallel, "parallel", 1, "the maximum number of tests of the same weight to run at a time")
+	f.IntVar(&client.Retries, "retries", 0, "the maximum number of times to run a failed test again")
+	f.StringVar(&junitReport, "junit-report", "", "write the result of each test to this file in JUnit XML format")
 	f.StringSliceVar(&filter, "filter", []string{}, "specify tests by attribute (currently \"name\") using attribute=value syntax or '!attribute=value' to exclude a test (can specify multiple or separate values with commas: name=test1,name=test2)")
 
 	return cmd
 }
+
+// writeTestReport writes the results of the tests of a release to the named
+// file as a JUnit report.
+func writeTestReport(filename string, rel *release.Release, results []action.TestResult) error {
+	suite := junit.TestSuite{Name: rel.Name}
+	for _, r := range results {
+		c := junit.TestCase{Name: r.Name, ClassName: rel.Name, Time: junit.Seconds(r.Duration)}
+		switch {
+		case r.Skipped:
+			c.Skipped = &junit.Skipped{Message: "filtered out"}
+		case r.Phase == "":
+			c.Skipped = &junit.Skipped{Message: "not run"}
+		case r.Phase == release.HookPhaseSucceeded:
+			c.SystemOut = r.Log
+		default:
+			msg := r.Error
+			if msg == "" {
+				msg = fmt.Sprintf("test %s", strings.ToLower(r.Phase.String()))
+			}
+			c.Failure = &junit.Failure{Message: msg, Body: r.Log}
+		}
+		suite.Add(c)
+	}
+	return junit.WriteFile(filename, suite)
+}
@@ -17,7 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
+
+	"helm.sh/helm/v3/internal/test"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
 )
 
 func TestReleaseTestingCompletion(t *testing.T) {
@@ -28,3 +34,19 @@ func TestReleaseTestingFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "test", false)
 	checkFileCompletion(t, "test myrelease", false)
 }
+
+func TestWriteTestReport(t *testing.T) {
+	results := []action.TestResult{
+		{Name: "test-connection", Phase: release.HookPhaseSucceeded, Duration: 1500 * time.Millisecond, Attempts: 1, Log: "[test-connection/wget] connected\n"},
+		{Name: "test-auth", Phase: release.HookPhaseFailed, Duration: 2 * time.Second, Attempts: 2, Error: "pod test-auth failed", Log: "[test-auth/curl] 401 Unauthorized\n"},
+		{Name: "test-unknown", Phase: release.HookPhaseUnknown},
+		{Name: "test-setup", Phase: ""},
+		{Name: "test-slow", Skipped: true},
+	}
+
+	report := filepath.Join(t.TempDir(), "report.xml")
+	if err := writeTestReport(report, &release.Release{Name: "my-release"}, results); err != nil {
+		t.Fatal(err)
+	}
+	test.AssertGoldenFile(t, report, "output/test-junit-report.xml")
+}
@@ -66,7 +66,7 @@ func newRollbackCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				client.Version = ver
 			}
 
-			if err := client.Run(args[0]); err != nil {
+			if err := client.RunWithContext(cmd.Context(), args[0]); err != nil {
 				return err
 			}
 
@@ -85,6 +85,8 @@ func newRollbackCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.BoolVar(&client.WaitForJobs, "wait-for-jobs", false, "if set and --wait enabled, will wait until all Jobs have been completed before marking the release as successful. It will wait for as long as --timeout")
 	f.BoolVar(&client.CleanupOnFail, "cleanup-on-fail", false, "allow deletion of new resources created in this rollback when rollback fails")
 	f.IntVar(&client.MaxHistory, "history-max", settings.MaxHistory, "limit the maximum number of revisions saved per release. Use 0 for no limit")
+	f.DurationVar(&client.MaxHistoryAge, "history-max-age", settings.MaxHistoryAge, "prune revisions last deployed longer ago than this duration. Use 0 for no limit")
+	f.IntVar(&client.MinHistory, "history-min", settings.MinHistory, "minimum number of revisions to keep when pruning revisions by --history-max-age")
 
 	return cmd
 }
@@ -47,19 +47,33 @@ Environment variables:
 
 | Name                               | Description                                                                       |
 |------------------------------------|-----------------------------------------------------------------------------------|
+| $HELM_AUDIT_EVENTS                 | record audited operations as Kubernetes Events in the release namespace.          |
+| $HELM_AUDIT_LOG                    | append a JSON line for each audited operation to this file.                       |
+| $HELM_AUDIT_WEBHOOK                | post a JSON record of each audited operation to this URL.                         |
 | $HELM_CACHE_HOME                   | set an alternative location for storing cached files.                             |
 | $HELM_CONFIG_HOME                  | set an alternative location for storing Helm configuration.                       |
 | $HELM_DATA_HOME                    | set an alternative location for storing Helm data.                                |
 | $HELM_DEBUG                        | indicate whether or not Helm is running in Debug mode                             |
-| $HELM_DRIVER                       | set the backend storage driver. Values are: configmap, secret, memory, sql.       |
+| $HELM_DRIVER                       | set the storage driver: configmap, secret, memory, sql, file or plugin:<name>.    |
 | $HELM_DRIVER_SQL_CONNECTION_STRING | set the connection string the SQL storage driver should use.                      |
+| $HELM_DRIVER_FILE_PATH             | set the directory the file storage driver should use.                             |
+| $HELM_HOOK_CONCURRENCY             | set the maximum number of hooks of the same weight run concurrently (default 1).  |
+| $HELM_LOG_FORMAT                   | set the format of the log output: text or json.                                   |
+| $HELM_LOG_LEVEL                    | set the minimum level of the log output: debug, info, warn or error.              |
 | $HELM_MAX_HISTORY                  | set the maximum number of helm release history.                                   |
+| $HELM_MAX_HISTORY_AGE              | set the maximum age of helm release history, e.g. 720h.                           |
+| $HELM_MIN_HISTORY                  | set the minimum number of helm release history kept when pruning by age.          |
 | $HELM_NAMESPACE                    | set the namespace used for the helm operations.                                   |
+| $HELM_NOTIFICATIONS_CONFIG         | set the path to the release notifications config file.                            |
 | $HELM_NO_PLUGINS                   | disable plugins. Set HELM_NO_PLUGINS=1 to disable plugins.                        |
 | $HELM_PLUGINS                      | set the path to the plugins directory                                             |
 | $HELM_REGISTRY_CONFIG              | set the path to the registry config file.                                         |
 | $HELM_REPOSITORY_CACHE             | set the path to the repository cache directory                                    |
 | $HELM_REPOSITORY_CONFIG            | set the path to the repositories file.                                            |
+| $HELM_TRACING_ENDPOINT             | set the host:port of the OpenTelemetry collector used by the otlp exporter.       |
+| $HELM_TRACING_EXPORTER             | export OpenTelemetry traces of helm operations: otlp, file or stderr.             |
+| $HELM_TRACING_FILE                 | set the file the file trace exporter appends spans to.                            |
+| $HELM_TRACING_INSECURE             | disable TLS when sending traces to the OpenTelemetry collector.                   |
 | $KUBECONFIG                        | set an alternative Kubernetes configuration file (default "~/.kube/config")       |
 | $HELM_KUBEAPISERVER                | set the Kubernetes API Server Endpoint for authentication                         |
 | $HELM_KUBECAFILE                   | set the Kubernetes certificate authority file.                                    |
@@ -81,6 +95,21 @@ By default, the default directories depend on the Operating System. The defaults
 | Linux            | $HOME/.cache/helm         | $HOME/.config/helm             | $HOME/.local/share/helm |
 | macOS            | $HOME/Library/Caches/helm | $HOME/Library/Preferences/helm | $HOME/Library/helm      |
 | Windows          | %TEMP%\helm               | %APPDATA%\helm                 | %APPDATA%\helm          |
+
+Helm exits with a distinct code for each type of error. When a command is run
+with '--output json', the error is also written to stdout as a JSON document
+holding its type, message, exit code and details.
+
+| Code | Error type        | Description                                           |
+|------|-------------------|-------------------------------------------------------|
+| 1    | unknown           | any other error                                       |
+| 2    | release-not-found | the release or revision does not exist                |
+| 3    | timeout           | the operation did not complete in time                |
+| 4    | resource-conflict | a resource or release already exists                  |
+| 5    | render-error      | the chart templates could not be rendered             |
+| 6    | schema-violation  | the values or manifests do not conform to the schema  |
+| 7    | hook-failed       | a hook failed                                         |
+| 8    | auth-error        | the request was not authenticated or authorized       |
 `
 
 func newRootCmd(actionConfig *action.Configuration, out io.Writer, args []string) (*cobra.Command, error) {
@@ -149,8 +178,14 @@ func newRootCmd(actionConfig *action.Configuration, out io.Writer, args []string
 	flags.ParseErrorsWhitelist.UnknownFlags = true
 	flags.Parse(args)
 
+	if logger, err = newLogger(os.Stderr); err != nil {
+		return nil, err
+	}
+	actionConfig.Logger = logger
+
 	registryClient, err := registry.NewClient(
 		registry.ClientOptDebug(settings.Debug),
+		registry.ClientOptLogger(logger),
 		registry.ClientOptWriter(out),
 		registry.ClientOptCredentialsFile(settings.RegistryConfig),
 	)
@@ -167,16 +202,20 @@ func newRootCmd(actionConfig *action.Configuration, out io.Writer, args []string
 		newPullCmd(actionConfig, out),
 		newShowCmd(actionConfig, out),
 		newLintCmd(out),
+		newUnitTestCmd(out),
 		newPackageCmd(actionConfig, out),
 		newRepoCmd(out),
 		newSearchCmd(out),
 		newVerifyCmd(out),
+		newValuesCmd(out),
 
 		// release commands
 		newGetCmd(actionConfig, out),
 		newHistoryCmd(actionConfig, out),
 		newInstallCmd(actionConfig, out),
 		newListCmd(actionConfig, out),
+		newLogsCmd(actionConfig, out),
+		newReleaseCmd(actionConfig, out),
 		newReleaseTestCmd(actionConfig, out),
 		newRollbackCmd(actionConfig, out),
 		newStatusCmd(actionConfig, out),
@@ -23,12 +23,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
 
 	"helm.sh/helm/v3/cmd/helm/require"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 )
 
@@ -41,14 +44,17 @@ The status consists of:
 - state of the release (can be: unknown, deployed, uninstalled, superseded, failed, uninstalling, pending-install, pending-upgrade or pending-rollback)
 - revision of the release
 - description of the release (can be completion message or error message, need to enable --show-desc)
-- list of resources that this release consists of, sorted by kind
+- list of resources that this release consists of, grouped by kind, with their
+  readiness, pod counts and age (need to enable --show-resources)
 - details on last test suite run, if applicable
+- attempts and logs of the hooks that were retried or failed in their last run
 - additional notes provided by the chart
 `
 
 func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewStatus(cfg)
 	var outfmt output.Format
+	var showResources bool
 
 	cmd := &cobra.Command{
 		Use:   "status RELEASE_NAME",
@@ -70,7 +76,18 @@ func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			// strip chart metadata from the output
 			rel.Chart = nil
 
-			return outfmt.Write(out, &statusPrinter{rel, false, client.ShowDescription})
+			var resources []kube.ResourceStatus
+			if showResources {
+				if resources, err = client.Resources(rel); err != nil {
+					return err
+				}
+				// Distinguish a release without resources from one whose
+				// resources were not requested.
+				if resources == nil {
+					resources = []kube.ResourceStatus{}
+				}
+			}
+			return outfmt.Write(out, &statusPrinter{rel, false, client.ShowDescription, resources})
 		},
 	}
 
@@ -91,6 +108,7 @@ func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 
 	bindOutputFlag(cmd, &outfmt)
 	f.BoolVar(&client.ShowDescription, "show-desc", false, "if set, display the description message of the named release")
+	f.BoolVar(&showResources, "show-resources", false, "if set, display the live state of the resources of the named release")
 
 	return cmd
 }
@@ -99,14 +117,31 @@ type statusPrinter struct {
 	release         *release.Release
 	debug           bool
 	showDescription bool
+	// resources is the live state of the resources of the release. They
+	// are not shown if nil.
+	resources []kube.ResourceStatus
+}
+
+// releaseWithResources is the JSON and YAML output of a release with the
+// live state of its resources.
+type releaseWithResources struct {
+	*release.Release
+	Resources []kube.ResourceStatus `json:"resources"`
+}
+
+func (s statusPrinter) object() interface{} {
+	if s.resources == nil {
+		return s.release
+	}
+	return releaseWithResources{s.release, s.resources}
 }
 
 func (s statusPrinter) WriteJSON(out io.Writer) error {
-	return output.EncodeJSON(out, s.release)
+	return output.EncodeJSON(out, s.object())
 }
 
 func (s statusPrinter) WriteYAML(out io.Writer) error {
-	return output.EncodeYAML(out, s.release)
+	return output.EncodeYAML(out, s.object())
 }
 
 func (s statusPrinter) WriteTable(out io.Writer) error {
@@ -123,6 +158,9 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 	if s.showDescription {
 		fmt.Fprintf(out, "DESCRIPTION: %s\n", s.release.Info.Description)
 	}
+	if s.resources != nil {
+		printResources(out, s.resources, time.Now())
+	}
 
 	executions := executionsByHookEvent(s.release)
 	if tests, ok := executions[release.HookTest]; !ok || len(tests) == 0 {
@@ -139,9 +177,31 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 				fmt.Sprintf("Last Completed: %s", h.LastRun.CompletedAt.Format(time.ANSIC)),
 				fmt.Sprintf("Phase:          %s", h.LastRun.Phase),
 			)
+			printHookAttempts(out, h)
+			printHookLogs(out, h)
 		}
 	}
 
+	// Hooks that were retried or failed explain how the release got here.
+	for _, h := range s.release.Hooks {
+		if isTestHook(h) || (len(h.LastRun.Attempts) < 2 && h.LastRun.Phase != release.HookPhaseFailed) {
+			continue
+		}
+		events := make([]string, 0, len(h.Events))
+		for _, e := range h.Events {
+			events = append(events, e.String())
+		}
+		fmt.Fprintf(out, "HOOK:           %s (%s)\n%s\n%s\n%s\n",
+			h.Name,
+			strings.Join(events, ","),
+			fmt.Sprintf("Last Started:   %s", h.LastRun.StartedAt.Format(time.ANSIC)),
+			fmt.Sprintf("Last Completed: %s", h.LastRun.CompletedAt.Format(time.ANSIC)),
+			fmt.Sprintf("Phase:          %s", h.LastRun.Phase),
+		)
+		printHookAttempts(out, h)
+		printHookLogs(out, h)
+	}
+
 	if s.debug {
 		fmt.Fprintln(out, "USER-SUPPLIED VALUES:")
 		err := output.EncodeYAML(out, s.release.Config)
@@ -179,6 +239,134 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 	return nil
 }
 
+// printResources prints the live state of resources as tables grouped by
+// kind, in the order the kinds first appear.
+func printResources(out io.Writer, resources []kube.ResourceStatus, now time.Time) {
+	fmt.Fprintln(out, "RESOURCES:")
+	var kinds []string
+	groups := map[string][]kube.ResourceStatus{}
+	missing := 0
+	for _, r := range resources {
+		kind := r.Kind
+		if r.APIVersion != "" {
+			kind = r.APIVersion + "/" + r.Kind
+		}
+		if _, ok := groups[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+		groups[kind] = append(groups[kind], r)
+		if r.Missing {
+			missing++
+		}
+	}
+
+	for _, kind := range kinds {
+		withPods := false
+		for _, r := range groups[kind] {
+			withPods = withPods || r.Pods != nil
+		}
+
+		tbl := uitable.New()
+		if withPods {
+			tbl.AddRow("NAME", "STATUS", "PODS", "AGE")
+		} else {
+			tbl.AddRow("NAME", "STATUS", "AGE")
+		}
+		for _, r := range groups[kind] {
+			status := "NotReady"
+			switch {
+			case r.Missing:
+				status = "Missing"
+			case r.Ready:
+				status = "Ready"
+			}
+			age := "<unknown>"
+			if r.CreatedAt != nil {
+				age = duration.HumanDuration(now.Sub(*r.CreatedAt))
+			}
+			if !withPods {
+				tbl.AddRow(r.Name, status, age)
+				continue
+			}
+			pods := "-"
+			if r.Pods != nil {
+				pods = fmt.Sprintf("%d/%d", r.Pods.Ready, r.Pods.Total)
+			}
+			tbl.AddRow(r.Name, status, pods, age)
+		}
+		fmt.Fprintf(out, "==> %s\n%s\n\n", kind, tbl)
+	}
+	if missing > 0 {
+		fmt.Fprintf(out, "WARNING: %d resource(s) of the release are missing from the cluster\n", missing)
+	}
+}
+
+// printHookAttempts prints the attempts of the last run of a hook, if any.
+func printHookAttempts(out io.Writer, h *release.Hook) {
+	lines := hookAttemptLines(h)
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "Attempts:")
+	for _, line := range lines {
+		fmt.Fprintf(out, "  %s\n", line)
+	}
+}
+
+// hookAttemptLines describes each attempt of the last run of a hook on a
+// line, like "Attempt 1: Failed after 30s: job failed: BackoffLimitExceeded".
+func hookAttemptLines(h *release.Hook) []string {
+	lines := make([]string, 0, len(h.LastRun.Attempts))
+	for i, a := range h.LastRun.Attempts {
+		line := fmt.Sprintf("Attempt %d: %s", i+1, a.Phase)
+		if !a.StartedAt.IsZero() && !a.CompletedAt.IsZero() {
+			line += " after " + duration.HumanDuration(a.CompletedAt.Sub(a.StartedAt))
+		}
+		if a.Error != "" {
+			// Only the first line; the rest lists events.
+			line += ": " + strings.SplitN(a.Error, "\n", 2)[0]
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// printHookLogs prints the logs captured in the last run of a hook, if any.
+func printHookLogs(out io.Writer, h *release.Hook) {
+	lines := hookLogLines(h)
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "Logs:")
+	for _, line := range lines {
+		fmt.Fprintf(out, "  %s\n", line)
+	}
+}
+
+// hookLogLines returns the logs captured in the last run of a hook, each
+// line prefixed with the pod and container it comes from like 'helm logs',
+// after how the container terminated.
+func hookLogLines(h *release.Hook) []string {
+	var lines []string
+	for _, l := range h.LastRun.Logs {
+		prefix := fmt.Sprintf("[%s/%s] ", l.Pod, l.Container)
+		if l.Reason != "" {
+			line := fmt.Sprintf("%sterminated: %s (exit code %d)", prefix, l.Reason, l.ExitCode)
+			if l.Message != "" {
+				line += ": " + strings.TrimSpace(l.Message)
+			}
+			lines = append(lines, line)
+		}
+		if l.Log == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(l.Log, "\n"), "\n") {
+			lines = append(lines, prefix+line)
+		}
+	}
+	return lines
+}
+
 func executionsByHookEvent(rel *release.Release) map[release.HookEvent][]*release.Hook {
 	result := make(map[release.HookEvent][]*release.Hook)
 	for _, h := range rel.Hooks {
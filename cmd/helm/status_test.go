@@ -17,10 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
+	"helm.sh/helm/v3/internal/test"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 	helmtime "helm.sh/helm/v3/pkg/time"
 )
@@ -52,6 +55,14 @@ func TestStatusCmd(t *testing.T) {
 			Status:      release.StatusDeployed,
 			Description: "Mock description",
 		}),
+	}, {
+		name:   "get status of a failed release with desc",
+		cmd:    "status flummoxed-chickadee --show-desc",
+		golden: "output/status-failed.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status:      release.StatusFailed,
+			Description: "Release \"flummoxed-chickadee\" failed: timed out waiting for the condition\nRecent events:\n  Warning BackOff Pod/web-0: Back-off restarting failed container (x5)",
+		}),
 	}, {
 		name:   "get status of a deployed release with notes",
 		cmd:    "status flummoxed-chickadee",
@@ -68,6 +79,13 @@ func TestStatusCmd(t *testing.T) {
 			Status: release.StatusDeployed,
 			Notes:  "release notes",
 		}),
+	}, {
+		name:   "get status of a deployed release with resources in json",
+		cmd:    "status flummoxed-chickadee --show-resources -o json",
+		golden: "output/status-with-resources.json",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+		}),
 	}, {
 		name:   "get status of a deployed release with test suite",
 		cmd:    "status flummoxed-chickadee",
@@ -108,15 +126,71 @@ func TestStatusCmd(t *testing.T) {
 				},
 			},
 		),
+	}, {
+		name:   "get status of a release with a retried hook",
+		cmd:    "status flummoxed-chickadee",
+		golden: "output/status-with-retried-hook.txt",
+		rels: releasesMockWithStatus(
+			&release.Info{
+				Status: release.StatusDeployed,
+			},
+			retriedHook(),
+		),
 	}}
 	runTestCmd(t, tests)
 }
 
+func retriedHook() *release.Hook {
+	return &release.Hook{
+		Name:    "migrate",
+		Path:    "templates/migrate.yaml",
+		Events:  []release.HookEvent{release.HookPreInstall, release.HookPreUpgrade},
+		Retries: 1,
+		LastRun: release.HookExecution{
+			StartedAt:   mustParseTime("2006-01-02T15:00:05Z"),
+			CompletedAt: mustParseTime("2006-01-02T15:01:17Z"),
+			Phase:       release.HookPhaseSucceeded,
+			Attempts: []release.HookAttempt{{
+				StartedAt:   mustParseTime("2006-01-02T15:00:05Z"),
+				CompletedAt: mustParseTime("2006-01-02T15:01:05Z"),
+				Phase:       release.HookPhaseFailed,
+				Error:       "job failed: BackoffLimitExceeded\nRecent events:\n  Warning BackoffLimitExceeded Job/migrate: Job has reached the specified backoff limit",
+			}, {
+				StartedAt:   mustParseTime("2006-01-02T15:01:07Z"),
+				CompletedAt: mustParseTime("2006-01-02T15:01:17Z"),
+				Phase:       release.HookPhaseSucceeded,
+			}},
+			Logs: []release.HookLog{{
+				Pod:       "migrate-x7k2",
+				Container: "migrate",
+				Reason:    "Completed",
+				Log:       "applying 0042_add_index\ndone\n",
+			}},
+		},
+	}
+}
+
 func mustParseTime(t string) helmtime.Time {
 	res, _ := helmtime.Parse(time.RFC3339, t)
 	return res
 }
 
+func TestPrintResources(t *testing.T) {
+	now := time.Date(2022, 5, 8, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-49 * time.Hour)
+	resources := []kube.ResourceStatus{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "web-config", Namespace: "default", Ready: true, CreatedAt: &created},
+		{APIVersion: "v1", Kind: "Service", Name: "web", Namespace: "default", Missing: true},
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Namespace: "default", Pods: &kube.PodCount{Ready: 1, Total: 3}, CreatedAt: &created},
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker", Namespace: "default", Ready: true, Pods: &kube.PodCount{Ready: 2, Total: 2}, CreatedAt: &created},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "worker-config", Namespace: "default", Ready: true, CreatedAt: &created},
+	}
+
+	var out bytes.Buffer
+	printResources(&out, resources, now)
+	test.AssertGoldenString(t, out.String(), "output/status-resources.txt")
+}
+
 func TestStatusCompletion(t *testing.T) {
 	rels := []*release.Release{
 		{
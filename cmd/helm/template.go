@@ -17,7 +17,8 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -44,6 +45,26 @@ Render chart templates locally and display the output.
 Any values that would normally be looked up or retrieved in-cluster will be
 faked locally. Additionally, none of the server-side testing of chart validity
 (e.g. whether an API is supported) is done.
+
+Use '--snapshot-dir' to write each rendered resource to its own normalised
+file in a directory, to commit alongside the chart. With '--snapshot-check',
+the snapshot is compared with the rendered resources instead, and the command
+fails with a diff if they differ. The directory must be empty or a snapshot
+already; Helm lists the files it wrote in its '.helm-snapshot' file, and only
+ever replaces or removes those.
+
+'--snapshot-dir' cannot be used with '--output-dir'. A snapshot has a
+normalised file per resource, while '--output-dir' writes the raw output of
+each template, and the resources it writes to files are left out of the
+rendered manifest a snapshot is taken from.
+
+'--snapshot-scenario NAME=FILE' renders the chart once per scenario, with the
+values file merged over the other values, into the subdirectory NAME of the
+snapshot:
+
+    $ helm template web ./mychart --snapshot-dir snapshots \
+        --snapshot-scenario default=ci/default-values.yaml \
+        --snapshot-scenario ha=ci/ha-values.yaml
 `
 
 func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -55,6 +76,9 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	var kubeVersion string
 	var extraAPIs []string
 	var showFiles []string
+	var snapshotDir string
+	var snapshotCheck bool
+	var snapshotScenarios []string
 
 	cmd := &cobra.Command{
 		Use:   "template [NAME] [CHART]",
@@ -64,7 +88,7 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return compInstall(args, toComplete, client)
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if kubeVersion != "" {
 				parsedKubeVersion, err := chartutil.ParseKubeVersion(kubeVersion)
 				if err != nil {
@@ -73,13 +97,29 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				client.KubeVersion = parsedKubeVersion
 			}
 
+			clusterValues(valueOpts, cfg)
 			client.DryRun = true
 			client.ReleaseName = "release-name"
 			client.Replace = true // Skip the name check
 			client.ClientOnly = !validate
 			client.APIVersions = chartutil.VersionSet(extraAPIs)
 			client.IncludeCRDs = includeCrds
-			rel, err := runInstall(args, client, valueOpts, out)
+
+			if snapshotDir != "" {
+				// With --output-dir, the resources are written to files
+				// instead of the manifest of the release, so there would be
+				// nothing to snapshot, and each scenario would overwrite the
+				// files of the previous one.
+				if client.OutputDir != "" || len(showFiles) > 0 {
+					return errors.New("--snapshot-dir cannot be used with --output-dir or --show-only")
+				}
+				return templateSnapshot(cmd.Context(), args, client, valueOpts, skipTests, snapshotDir, snapshotCheck, snapshotScenarios, out)
+			}
+			if snapshotCheck || len(snapshotScenarios) > 0 {
+				return errors.New("--snapshot-check and --snapshot-scenario require --snapshot-dir")
+			}
+
+			rel, err := runInstall(cmd.Context(), args, client, valueOpts, out)
 
 			if err != nil && !settings.Debug {
 				if rel != nil {
@@ -91,29 +131,9 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			// We ignore a potential error here because, when the --debug flag was specified,
 			// we always want to print the YAML, even if it is not valid. The error is still returned afterwards.
 			if rel != nil {
-				var manifests bytes.Buffer
-				fmt.Fprintln(&manifests, strings.TrimSpace(rel.Manifest))
-				if !client.DisableHooks {
-					fileWritten := make(map[string]bool)
-					for _, m := range rel.Hooks {
-						if skipTests && isTestHook(m) {
-							continue
-						}
-						if client.OutputDir == "" {
-							fmt.Fprintf(&manifests, "---\n# Source: %s\n%s\n", m.Path, m.Manifest)
-						} else {
-							newDir := client.OutputDir
-							if client.UseReleaseName {
-								newDir = filepath.Join(client.OutputDir, client.ReleaseName)
-							}
-							err = writeToFile(newDir, m.Path, m.Manifest, fileWritten[m.Path])
-							if err != nil {
-								return err
-							}
-							fileWritten[m.Path] = true
-						}
-
-					}
+				manifests, err := templateManifest(rel, client, skipTests)
+				if err != nil {
+					return err
 				}
 
 				// if we have a list of files to render, then check that each of the
@@ -121,7 +141,7 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				if len(showFiles) > 0 {
 					// This is necessary to ensure consistent manifest ordering when using --show-only
 					// with globs or directory names.
-					splitManifests := releaseutil.SplitManifests(manifests.String())
+					splitManifests := releaseutil.SplitManifests(manifests)
 					manifestsKeys := make([]string, 0, len(splitManifests))
 					for k := range splitManifests {
 						manifestsKeys = append(manifestsKeys, k)
@@ -164,7 +184,7 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 						fmt.Fprintf(out, "---\n%s\n", m)
 					}
 				} else {
-					fmt.Fprintf(out, "%s", manifests.String())
+					fmt.Fprintf(out, "%s", manifests)
 				}
 			}
 
@@ -183,11 +203,109 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion")
 	f.StringArrayVarP(&extraAPIs, "api-versions", "a", []string{}, "Kubernetes api versions used for Capabilities.APIVersions")
 	f.BoolVar(&client.UseReleaseName, "release-name", false, "use release name in the output-dir path.")
+	f.StringVar(&snapshotDir, "snapshot-dir", "", "write each rendered resource to a normalised file in this directory instead of stdout")
+	f.BoolVar(&snapshotCheck, "snapshot-check", false, "compare the rendered resources with the snapshot in --snapshot-dir, and fail with a diff if they differ")
+	f.StringArrayVar(&snapshotScenarios, "snapshot-scenario", []string{}, "render a snapshot of the chart with a values file, into a subdirectory of --snapshot-dir (can specify multiple: NAME=FILE)")
 	bindPostRenderFlag(cmd, &client.PostRenderer)
 
 	return cmd
 }
 
+// templateSnapshot renders the chart once per scenario, and writes the rendered
+// resources to the snapshot of the scenario or compares them with it.
+func templateSnapshot(ctx context.Context, args []string, client *action.Install, valueOpts *values.Options, skipTests bool, dir string, check bool, scenarios []string, out io.Writer) error {
+	type scenario struct {
+		dir  string
+		opts *values.Options
+	}
+	list := []scenario{{dir: dir, opts: valueOpts}}
+	if len(scenarios) > 0 {
+		list = nil
+		seen := map[string]bool{}
+		for _, s := range scenarios {
+			parts := strings.SplitN(s, "=", 2)
+			name := parts[0]
+			if len(parts) != 2 || name == "" || parts[1] == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+				return fmt.Errorf("invalid snapshot scenario %q, expected NAME=FILE", s)
+			}
+			if seen[name] {
+				return fmt.Errorf("snapshot scenario %s is specified more than once", name)
+			}
+			seen[name] = true
+			opts := *valueOpts
+			opts.ValueFiles = append(append([]string{}, valueOpts.ValueFiles...), parts[1])
+			list = append(list, scenario{dir: filepath.Join(dir, name), opts: &opts})
+		}
+	}
+
+	outdated := 0
+	for _, s := range list {
+		rel, err := runInstall(ctx, args, client, s.opts, out)
+		if err != nil {
+			return fmt.Errorf("unable to render %s: %w", s.dir, err)
+		}
+		manifest, err := templateManifest(rel, client, skipTests)
+		if err != nil {
+			return err
+		}
+		files, err := action.SnapshotFiles(manifest)
+		if err != nil {
+			return err
+		}
+		if !check {
+			if err := action.WriteSnapshot(s.dir, files); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "wrote %s (%d resources)\n", s.dir, len(files))
+			continue
+		}
+		diff, err := action.DiffSnapshot(s.dir, files)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			fmt.Fprintf(out, "%s is up to date\n", s.dir)
+			continue
+		}
+		outdated++
+		fmt.Fprintf(out, "%s is out of date:\n%s", s.dir, diff)
+	}
+	if outdated > 0 {
+		return fmt.Errorf("%d snapshot(s) out of date; run without --snapshot-check to update them", outdated)
+	}
+	return nil
+}
+
+// templateManifest returns the manifest of a rendered release, followed by
+// its hooks. With an output directory, the hooks are written to their own
+// files in it instead, as renderResources does with the other resources.
+func templateManifest(rel *release.Release, client *action.Install, skipTests bool) (string, error) {
+	var manifest strings.Builder
+	fmt.Fprintln(&manifest, strings.TrimSpace(rel.Manifest))
+	if client.DisableHooks {
+		return manifest.String(), nil
+	}
+	fileWritten := make(map[string]bool)
+	for _, h := range rel.Hooks {
+		if skipTests && isTestHook(h) {
+			continue
+		}
+		if client.OutputDir == "" {
+			fmt.Fprintf(&manifest, "---\n# Source: %s\n%s\n", h.Path, h.Manifest)
+			continue
+		}
+		newDir := client.OutputDir
+		if client.UseReleaseName {
+			newDir = filepath.Join(client.OutputDir, client.ReleaseName)
+		}
+		if err := writeToFile(newDir, h.Path, h.Manifest, fileWritten[h.Path]); err != nil {
+			return "", err
+		}
+		fileWritten[h.Path] = true
+	}
+	return manifest.String(), nil
+}
+
 func isTestHook(h *release.Hook) bool {
 	for _, e := range h.Events {
 		if e == release.HookTest {
@@ -18,6 +18,8 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 )
@@ -89,6 +91,22 @@ func TestTemplateCmd(t *testing.T) {
 			cmd:    fmt.Sprintf("template '%s' --include-crds", chartPath),
 			golden: "output/template-with-crds.txt",
 		},
+		{
+			name:   "check set-json and set-literal",
+			cmd:    fmt.Sprintf(`template '%s' --show-only templates/service.yaml --set-json 'service={"type":"NodePort","externalPort":8080}' --set-literal 'service.name=web,http'`, chartPath),
+			golden: "output/template-set-json-literal.txt",
+		},
+		{
+			name:   "check encrypted values files",
+			cmd:    "template testdata/testcharts/alpine --values testdata/encrypted-values.yaml --age-identity testdata/age-key.txt --values testdata/encrypted-values-pgp.yaml --values-keyring testdata/helm-test-key.secret",
+			golden: "output/template-encrypted-values.txt",
+		},
+		{
+			name:      "check encrypted values files without a key",
+			cmd:       "template testdata/testcharts/alpine --values testdata/encrypted-values-pgp.yaml",
+			golden:    "output/template-encrypted-values-no-key.txt",
+			wantError: true,
+		},
 		{
 			name:   "template with show-only one",
 			cmd:    fmt.Sprintf("template '%s' --show-only templates/service.yaml", chartPath),
@@ -135,6 +153,63 @@ func TestTemplateCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestTemplateSnapshot(t *testing.T) {
+	snapshot := "testdata/output/template-snapshot"
+	tests := []cmdTestCase{{
+		name:   "check an up to date snapshot",
+		cmd:    fmt.Sprintf("template '%s' --snapshot-dir %s --snapshot-check", chartPath, snapshot),
+		golden: "output/template-snapshot-check.txt",
+	}, {
+		name:      "check an out of date snapshot",
+		cmd:       fmt.Sprintf("template '%s' --snapshot-dir %s --snapshot-check --set service.name=apache --set subcharta.enabled=false", chartPath, snapshot),
+		golden:    "output/template-snapshot-check-outdated.txt",
+		wantError: true,
+	}, {
+		name:      "check without a snapshot directory",
+		cmd:       fmt.Sprintf("template '%s' --snapshot-check", chartPath),
+		golden:    "output/template-snapshot-no-dir.txt",
+		wantError: true,
+	}, {
+		name:      "snapshot with an invalid scenario",
+		cmd:       fmt.Sprintf("template '%s' --snapshot-dir %s --snapshot-scenario apache", chartPath, snapshot),
+		golden:    "output/template-snapshot-invalid-scenario.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestTemplateSnapshotScenarios(t *testing.T) {
+	dir := t.TempDir()
+	values := filepath.Join(dir, "no-subcharta.yaml")
+	if err := ioutil.WriteFile(values, []byte("subcharta:\n  enabled: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := filepath.Join(dir, "snapshots")
+	cmd := fmt.Sprintf("template '%s' --snapshot-dir %s --snapshot-scenario default=%s --snapshot-scenario no-subcharta=%s",
+		chartPath, snapshot, filepath.Join(chartPath, "values.yaml"), values)
+
+	if _, out, err := executeActionCommand(cmd); err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+	for _, f := range []string{"default/service_subcharta.yaml", "no-subcharta/service_subchartb.yaml"} {
+		if _, err := os.Stat(filepath.Join(snapshot, f)); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(snapshot, "no-subcharta/service_subcharta.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected no service of subcharta in the snapshot without it, got %v", err)
+	}
+
+	_, out, err := executeActionCommand(cmd + " --snapshot-check")
+	if err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+	expect := fmt.Sprintf("%s is up to date\n%s is up to date\n", filepath.Join(snapshot, "default"), filepath.Join(snapshot, "no-subcharta"))
+	if out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+}
+
 func TestTemplateVersionCompletion(t *testing.T) {
 	repoFile := "testdata/helmhome/helm/repositories.yaml"
 	repoCache := "testdata/helmhome/helm/repository"
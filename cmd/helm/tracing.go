@@ -0,0 +1,57 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+
+	"helm.sh/helm/v3/pkg/tracing"
+)
+
+// startTracing installs the trace exporter selected by the HELM_TRACING_*
+// environment variables and starts the span of the command that cmd runs.
+// The returned function ends the span and flushes the exported spans.
+func startTracing(cmd *cobra.Command) (context.Context, func(error)) {
+	insecure, _ := strconv.ParseBool(os.Getenv("HELM_TRACING_INSECURE"))
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter: os.Getenv("HELM_TRACING_EXPORTER"),
+		Endpoint: os.Getenv("HELM_TRACING_ENDPOINT"),
+		Insecure: insecure,
+		File:     os.Getenv("HELM_TRACING_FILE"),
+	})
+	if err != nil {
+		warning("tracing is disabled: %s", err)
+	}
+
+	name := cmd.Name()
+	if c, _, err := cmd.Find(os.Args[1:]); err == nil {
+		name = c.CommandPath()
+	}
+	ctx, span := otel.Tracer("helm.sh/helm/v3/cmd/helm").Start(context.Background(), strings.ReplaceAll(name, " ", "."))
+	return ctx, func(err error) {
+		tracing.EndSpan(span, err)
+		if err := shutdown(context.Background()); err != nil {
+			debug("failed to flush the trace spans: %s", err)
+		}
+	}
+}
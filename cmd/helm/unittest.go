@@ -0,0 +1,182 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/junit"
+	"helm.sh/helm/v3/pkg/unittest"
+)
+
+var unittestHelp = `
+This command runs the unit tests of the templates of charts.
+
+The tests are the suites in the files 'tests/*_test.yaml' of a chart. Each test
+renders templates with its values, release and capabilities, without a
+cluster, and asserts about the documents rendered:
+
+    suite: deployment
+    templates:
+      - templates/deployment.yaml
+    tests:
+      - it: should set the image
+        set:
+          image.tag: "1.2.3"
+        asserts:
+          - isKind:
+              of: Deployment
+          - equals:
+              path: spec.template.spec.containers[0].image
+              value: nginx:1.2.3
+
+The assertions are 'equals', 'matchRegex', 'isKind', 'hasDocuments',
+'notExists', 'failedTemplate' and 'snapshot'. Each may be negated with
+'not: true' and restricted to a 'template' and a 'documentIndex'.
+
+A snapshot is written to 'tests/__snapshot__' the first time its assertion
+runs; the documents are then compared with it. Use '--update-snapshots' to
+replace the snapshots that differ.
+`
+
+func newUnitTestCmd(out io.Writer) *cobra.Command {
+	client := action.NewUnitTest()
+	var junitReport string
+
+	cmd := &cobra.Command{
+		Use:   "unittest [CHART]...",
+		Short: "run unit tests of the templates of charts",
+		Long:  unittestHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := []string{"."}
+			if len(args) > 0 {
+				paths = args
+			}
+
+			var results []*unittest.Result
+			for _, path := range paths {
+				fmt.Fprintf(out, "==> Testing %s\n", path)
+				result, err := client.Run(path)
+				if err != nil {
+					return err
+				}
+				printUnitTestResult(out, result)
+				fmt.Fprintln(out)
+				results = append(results, result)
+			}
+
+			if junitReport != "" {
+				if err := junit.WriteFile(junitReport, unitTestReport(results)...); err != nil {
+					return err
+				}
+			}
+			return printUnitTestSummary(out, results)
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&client.UpdateSnapshots, "update-snapshots", "u", false, "replace the snapshots that differ from the documents rendered")
+	f.StringVar(&junitReport, "junit-report", "", "write the result of each test to this file in JUnit XML format")
+
+	return cmd
+}
+
+// printUnitTestResult prints the result of each suite of a chart, and why its
+// tests failed.
+func printUnitTestResult(out io.Writer, result *unittest.Result) {
+	for _, s := range result.Suites {
+		switch {
+		case s.Err != nil:
+			fmt.Fprintf(out, "ERROR %s (%s): %s\n", s.Name, s.Path, s.Err)
+			continue
+		case s.Passed():
+			fmt.Fprintf(out, "PASS  %s (%s)\n", s.Name, s.Path)
+			continue
+		}
+		fmt.Fprintf(out, "FAIL  %s (%s)\n", s.Name, s.Path)
+		for _, t := range s.Tests {
+			if t.Passed() {
+				continue
+			}
+			fmt.Fprintf(out, "  - %s\n", t.Name)
+			for _, f := range t.Failures {
+				fmt.Fprintf(out, "      %s\n", strings.ReplaceAll(strings.TrimSuffix(f, "\n"), "\n", "\n      "))
+			}
+		}
+	}
+}
+
+// printUnitTestSummary prints the number of suites and tests that passed and
+// failed, and returns an error if any failed.
+func printUnitTestSummary(out io.Writer, results []*unittest.Result) error {
+	var suites, failedSuites, tests, failedTests, snapshots int
+	for _, r := range results {
+		snapshots += r.SnapshotsWritten
+		for _, s := range r.Suites {
+			suites++
+			if !s.Passed() {
+				failedSuites++
+			}
+			for _, t := range s.Tests {
+				tests++
+				if !t.Passed() {
+					failedTests++
+				}
+			}
+		}
+	}
+	fmt.Fprintf(out, "Charts:    %d total\n", len(results))
+	fmt.Fprintf(out, "Suites:    %d passed, %d failed, %d total\n", suites-failedSuites, failedSuites, suites)
+	fmt.Fprintf(out, "Tests:     %d passed, %d failed, %d total\n", tests-failedTests, failedTests, tests)
+	fmt.Fprintf(out, "Snapshots: %d written\n", snapshots)
+	if failedSuites > 0 {
+		return errors.Errorf("%d suite(s) failed", failedSuites)
+	}
+	return nil
+}
+
+// unitTestReport returns a JUnit suite for each suite of the charts. A suite
+// that could not be run is reported as a single failed test.
+func unitTestReport(results []*unittest.Result) []junit.TestSuite {
+	var suites []junit.TestSuite
+	for _, r := range results {
+		for _, s := range r.Suites {
+			suite := junit.TestSuite{Name: r.Chart + "/" + s.Path}
+			if s.Err != nil {
+				suite.Add(junit.TestCase{Name: s.Name, ClassName: r.Chart, Failure: &junit.Failure{Message: s.Err.Error()}})
+			}
+			for _, t := range s.Tests {
+				c := junit.TestCase{Name: t.Name, ClassName: r.Chart + "." + s.Name, Time: junit.Seconds(t.Duration)}
+				if !t.Passed() {
+					c.Failure = &junit.Failure{
+						Message: fmt.Sprintf("%d assertion(s) failed", len(t.Failures)),
+						Body:    strings.Join(t.Failures, "\n"),
+					}
+				}
+				suite.Add(c)
+			}
+			suites = append(suites, suite)
+		}
+	}
+	return suites
+}
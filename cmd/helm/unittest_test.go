@@ -0,0 +1,71 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/test"
+	"helm.sh/helm/v3/pkg/junit"
+	"helm.sh/helm/v3/pkg/unittest"
+)
+
+func TestUnitTestCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:      "run the unit tests of a chart",
+		cmd:       "unittest testdata/testcharts/unittest",
+		golden:    "output/unittest.txt",
+		wantError: true,
+	}, {
+		name:      "run the unit tests of a packaged chart",
+		cmd:       "unittest testdata/testcharts/compressedchart-0.1.0.tgz",
+		golden:    "output/unittest-packaged.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestUnitTestReport(t *testing.T) {
+	results := []*unittest.Result{{
+		Chart: "nginx",
+		Suites: []unittest.SuiteResult{{
+			Name: "deployment",
+			Path: "tests/deployment_test.yaml",
+			Tests: []unittest.TestResult{
+				{Name: "should set the image", Duration: 12 * time.Millisecond},
+				{Name: "should set the replicas", Duration: 3 * time.Millisecond, Failures: []string{
+					`asserts[0] equals: templates/deployment.yaml: spec.replicas: expected 3, got 1`,
+					`asserts[2] isKind: templates/deployment.yaml: expected kind Deployment, got StatefulSet`,
+				}},
+			},
+		}, {
+			Name: "service",
+			Path: "tests/service_test.yaml",
+			Err:  errors.New("tests[0]: 'it' is required"),
+		}},
+	}}
+
+	var buf bytes.Buffer
+	if err := junit.Write(&buf, unitTestReport(results)...); err != nil {
+		t.Fatal(err)
+	}
+	test.AssertGoldenString(t, buf.String(), "output/unittest-junit-report.xml")
+}
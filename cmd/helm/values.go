@@ -0,0 +1,141 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/encryption"
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+var valuesHelp = `
+This command consists of multiple subcommands to manage encrypted values files.
+
+Values files are encrypted either with age keys or with OpenPGP keys, as a
+whole or only some of their fields. 'helm install', 'helm upgrade' and
+'helm template' decrypt them in memory when given the private keys with
+'--age-identity' or '--values-keyring'.
+`
+
+func newValuesCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "values encrypt|decrypt|edit [ARGS]",
+		Short: "encrypt, decrypt and edit encrypted values files",
+		Long:  valuesHelp,
+		Args:  require.NoArgs,
+	}
+
+	cmd.AddCommand(newValuesEncryptCmd(out))
+	cmd.AddCommand(newValuesDecryptCmd(out))
+	cmd.AddCommand(newValuesEditCmd(out))
+
+	return cmd
+}
+
+// valuesKeyOptions are the keys values files are encrypted and decrypted
+// with.
+type valuesKeyOptions struct {
+	ageRecipients []string
+	ageIdentities []string
+	keyring       string
+	keyNames      []string
+	// passphraseFile is the file of the passphrase of the secret keys of
+	// keyring, which is prompted for if it is empty.
+	passphraseFile string
+}
+
+func (o *valuesKeyOptions) addEncryptFlags(f *pflag.FlagSet) {
+	f.StringArrayVar(&o.ageRecipients, "age-recipient", []string{}, "encrypt for an age public key (can specify multiple)")
+	f.StringArrayVar(&o.keyNames, "key", []string{}, "encrypt for the OpenPGP key of this name in the keyring (can specify multiple)")
+}
+
+func (o *valuesKeyOptions) addDecryptFlags(f *pflag.FlagSet) {
+	f.StringArrayVar(&o.ageIdentities, "age-identity", []string{}, "decrypt with the age identities in a file (can specify multiple)")
+	f.StringVar(&o.passphraseFile, "values-passphrase-file", "", `location of a file which contains the passphrase for the secret keys of --values-keyring, which is prompted for if it is needed and not given. Use "-" in order to read from stdin.`)
+}
+
+func (o *valuesKeyOptions) addKeyringFlag(f *pflag.FlagSet) {
+	f.StringVar(&o.keyring, "values-keyring", "", "location of an OpenPGP keyring, with public keys to encrypt or secret keys to decrypt")
+}
+
+// keys loads the keys of the options. The secret keys of the keyring are
+// decrypted only to decrypt values.
+func (o *valuesKeyOptions) keys(decrypt bool) (*encryption.Keys, error) {
+	keys := &encryption.Keys{}
+	var err error
+	if keys.AgeRecipients, err = encryption.ParseAgeRecipients(o.ageRecipients); err != nil {
+		return nil, err
+	}
+	for _, f := range o.ageIdentities {
+		ids, err := encryption.ReadAgeIdentities(f)
+		if err != nil {
+			return nil, err
+		}
+		keys.AgeIdentities = append(keys.AgeIdentities, ids...)
+	}
+
+	if o.keyring == "" {
+		if len(o.keyNames) > 0 {
+			return nil, errors.New("--values-keyring is required to encrypt for an OpenPGP key")
+		}
+		return keys, nil
+	}
+	ring, err := provenance.NewFromKeyring(o.keyring, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load keyring")
+	}
+	if decrypt {
+		fetch, err := action.NewPassphraseFetcher(o.passphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := encryption.DecryptPGPKeys(ring.KeyRing, fetch); err != nil {
+			return nil, err
+		}
+	}
+	keys.PGPKeyRing = ring.KeyRing
+	for _, name := range o.keyNames {
+		s, err := provenance.NewFromKeyring(o.keyring, name)
+		if err != nil {
+			return nil, err
+		}
+		keys.PGPRecipients = append(keys.PGPRecipients, s.Entity)
+	}
+	return keys, nil
+}
+
+// writeValues writes values to their file in place, or to out.
+func writeValues(out io.Writer, filename string, data []byte, inPlace bool) error {
+	if !inPlace {
+		_, err := out.Write(data)
+		return err
+	}
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, fi.Mode())
+}
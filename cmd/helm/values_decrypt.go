@@ -0,0 +1,83 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/encryption"
+)
+
+const valuesDecryptDesc = `
+This command decrypts a values file encrypted with 'helm values encrypt', with
+the age identities of '--age-identity' or the secret keys of '--values-keyring'.
+
+    $ helm values decrypt secrets.yaml --age-identity ~/.config/age/keys.txt
+
+The decrypted file is printed, or written in place with '--in-place'. There is
+no need to decrypt values files to install or upgrade a chart, as Helm decrypts
+them in memory.
+`
+
+type valuesDecryptOptions struct {
+	valuesKeyOptions
+	inPlace bool
+}
+
+func newValuesDecryptCmd(out io.Writer) *cobra.Command {
+	o := &valuesDecryptOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "decrypt FILE",
+		Short: "decrypt a values file",
+		Long:  valuesDecryptDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(out, args[0])
+		},
+	}
+
+	f := cmd.Flags()
+	o.addDecryptFlags(f)
+	o.addKeyringFlag(f)
+	f.BoolVarP(&o.inPlace, "in-place", "i", false, "write the decrypted file in place instead of printing it")
+
+	return cmd
+}
+
+func (o *valuesDecryptOptions) run(out io.Writer, filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if !encryption.IsEncrypted(data) {
+		return errors.Errorf("%s is not encrypted", filename)
+	}
+	keys, err := o.keys(true)
+	if err != nil {
+		return err
+	}
+	if data, err = encryption.Decrypt(data, keys); err != nil {
+		return errors.Wrapf(err, "failed to decrypt %s", filename)
+	}
+	return writeValues(out, filename, data, o.inPlace)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/encryption"
+)
+
+const valuesEditDesc = `
+This command opens an encrypted values file decrypted in an editor, and
+encrypts it again once the editor exits. The same fields are encrypted again,
+or the whole file if it was encrypted as a whole.
+
+The file is decrypted with '--age-identity' or '--values-keyring', and encrypted for
+'--age-recipient' or '--key', which must list all of its recipients:
+
+    $ helm values edit secrets.yaml --age-identity ~/.config/age/keys.txt \
+        --age-recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+
+The editor is the one of the EDITOR environment variable, or vi.
+`
+
+func newValuesEditCmd(out io.Writer) *cobra.Command {
+	o := &valuesKeyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "edit FILE",
+		Short: "edit an encrypted values file",
+		Long:  valuesEditDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.edit(out, args[0])
+		},
+	}
+
+	f := cmd.Flags()
+	o.addEncryptFlags(f)
+	o.addDecryptFlags(f)
+	o.addKeyringFlag(f)
+
+	return cmd
+}
+
+func (o *valuesKeyOptions) edit(out io.Writer, filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if !encryption.IsEncrypted(data) {
+		return errors.Errorf("%s is not encrypted", filename)
+	}
+	if len(o.ageRecipients) == 0 && len(o.keyNames) == 0 {
+		return errors.New("--age-recipient or --key is required to encrypt the edited file")
+	}
+	keys, err := o.keys(true)
+	if err != nil {
+		return err
+	}
+	fields, err := encryption.EncryptedFields(data)
+	if err != nil {
+		return err
+	}
+	plain, err := encryption.Decrypt(data, keys)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decrypt %s", filename)
+	}
+
+	edited, err := editValues(plain)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, plain) {
+		fmt.Fprintf(out, "%s has not changed\n", filename)
+		return nil
+	}
+
+	if len(fields) == 0 {
+		data, err = encryption.Encrypt(edited, keys)
+	} else {
+		data, err = encryption.EncryptFields(edited, existingFields(edited, fields), keys)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to encrypt %s", filename)
+	}
+	return writeValues(out, filename, data, true)
+}
+
+// editValues opens values in the editor, in a temporary file only readable by
+// the user, and returns them once edited.
+func editValues(data []byte) ([]byte, error) {
+	f, err := ioutil.TempFile("", "helm-values-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "failed to run editor %s", editor[0])
+	}
+	return ioutil.ReadFile(f.Name())
+}
+
+// existingFields returns the fields still in the edited values, so that the
+// ones removed are not encrypted again.
+func existingFields(data []byte, fields []string) []string {
+	var vals map[string]interface{}
+	if err := yaml.Unmarshal(data, &vals); err != nil {
+		// EncryptFields reports the error.
+		return fields
+	}
+	var existing []string
+	for _, field := range fields {
+		v := interface{}(vals)
+		for _, k := range strings.Split(field, ".") {
+			m, _ := v.(map[string]interface{})
+			if v = m[k]; v == nil {
+				break
+			}
+		}
+		if v != nil {
+			existing = append(existing, field)
+		}
+	}
+	return existing
+}
@@ -0,0 +1,93 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/encryption"
+)
+
+const valuesEncryptDesc = `
+This command encrypts a values file, for age public keys given with
+'--age-recipient' or for OpenPGP keys of a keyring given with '--key'.
+
+The whole file is encrypted, unless fields are given with '--field' by their
+dotted paths, in which case only their values are:
+
+    $ helm values encrypt secrets.yaml --age-recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+    $ helm values encrypt values.yaml --field database.password --values-keyring ~/.gnupg/pubring.gpg --key me@example.com
+
+The encrypted file is printed, or written in place with '--in-place'.
+`
+
+type valuesEncryptOptions struct {
+	valuesKeyOptions
+	fields  []string
+	inPlace bool
+}
+
+func newValuesEncryptCmd(out io.Writer) *cobra.Command {
+	o := &valuesEncryptOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "encrypt FILE",
+		Short: "encrypt a values file",
+		Long:  valuesEncryptDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(out, args[0])
+		},
+	}
+
+	f := cmd.Flags()
+	o.addEncryptFlags(f)
+	o.addKeyringFlag(f)
+	f.StringArrayVar(&o.fields, "field", []string{}, "encrypt only the value of the field at this dotted path, like database.password (can specify multiple)")
+	f.BoolVarP(&o.inPlace, "in-place", "i", false, "write the encrypted file in place instead of printing it")
+
+	return cmd
+}
+
+func (o *valuesEncryptOptions) run(out io.Writer, filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	keys, err := o.keys(false)
+	if err != nil {
+		return err
+	}
+
+	if len(o.fields) == 0 {
+		if encryption.IsEncrypted(data) {
+			return errors.Errorf("%s is already encrypted", filename)
+		}
+		data, err = encryption.Encrypt(data, keys)
+	} else {
+		data, err = encryption.EncryptFields(data, o.fields, keys)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to encrypt %s", filename)
+	}
+	return writeValues(out, filename, data, o.inPlace)
+}
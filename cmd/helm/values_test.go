@@ -0,0 +1,142 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/encryption"
+)
+
+// testAgeRecipient is the public key of testdata/age-key.txt.
+const testAgeRecipient = "age1f927pscfh46kzp0lh79r0xclrsyggqe80x3vygz85c8x26t9vsesg7j8la"
+
+func TestValuesDecryptCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "decrypt fields with age",
+		cmd:    "values decrypt testdata/encrypted-values.yaml --age-identity testdata/age-key.txt",
+		golden: "output/values-decrypt.txt",
+	}, {
+		name:   "decrypt a whole file with OpenPGP",
+		cmd:    "values decrypt testdata/encrypted-values-pgp.yaml --values-keyring testdata/helm-test-key.secret",
+		golden: "output/values-decrypt-pgp.txt",
+	}, {
+		name:      "decrypt without an identity",
+		cmd:       "values decrypt testdata/encrypted-values.yaml",
+		golden:    "output/values-decrypt-no-identity.txt",
+		wantError: true,
+	}, {
+		name:      "decrypt a file that is not encrypted",
+		cmd:       "values decrypt testdata/testcharts/alpine/extra_values.yaml --age-identity testdata/age-key.txt",
+		golden:    "output/values-decrypt-not-encrypted.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestValuesEncryptCmd(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "values.yaml")
+	values := "image: nginx\ndatabase:\n  password: s3cr3t\n"
+
+	for _, tt := range []struct {
+		name  string
+		flags string
+	}{
+		{"whole file with age", "--age-recipient " + testAgeRecipient},
+		{"fields with age", "--field database.password --age-recipient " + testAgeRecipient},
+		{"whole file with OpenPGP", "--values-keyring testdata/helm-test-key.pub --key helm-testing@helm.sh"},
+	} {
+		if err := ioutil.WriteFile(file, []byte(values), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, out, err := executeActionCommand(fmt.Sprintf("values encrypt %s -i %s", file, tt.flags)); err != nil {
+			t.Fatalf("%s: %s\n%s", tt.name, err, out)
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !encryption.IsEncrypted(data) || strings.Contains(string(data), "s3cr3t") {
+			t.Errorf("%s: expected the file to be encrypted, got\n%s", tt.name, data)
+		}
+		if strings.HasPrefix(tt.name, "fields") && !strings.HasPrefix(string(data), "image: nginx\n") {
+			t.Errorf("%s: expected the other fields to be left as they are, got\n%s", tt.name, data)
+		}
+	}
+
+	_, _, err := executeActionCommand(fmt.Sprintf("values encrypt %s --age-recipient %s", file, testAgeRecipient))
+	if err == nil || err.Error() != file+" is already encrypted" {
+		t.Errorf("expected an error encrypting an encrypted file, got %v", err)
+	}
+	_, _, err = executeActionCommand(fmt.Sprintf("values encrypt %s --key helm-testing@helm.sh", file))
+	if err == nil || err.Error() != "--values-keyring is required to encrypt for an OpenPGP key" {
+		t.Errorf("expected an error encrypting for an OpenPGP key without a keyring, got %v", err)
+	}
+}
+
+func TestValuesEditCmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the editor of the test is sed")
+	}
+	defer resetEnv()()
+
+	file := filepath.Join(t.TempDir(), "values.yaml")
+	data, err := ioutil.ReadFile("testdata/encrypted-values.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("EDITOR", "sed -i.bak -e s/encrypted-name/edited-name/ -e s/plain/edited-plain/")
+	cmd := fmt.Sprintf("values edit %s --age-identity testdata/age-key.txt --age-recipient %s", file, testAgeRecipient)
+	if _, out, err := executeActionCommand(cmd); err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+	os.Remove(file + ".bak")
+
+	data, err = ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "edited-name") || !strings.Contains(string(data), "Name: edited-plain") {
+		t.Errorf("expected the same fields to be encrypted again, got\n%s", data)
+	}
+	_, out, err := executeActionCommand(fmt.Sprintf("values decrypt %s --age-identity testdata/age-key.txt", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "# The name of the pod.\nName: \"edited-name\"\ntest:\n  Name: edited-plain\n"; out != expect {
+		t.Errorf("expected the edited values\n%s\ngot\n%s", expect, out)
+	}
+
+	os.Setenv("EDITOR", "true")
+	if _, out, err := executeActionCommand(cmd); err != nil || out != file+" has not changed\n" {
+		t.Errorf("expected the file not to change, got %q (%v)", out, err)
+	}
+	if _, _, err := executeActionCommand(fmt.Sprintf("values edit %s --age-identity testdata/age-key.txt", file)); err == nil {
+		t.Error("expected an error editing without recipients")
+	}
+}
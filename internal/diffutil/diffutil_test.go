@@ -0,0 +1,39 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diffutil
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	for _, tt := range []struct {
+		name, a, b, expect string
+	}{
+		{"same", "a\nb\n", "a\nb\n", ""},
+		{"changed", "a\nb\n", "a\nc\n", "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"},
+		{"added", "", "a\n", "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := Unified(tt.a, tt.b, "old", "new")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff != tt.expect {
+				t.Errorf("expected diff %q, got %q", tt.expect, diff)
+			}
+		})
+	}
+}
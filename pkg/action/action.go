@@ -18,6 +18,7 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -26,16 +27,25 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"helm.sh/helm/v3/pkg/audit"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/errdefs"
+	"helm.sh/helm/v3/pkg/helmpath"
 	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/logging"
+	"helm.sh/helm/v3/pkg/notify"
+	"helm.sh/helm/v3/pkg/plugin"
 	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/release"
@@ -43,6 +53,7 @@ import (
 	"helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	"helm.sh/helm/v3/pkg/time"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
 // Timestamper is a function capable of producing a timestamp.Timestamper.
@@ -94,15 +105,78 @@ type Configuration struct {
 	// Capabilities describes the capabilities of the Kubernetes cluster.
 	Capabilities *chartutil.Capabilities
 
+	// Audit receives a record of each install, upgrade, rollback, uninstall
+	// and test. Operations are not audited if it is nil.
+	Audit audit.Sink
+
+	// AuditFlags are the command-line flags recorded with each audit record.
+	AuditFlags map[string]string
+
+	// PluginsDirectory is where the plugins supplying storage drivers, used
+	// with a "plugin:NAME" driver, are looked for. PluginEnv are environment
+	// variables set for them, like the ones of cli.EnvSettings.EnvVars.
+	PluginsDirectory string
+	PluginEnv        map[string]string
+
+	// HookConcurrency is the maximum number of hooks of the same weight
+	// that are run concurrently. Hooks are run one at a time if it is less
+	// than 2.
+	HookConcurrency int
+
+	// Notifier is notified when an install, upgrade, rollback or uninstall
+	// starts and completes. Nothing is notified if it is nil.
+	Notifier *notify.Notifier
+
+	// Logger receives the diagnostic output of the actions. Init passes it
+	// on to the Kubernetes client and the release storage. If nil, Log is
+	// used.
+	Logger logging.Logger
+
+	// Log is the printf-style logger used before Logger was introduced.
+	//
+	// Deprecated: set Logger instead. Log is only used if Logger is nil.
 	Log func(string, ...interface{})
 }
 
+// logger returns the logger of the configuration, wrapping the legacy Log
+// function if Logger is not set.
+func (cfg *Configuration) logger() logging.Logger {
+	if cfg.Logger != nil {
+		return cfg.Logger
+	}
+	return logging.FromFunc(cfg.Log)
+}
+
+// valuesError classifies an error of chartutil.ToRenderValues. It is a schema
+// violation only if the values do not validate against the chart schemas.
+func valuesError(ch *chart.Chart, vals map[string]interface{}, err error) error {
+	coalesced, cerr := chartutil.CoalesceValues(ch, vals)
+	if cerr == nil && chartutil.ValidateAgainstSchema(ch, coalesced) != nil {
+		return errdefs.Wrap(errdefs.TypeSchemaViolation, err, "chart", ch.Name())
+	}
+	return err
+}
+
+// buildError classifies an error of kube.Interface.Build. It is a schema
+// violation only if the manifest is invalid or fails the OpenAPI validation;
+// discovery, connection and authorization errors keep their own type.
+func buildError(err error) error {
+	// cli-runtime reports OpenAPI validation failures as plain errors.
+	if apierrors.IsInvalid(err) || strings.Contains(err.Error(), "error validating data") {
+		return errdefs.Wrap(errdefs.TypeSchemaViolation, err)
+	}
+	return err
+}
+
 // renderResources renders the templates in a chart
 //
 // TODO: This function is badly in need of a refactor.
 // TODO: As part of the refactor the duplicate code in cmd/helm/template.go should be removed
 //       This code has to do with writing files to disk.
-func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Values, releaseName, outputDir string, subNotes, useReleaseName, includeCrds bool, pr postrender.PostRenderer, dryRun bool) ([]*release.Hook, *bytes.Buffer, string, error) {
+func (cfg *Configuration) renderResources(ctx context.Context, ch *chart.Chart, values chartutil.Values, releaseName, outputDir string, subNotes, useReleaseName, includeCrds bool, pr postrender.PostRenderer, dryRun bool) (_ []*release.Hook, _ *bytes.Buffer, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "helm.render", trace.WithAttributes(attribute.String("chart", ch.Name())))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	hs := []*release.Hook{}
 	b := bytes.NewBuffer(nil)
 
@@ -113,7 +187,9 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 
 	if ch.Metadata.KubeVersion != "" {
 		if !chartutil.IsCompatibleRange(ch.Metadata.KubeVersion, caps.KubeVersion.String()) {
-			return hs, b, "", errors.Errorf("chart requires kubeVersion: %s which is incompatible with Kubernetes %s", ch.Metadata.KubeVersion, caps.KubeVersion.String())
+			return hs, b, "", errdefs.Wrap(errdefs.TypeRenderError,
+				errors.Errorf("chart requires kubeVersion: %s which is incompatible with Kubernetes %s", ch.Metadata.KubeVersion, caps.KubeVersion.String()),
+				"chart", ch.Name(), "kubeVersion", caps.KubeVersion.String())
 		}
 	}
 
@@ -130,13 +206,13 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 		if err != nil {
 			return hs, b, "", err
 		}
-		files, err2 = engine.RenderWithClient(ch, values, restConfig)
+		files, err2 = engine.RenderWithClientContext(ctx, ch, values, restConfig)
 	} else {
-		files, err2 = engine.Render(ch, values)
+		files, err2 = engine.RenderContext(ctx, ch, values)
 	}
 
 	if err2 != nil {
-		return hs, b, "", err2
+		return hs, b, "", errdefs.Wrap(errdefs.TypeRenderError, err2, "chart", ch.Name())
 	}
 
 	// NOTES.txt gets rendered like all the other files, but because it's not a hook nor a resource,
@@ -175,7 +251,7 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 			}
 			fmt.Fprintf(b, "---\n# Source: %s\n%s\n", name, content)
 		}
-		return hs, b, "", err
+		return hs, b, "", errdefs.Wrap(errdefs.TypeRenderError, err, "chart", ch.Name())
 	}
 
 	// Aggregate all valid manifests into one big doc.
@@ -258,8 +334,7 @@ func (cfg *Configuration) getCapabilities() (*chartutil.Capabilities, error) {
 	apiVersions, err := GetVersionSet(dc)
 	if err != nil {
 		if discovery.IsGroupDiscoveryFailedError(err) {
-			cfg.Log("WARNING: The Kubernetes server has an orphaned API service. Server reports: %s", err)
-			cfg.Log("WARNING: To fix this, kubectl delete apiservice <service-name>")
+			cfg.logger().Warn("the Kubernetes server has an orphaned API service; to fix this, kubectl delete apiservice <service-name>", "error", err)
 		} else {
 			return nil, errors.Wrap(err, "could not get apiVersions from Kubernetes")
 		}
@@ -357,13 +432,25 @@ func GetVersionSet(client discovery.ServerResourcesInterface) (chartutil.Version
 // recordRelease with an update operation in case reuse has been set.
 func (cfg *Configuration) recordRelease(r *release.Release) {
 	if err := cfg.Releases.Update(r); err != nil {
-		cfg.Log("warning: Failed to update release %s: %s", r.Name, err)
+		cfg.logger().Warn("failed to update release", "release", r.Name, "error", err)
 	}
 }
 
 // Init initializes the action configuration
+//
+// If Logger is not set, it logs to log. Otherwise log is only kept as the
+// deprecated Log function.
 func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namespace, helmDriver string, log DebugLog) error {
+	if cfg.Logger == nil {
+		cfg.Logger = logging.FromFunc(log)
+	}
+	if log == nil {
+		log = logging.Printf(cfg.Logger)
+	}
+	driverLog := logging.Printf(cfg.Logger)
+
 	kc := kube.New(getter)
+	kc.Logger = cfg.Logger
 	kc.Log = log
 
 	lazyClient := &lazyClient{
@@ -375,11 +462,11 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 	switch helmDriver {
 	case "secret", "secrets", "":
 		d := driver.NewSecrets(newSecretClient(lazyClient))
-		d.Log = log
+		d.Log = driverLog
 		store = storage.Init(d)
 	case "configmap", "configmaps":
 		d := driver.NewConfigMaps(newConfigMapClient(lazyClient))
-		d.Log = log
+		d.Log = driverLog
 		store = storage.Init(d)
 	case "memory":
 		var d *driver.Memory
@@ -399,17 +486,37 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 	case "sql":
 		d, err := driver.NewSQL(
 			os.Getenv("HELM_DRIVER_SQL_CONNECTION_STRING"),
-			log,
+			driverLog,
 			namespace,
 		)
 		if err != nil {
 			panic(fmt.Sprintf("Unable to instantiate SQL driver: %v", err))
 		}
 		store = storage.Init(d)
+	case "file":
+		path := os.Getenv("HELM_DRIVER_FILE_PATH")
+		if path == "" {
+			path = helmpath.DataPath("releases")
+		}
+		d, err := driver.NewFile(path, driverLog, namespace)
+		if err != nil {
+			panic(fmt.Sprintf("Unable to instantiate file driver: %v", err))
+		}
+		store = storage.Init(d)
 	default:
-		// Not sure what to do here.
-		panic("Unknown driver in HELM_DRIVER: " + helmDriver)
+		name := strings.TrimPrefix(helmDriver, "plugin:")
+		if name == helmDriver || name == "" {
+			// Not sure what to do here.
+			panic("Unknown driver in HELM_DRIVER: " + helmDriver)
+		}
+		d, err := cfg.newPluginDriver(name, namespace)
+		if err != nil {
+			panic(fmt.Sprintf("Unable to instantiate storage driver plugin %q: %v", name, err))
+		}
+		d.Log = driverLog
+		store = storage.Init(d)
 	}
+	store.Logger = cfg.Logger
 
 	cfg.RESTClientGetter = getter
 	cfg.KubeClient = kc
@@ -418,3 +525,70 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 
 	return nil
 }
+
+// releasesInNamespace returns the release storage of the given namespace.
+// The secrets and configmaps drivers are bound to a namespace. The other
+// drivers store the releases of all namespaces and are shared, callers
+// select the namespace with driver.NamespaceSetter.
+func (cfg *Configuration) releasesInNamespace(namespace string) (*storage.Storage, error) {
+	var d driver.Driver
+	switch cur := cfg.Releases.Driver.(type) {
+	case *driver.Secrets:
+		clientset, err := cfg.KubernetesClientSet()
+		if err != nil {
+			return nil, err
+		}
+		sd := driver.NewSecrets(clientset.CoreV1().Secrets(namespace))
+		sd.Log = cur.Log
+		d = sd
+	case *driver.ConfigMaps:
+		clientset, err := cfg.KubernetesClientSet()
+		if err != nil {
+			return nil, err
+		}
+		cd := driver.NewConfigMaps(clientset.CoreV1().ConfigMaps(namespace))
+		cd.Log = cur.Log
+		d = cd
+	default:
+		return cfg.Releases, nil
+	}
+
+	store := storage.Init(d)
+	store.Logger = cfg.Releases.Logger
+	store.Log = cfg.Releases.Log
+	return store, nil
+}
+
+// newPluginDriver creates a storage driver delegating to the plugin with the
+// given name in PluginsDirectory. The plugin runs with PluginEnv and the
+// HELM_PLUGIN_NAME and HELM_PLUGIN_DIR variables added to the environment of
+// Helm, which is left unchanged.
+func (cfg *Configuration) newPluginDriver(name, namespace string) (*driver.Plugin, error) {
+	if cfg.PluginsDirectory == "" {
+		return nil, errors.New("no plugins directory configured")
+	}
+	p, err := plugin.FindStorageDriver(cfg.PluginsDirectory, name)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{}
+	for k, v := range cfg.PluginEnv {
+		vars[k] = v
+	}
+	vars["HELM_PLUGIN_NAME"] = p.Metadata.Name
+	vars["HELM_PLUGIN_DIR"] = p.Dir
+	env := os.Environ()
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	expand := func(k string) string {
+		if v, ok := vars[k]; ok {
+			return v
+		}
+		return os.Getenv(k)
+	}
+
+	parts := strings.Split(os.Expand(p.Metadata.StorageDriver.Command, expand), " ")
+	return driver.NewPlugin(parts[0], parts[1:], env, namespace), nil
+}
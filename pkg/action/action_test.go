@@ -16,16 +16,22 @@ limitations under the License.
 package action
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	fakeclientset "k8s.io/client-go/kubernetes/fake"
 
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
 	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/logging"
 	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage"
@@ -72,6 +78,22 @@ metadata:
 data:
   name: value`
 
+var manifestWithFailureHook = `kind: ConfigMap
+metadata:
+  name: alert
+  annotations:
+    "helm.sh/hook": install-failed,upgrade-failed
+data:
+  name: value`
+
+var manifestWithAtomicRollbackHook = `kind: ConfigMap
+metadata:
+  name: restored
+  annotations:
+    "helm.sh/hook": post-rollback-on-atomic
+data:
+  name: value`
+
 var manifestWithTestHook = `kind: Pod
   metadata:
 	name: finding-nemo,
@@ -203,6 +225,15 @@ func withSampleTemplates() chartOption {
 	}
 }
 
+func withFailureHook() chartOption {
+	return func(opts *chartOptions) {
+		opts.Templates = append(opts.Templates,
+			&chart.File{Name: "templates/alert", Data: []byte(manifestWithFailureHook)},
+			&chart.File{Name: "templates/restored", Data: []byte(manifestWithAtomicRollbackHook)},
+		)
+	}
+}
+
 func withSampleIncludingIncorrectTemplates() chartOption {
 	return func(opts *chartOptions) {
 		sampleTemplates := []*chart.File{
@@ -289,3 +320,79 @@ func TestGetVersionSet(t *testing.T) {
 		t.Error("Non-existent version is reported found.")
 	}
 }
+
+func TestInitStorageDriverPlugin(t *testing.T) {
+	plugdir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(plugdir, "kvstore"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	metadata := "name: kvstore\nversion: 0.1.0\nstorageDriver:\n  command: \"$HELM_PLUGIN_DIR/kvstore serve\"\n"
+	if err := ioutil.WriteFile(filepath.Join(plugdir, "kvstore", "plugin.yaml"), []byte(metadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Configuration{PluginsDirectory: plugdir, PluginEnv: map[string]string{"HELM_NAMESPACE": "default"}}
+	if err := cfg.Init(nil, "default", "plugin:kvstore", func(_ string, _ ...interface{}) {}); err != nil {
+		t.Fatal(err)
+	}
+	if name := cfg.Releases.Driver.Name(); name != driver.PluginDriverName {
+		t.Errorf("Expected driver %q, got %q", driver.PluginDriverName, name)
+	}
+	for _, k := range []string{"HELM_PLUGIN_NAME", "HELM_PLUGIN_DIR"} {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Errorf("Expected %s not to be set in the environment of helm, got %q", k, v)
+		}
+	}
+}
+
+func TestInitLogger(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &Configuration{Logger: logging.New(&buf, logging.LevelDebug, logging.FormatJSON)}
+	if err := cfg.Init(nil, "default", "memory", nil); err != nil {
+		t.Fatal(err)
+	}
+	if kc := cfg.KubeClient.(*kube.Client); kc.Logger != cfg.Logger {
+		t.Error("expected the Kubernetes client to use the logger of the configuration")
+	}
+	if cfg.Releases.Logger != cfg.Logger {
+		t.Error("expected the release storage to use the logger of the configuration")
+	}
+
+	// The deprecated Log function writes debug entries to the logger.
+	cfg.Log("legacy %s", "message")
+	cfg.recordRelease(releaseStub())
+
+	var entries []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) < 2 {
+		t.Fatalf("expected at least 2 entries, got %d", len(entries))
+	}
+	if entries[0]["level"] != "debug" || entries[0]["msg"] != "legacy message" {
+		t.Errorf("unexpected entry for the deprecated Log function: %v", entries[0])
+	}
+	last := entries[len(entries)-1]
+	if last["level"] != "warn" || last["msg"] != "failed to update release" || last["release"] != "angry-panda" {
+		t.Errorf("unexpected entry for the failed update: %v", last)
+	}
+}
+
+func TestLoggerShim(t *testing.T) {
+	var lines []string
+	cfg := &Configuration{Log: func(format string, v ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, v...))
+	}}
+	cfg.logger().Warn("upgrade failed", "release", "foo")
+	if len(lines) != 1 || lines[0] != "warning: upgrade failed release=foo" {
+		t.Errorf("unexpected output of the Log function: %q", lines)
+	}
+
+	cfg.Log = nil
+	cfg.logger().Warn("discarded")
+}
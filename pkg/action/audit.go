@@ -0,0 +1,96 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"helm.sh/helm/v3/pkg/audit"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// auditIdentity returns the identity the Kubernetes client authenticates as.
+func (cfg *Configuration) auditIdentity() audit.Identity {
+	if cfg.RESTClientGetter == nil {
+		return audit.IdentityFromConfig(nil)
+	}
+	config, err := cfg.RESTClientGetter.ToRESTConfig()
+	if err != nil {
+		cfg.logger().Warn("audit: failed to load the client configuration", "error", err)
+		return audit.IdentityFromConfig(nil)
+	}
+	return audit.IdentityFromConfig(config)
+}
+
+// observedRelease returns the last revision of the named release for the
+// audit records and notifications of an operation. It returns nil if neither
+// is configured or the release does not exist.
+func (cfg *Configuration) observedRelease(name string) *release.Release {
+	if cfg.Audit == nil && cfg.Notifier == nil {
+		return nil
+	}
+	rel, err := cfg.Releases.Last(name)
+	if err != nil {
+		return nil
+	}
+	return rel
+}
+
+// recordAudit writes the audit record of an operation on the named release.
+// rel is the revision the operation created or acted upon, if any, and prev
+// the revision it replaced. ch is the chart requested when rel is nil. A
+// failure to write the record is logged and does not fail the operation.
+func (cfg *Configuration) recordAudit(op audit.Operation, name, namespace string, ch *chart.Chart, prev, rel *release.Release, err error) {
+	if cfg.Audit == nil {
+		return
+	}
+
+	rec := &audit.Record{
+		Time:      Timestamper().Time,
+		Operation: op,
+		Release:   name,
+		Namespace: namespace,
+		User:      cfg.auditIdentity(),
+		Flags:     audit.RedactFlags(cfg.AuditFlags),
+		Outcome:   audit.OutcomeSuccess,
+	}
+	if err != nil {
+		rec.Outcome = audit.OutcomeFailure
+		rec.Error = err.Error()
+	}
+	if rel != nil {
+		rec.Release = rel.Name
+		rec.Namespace = rel.Namespace
+		rec.Revision = rel.Version
+		ch = rel.Chart
+	}
+	rec.Chart = audit.NewChartInfo(ch)
+
+	switch op {
+	case audit.OperationInstall, audit.OperationUpgrade, audit.OperationRollback:
+		if rel != nil {
+			var old map[string]interface{}
+			if prev != nil {
+				old = prev.Config
+			}
+			rec.ValuesDiff = audit.DiffValues(old, rel.Config)
+		}
+	}
+
+	if err := cfg.Audit.Write(rec); err != nil {
+		cfg.logger().Warn("audit: failed to record the operation", "operation", op, "release", rec.Release, "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/audit"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+type recordingAuditSink struct {
+	records []*audit.Record
+}
+
+func (s *recordingAuditSink) Write(rec *audit.Record) error {
+	s.records = append(s.records, rec)
+	return nil
+}
+
+func TestAuditInstallUpgradeUninstall(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	sink := &recordingAuditSink{}
+	instAction := installAction(t)
+	instAction.cfg.Audit = sink
+	instAction.cfg.AuditFlags = map[string]string{"namespace": "spaced", "set": "password=hunter2"}
+
+	_, err := instAction.Run(buildChart(), map[string]interface{}{"tag": "1.0"})
+	req.NoError(err)
+	req.Len(sink.records, 1)
+
+	rec := sink.records[0]
+	is.Equal(audit.OperationInstall, rec.Operation)
+	is.Equal("test-install-release", rec.Release)
+	is.Equal("spaced", rec.Namespace)
+	is.Equal(1, rec.Revision)
+	is.Equal(audit.OutcomeSuccess, rec.Outcome)
+	is.Equal(map[string]string{"namespace": "spaced", "set": "REDACTED"}, rec.Flags)
+	is.Equal("hello", rec.Chart.Name)
+	is.Equal(audit.ChartDigest(buildChart()), rec.Chart.Digest)
+	is.Equal([]audit.ValueChange{{Path: "tag", Change: audit.ValueAdded}}, rec.ValuesDiff)
+	is.Equal("unknown", rec.User.AuthMethod)
+
+	upAction := NewUpgrade(instAction.cfg)
+	upAction.Namespace = "spaced"
+	_, err = upAction.Run("test-install-release", buildChart(), map[string]interface{}{"tag": "1.1"})
+	req.NoError(err)
+	req.Len(sink.records, 2)
+
+	rec = sink.records[1]
+	is.Equal(audit.OperationUpgrade, rec.Operation)
+	is.Equal(2, rec.Revision)
+	is.Equal([]audit.ValueChange{{Path: "tag", Change: audit.ValueChanged}}, rec.ValuesDiff)
+
+	unAction := NewUninstall(instAction.cfg)
+	unAction.DisableHooks = true
+	_, err = unAction.Run("test-install-release")
+	req.NoError(err)
+	req.Len(sink.records, 3)
+
+	rec = sink.records[2]
+	is.Equal(audit.OperationUninstall, rec.Operation)
+	is.Equal("spaced", rec.Namespace)
+	is.Equal(2, rec.Revision)
+	is.Empty(rec.ValuesDiff)
+}
+
+func TestAuditFailure(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	sink := &recordingAuditSink{}
+	upAction := upgradeAction(t)
+	upAction.cfg.Audit = sink
+	rel := releaseStub()
+	rel.Name = "previous-release"
+	rel.Info.Status = release.StatusDeployed
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.UpdateError = errors.New("update failed")
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.Error(err)
+	req.Len(sink.records, 1)
+
+	rec := sink.records[0]
+	is.Equal(audit.OperationUpgrade, rec.Operation)
+	is.Equal(audit.OutcomeFailure, rec.Outcome)
+	is.Contains(rec.Error, "update failed")
+	is.Equal(2, rec.Revision)
+
+	// Operations that fail before a revision exists are recorded as well.
+	_, err = upAction.Run("missing", buildChart(), map[string]interface{}{})
+	req.Error(err)
+	req.Len(sink.records, 2)
+	is.Equal("missing", sink.records[1].Release)
+	is.Equal("spaced", sink.records[1].Namespace)
+	is.Equal(0, sink.records[1].Revision)
+	is.Equal("hello", sink.records[1].Chart.Name)
+}
+
+func TestAuditDryRun(t *testing.T) {
+	sink := &recordingAuditSink{}
+	instAction := installAction(t)
+	instAction.cfg.Audit = sink
+	instAction.DryRun = true
+
+	_, err := instAction.Run(buildChart(), map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Empty(t, sink.records)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/errdefs"
+	"helm.sh/helm/v3/pkg/kube"
+)
+
+// maxFailureEvents is the number of Kubernetes events reported with a
+// failure.
+const maxFailureEvents = 10
+
+// EventsError is an error decorated with the recent Kubernetes events about
+// the resources that were not ready when it occurred.
+type EventsError struct {
+	Err    error
+	Events []kube.Event
+}
+
+func (e *EventsError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	b.WriteString("\nRecent events:")
+	for _, ev := range e.Events {
+		b.WriteString("\n  ")
+		b.WriteString(ev.String())
+	}
+	return b.String()
+}
+
+// Unwrap returns the decorated error.
+func (e *EventsError) Unwrap() error { return e.Err }
+
+// Cause returns the decorated error, for github.com/pkg/errors.
+func (e *EventsError) Cause() error { return e.Err }
+
+// withEvents decorates err with the events about the resources that are not
+// ready since the operation started. It returns err unchanged if the client
+// cannot report events or there are none.
+func (cfg *Configuration) withEvents(err error, resources kube.ResourceList, started time.Time) error {
+	if err == nil {
+		return err
+	}
+	ec, ok := cfg.KubeClient.(kube.EventsInterface)
+	if !ok {
+		return err
+	}
+	events, eerr := ec.UnreadyEvents(resources, started, maxFailureEvents)
+	if eerr != nil {
+		cfg.logger().Warn("failed to get the events of unready resources", "error", eerr)
+		return err
+	}
+	if len(events) == 0 {
+		return err
+	}
+
+	details := make([]string, 0, len(events))
+	for _, ev := range events {
+		details = append(details, ev.String())
+	}
+	return errdefs.Wrap(errdefs.TypeOf(err), &EventsError{Err: err, Events: events}, "events", details)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// releaseArchiveAPIVersion is the version of the release archive format.
+const releaseArchiveAPIVersion = "v1"
+
+// releaseArchiveIndex is the name of the archive entry describing the
+// exported release. Each revision is stored as a JSON encoded release in
+// the "revisions" directory of the archive.
+const releaseArchiveIndex = "release.json"
+
+// releaseArchiveMetadata is the content of the release archive index.
+type releaseArchiveMetadata struct {
+	APIVersion string `json:"apiVersion"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Revisions  []int  `json:"revisions"`
+}
+
+// Export is the action for writing all revisions of a release, including
+// their chart, values, manifest and hooks, to a portable archive.
+//
+// It provides the implementation of 'helm release export'.
+type Export struct {
+	cfg *Configuration
+}
+
+// NewExport creates a new Export object with the given configuration.
+func NewExport(cfg *Configuration) *Export {
+	return &Export{
+		cfg: cfg,
+	}
+}
+
+// Run writes the archive of the named release to out. It returns the
+// exported revisions.
+func (e *Export) Run(name string, out io.Writer) ([]*release.Release, error) {
+	if err := e.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, errors.Errorf("release name is invalid: %s", name)
+	}
+
+	rels, err := e.cfg.Releases.History(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the history of release %q", name)
+	}
+	releaseutil.SortByRevision(rels)
+
+	e.cfg.logger().Debug("exporting release", "release", name, "revisions", len(rels))
+	return rels, writeReleaseArchive(out, rels)
+}
+
+// writeReleaseArchive writes the revisions of a release to out as a
+// gzipped tar archive.
+func writeReleaseArchive(out io.Writer, rels []*release.Release) error {
+	if len(rels) == 0 {
+		return errors.New("no revisions to export")
+	}
+
+	meta := releaseArchiveMetadata{
+		APIVersion: releaseArchiveAPIVersion,
+		Name:       rels[0].Name,
+		Namespace:  rels[0].Namespace,
+	}
+	for _, rel := range rels {
+		meta.Revisions = append(meta.Revisions, rel.Version)
+	}
+
+	zw := gzip.NewWriter(out)
+	tw := tar.NewWriter(zw)
+
+	if err := writeArchiveJSON(tw, releaseArchiveIndex, meta); err != nil {
+		return err
+	}
+	for _, rel := range rels {
+		if err := writeArchiveJSON(tw, revisionArchiveName(rel.Version), rel); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeArchiveJSON(tw *tar.Writer, name string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode %s", name)
+	}
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(b)),
+		ModTime: Timestamper().Time,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = tw.Write(b)
+	return err
+}
+
+func revisionArchiveName(version int) string {
+	return path.Join("revisions", fmt.Sprintf("%d.json", version))
+}
+
+// readReleaseArchive reads the revisions of a release written by
+// writeReleaseArchive, ordered by revision.
+func readReleaseArchive(in io.Reader) ([]*release.Release, error) {
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, errors.Wrap(err, "release archive is not a gzipped archive")
+	}
+	defer zr.Close()
+
+	var meta *releaseArchiveMetadata
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read release archive")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s from release archive", hdr.Name)
+		}
+		if hdr.Name == releaseArchiveIndex {
+			meta = &releaseArchiveMetadata{}
+			if err := json.Unmarshal(b, meta); err != nil {
+				return nil, errors.Wrapf(err, "failed to decode %s", hdr.Name)
+			}
+			continue
+		}
+		entries[hdr.Name] = b
+	}
+
+	if meta == nil {
+		return nil, errors.Errorf("release archive has no %s", releaseArchiveIndex)
+	}
+	if meta.APIVersion != releaseArchiveAPIVersion {
+		return nil, errors.Errorf("unsupported release archive version %q", meta.APIVersion)
+	}
+	if len(meta.Revisions) == 0 {
+		return nil, errors.New("release archive has no revisions")
+	}
+
+	rels := make([]*release.Release, 0, len(meta.Revisions))
+	for _, version := range meta.Revisions {
+		name := revisionArchiveName(version)
+		b, ok := entries[name]
+		if !ok {
+			return nil, errors.Errorf("release archive is missing %s", name)
+		}
+		var rel release.Release
+		if err := json.Unmarshal(b, &rel); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode %s", name)
+		}
+		if rel.Name != meta.Name || rel.Version != version || rel.Info == nil {
+			return nil, errors.Errorf("%s does not hold revision %d of release %q", name, version, meta.Name)
+		}
+		rels = append(rels, &rel)
+	}
+	sort.Slice(rels, func(i, j int) bool { return rels[i].Version < rels[j].Version })
+	return rels, nil
+}
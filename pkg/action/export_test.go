@@ -0,0 +1,83 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func exportFixture(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	config := actionConfigFixture(t)
+	for i, status := range []release.Status{release.StatusSuperseded, release.StatusDeployed} {
+		rel := namedReleaseStub("angry-panda", status)
+		rel.Namespace = "default"
+		rel.Version = i + 1
+		if err := config.Releases.Create(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	rels, err := NewExport(config).Run("angry-panda", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 2 {
+		t.Fatalf("expected 2 exported revisions, got %d", len(rels))
+	}
+	return &buf
+}
+
+func TestExport(t *testing.T) {
+	is := assert.New(t)
+
+	archive := exportFixture(t)
+	rels, err := readReleaseArchive(archive)
+	is.NoError(err)
+	is.Len(rels, 2)
+
+	for i, rel := range rels {
+		is.Equal("angry-panda", rel.Name)
+		is.Equal(i+1, rel.Version)
+		is.Equal("default", rel.Namespace)
+		is.Equal(manifestWithHook, rel.Hooks[0].Manifest)
+		is.Equal(map[string]interface{}{"name": "value"}, rel.Config)
+		is.Len(rel.Chart.Templates, len(buildChart(withSampleTemplates()).Templates))
+	}
+	is.Equal(release.StatusDeployed, rels[1].Info.Status)
+}
+
+func TestExportMissingRelease(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewExport(actionConfigFixture(t)).Run("angry-panda", &buf)
+	assert.Error(t, err)
+}
+
+func TestReadReleaseArchiveInvalid(t *testing.T) {
+	_, err := readReleaseArchive(bytes.NewBufferString("not an archive"))
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.Error(t, writeReleaseArchive(&buf, nil))
+}
@@ -17,17 +17,61 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"helm.sh/helm/v3/pkg/errdefs"
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 	helmtime "helm.sh/helm/v3/pkg/time"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
+// The backoff between the attempts of a hook that is retried. They are
+// variables so that tests need not wait.
+var (
+	hookRetryBaseBackoff = 2 * time.Second
+	maxHookRetryBackoff  = time.Minute
+)
+
+// The logs of hooks are kept in the release record, which is limited in
+// size, so only a bounded tail of a few containers is kept.
+const (
+	hookLogTailLines  = 20
+	hookLogLimitBytes = 4096
+	maxHookLogs       = 5
+)
+
+// hookRunOptions tune how the hooks of an event are run.
+type hookRunOptions struct {
+	// concurrency is the maximum number of hooks of the same weight that
+	// are run concurrently. Hooks are run one at a time if it is less than 2.
+	concurrency int
+	// retries is the maximum number of times a failed hook is run again,
+	// raised to the retries of the hook's own annotation.
+	retries int
+	// keepGoing runs the remaining hooks after one failed, instead of
+	// cancelling them. The first failure is still returned.
+	keepGoing bool
+}
+
 // execHook executes all of the hooks for the given hook event.
-func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent, timeout time.Duration) error {
+func (cfg *Configuration) execHook(ctx context.Context, rl *release.Release, hook release.HookEvent, timeout time.Duration) error {
+	return cfg.execHookWithOptions(ctx, rl, hook, timeout, hookRunOptions{concurrency: cfg.HookConcurrency})
+}
+
+// execHookWithOptions executes all of the hooks for the given hook event as
+// tuned by opts.
+func (cfg *Configuration) execHookWithOptions(ctx context.Context, rl *release.Release, hook release.HookEvent, timeout time.Duration, opts hookRunOptions) (err error) {
+	ctx, span := tracer.Start(ctx, "helm.hooks "+hook.String())
+	defer func() { tracing.EndSpan(span, err) }()
+
 	executingHooks := []*release.Hook{}
 
 	for _, h := range rl.Hooks {
@@ -40,73 +84,309 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 
 	// hooke are pre-ordered by kind, so keep order stable
 	sort.Stable(hookByWeight(executingHooks))
+	span.SetAttributes(attribute.Int("hooks", len(executingHooks)))
 
-	for _, h := range executingHooks {
-		// Set default delete policy to before-hook-creation
-		if h.DeletePolicies == nil || len(h.DeletePolicies) == 0 {
-			// TODO(jlegrone): Only apply before-hook-creation delete policy to run to completion
-			//                 resources. For all other resource types update in place if a
-			//                 resource with the same name already exists and is owned by the
-			//                 current release.
-			h.DeletePolicies = []release.HookDeletePolicy{release.HookBeforeHookCreation}
+	// Hooks of the same weight do not depend on each other, so each group
+	// may run concurrently.
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	for start := 0; start < len(executingHooks); {
+		end := start + 1
+		for end < len(executingHooks) && executingHooks[end].Weight == executingHooks[start].Weight {
+			end++
+		}
+		if err := cfg.runHooks(ctx, rl, executingHooks[start:end], hook, timeout, opts, &mu); err != nil {
+			if !opts.keepGoing {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
+		start = end
+	}
 
-		if err := cfg.deleteHookByPolicy(h, release.HookBeforeHookCreation); err != nil {
+	// Check the annotation of each successful hook to determine whether the hook should be deleted
+	// under succeeded condition. If so, then clear the corresponding resource object in each hook
+	for _, h := range executingHooks {
+		if h.LastRun.Phase != release.HookPhaseSucceeded {
+			continue
+		}
+		if err := cfg.deleteHookByPolicy(h, release.HookSucceeded); err != nil {
 			return err
 		}
+	}
 
-		resources, err := cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), true)
-		if err != nil {
-			return errors.Wrapf(err, "unable to build kubernetes object for %s hook %s", hook, h.Path)
+	return firstErr
+}
+
+// execFailureHook executes the hooks for an event fired by the failure of an
+// operation. Their own failure is logged rather than returned, so that the
+// failure of the operation is the one reported.
+func (cfg *Configuration) execFailureHook(ctx context.Context, rl *release.Release, hook release.HookEvent, timeout time.Duration) {
+	if err := cfg.execHook(spanContext(ctx), rl, hook, timeout); err != nil {
+		cfg.logger().Warn("failure hooks failed", "event", hook.String(), "release", rl.Name, "error", err)
+	}
+}
+
+// runHooks runs hooks of the same weight, up to opts.concurrency at a time.
+// If a hook fails, the hooks still running are cancelled and those not
+// started yet are skipped, unless opts.keepGoing is set.
+func (cfg *Configuration) runHooks(ctx context.Context, rl *release.Release, hooks []*release.Hook, hook release.HookEvent, timeout time.Duration, opts hookRunOptions, mu *sync.Mutex) error {
+	if opts.concurrency < 2 || len(hooks) == 1 {
+		var firstErr error
+		for _, h := range hooks {
+			if err := cfg.runHook(ctx, rl, h, hook, timeout, opts.retries, mu); err != nil {
+				if !opts.keepGoing {
+					return err
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
 		}
+		return firstErr
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	slots := make(chan struct{}, opts.concurrency)
+	for _, h := range hooks {
+		wg.Add(1)
+		go func(h *release.Hook) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if err := cfg.runHook(ctx, rl, h, hook, timeout, opts.retries, mu); err != nil {
+				once.Do(func() {
+					firstErr = err
+					if !opts.keepGoing {
+						cancel()
+					}
+				})
+			}
+		}(h)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// runHook creates the resources of a hook and waits for them to complete,
+// running it again up to retries times, or the retries of its annotation if
+// more, while it fails. The record of the hook executions in rl is guarded by
+// mu, as hooks of the same weight may run concurrently.
+func (cfg *Configuration) runHook(ctx context.Context, rl *release.Release, h *release.Hook, hook release.HookEvent, timeout time.Duration, retries int, mu *sync.Mutex) (err error) {
+	ctx, span := tracer.Start(ctx, "helm.hook", trace.WithAttributes(
+		attribute.String("hook", h.Name),
+		attribute.String("kind", h.Kind),
+		attribute.String("event", hook.String()),
+		attribute.Int("weight", h.Weight),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	// Set default delete policy to before-hook-creation
+	mu.Lock()
+	if h.DeletePolicies == nil || len(h.DeletePolicies) == 0 {
+		// TODO(jlegrone): Only apply before-hook-creation delete policy to run to completion
+		//                 resources. For all other resource types update in place if a
+		//                 resource with the same name already exists and is owned by the
+		//                 current release.
+		h.DeletePolicies = []release.HookDeletePolicy{release.HookBeforeHookCreation}
+	}
+	mu.Unlock()
+
+	if err := cfg.deleteHookByPolicy(h, release.HookBeforeHookCreation); err != nil {
+		return err
+	}
+
+	resources, err := cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), true)
+	if err != nil {
+		return hookError(errors.Wrapf(err, "unable to build kubernetes object for %s hook %s", hook, h.Path), h, hook)
+	}
+
+	// Record the time at which the hook was applied to the cluster
+	mu.Lock()
+	h.LastRun = release.HookExecution{
+		StartedAt: helmtime.Now(),
+		Phase:     release.HookPhaseRunning,
+	}
+	cfg.recordRelease(rl)
+
+	// As long as the implementation of WatchUntilReady does not panic, HookPhaseFailed or HookPhaseSucceeded
+	// should always be set by this function. If we fail to do that for any reason, then HookPhaseUnknown is
+	// the most appropriate value to surface.
+	h.LastRun.Phase = release.HookPhaseUnknown
+	mu.Unlock()
 
-		// Record the time at which the hook was applied to the cluster
-		h.LastRun = release.HookExecution{
-			StartedAt: helmtime.Now(),
-			Phase:     release.HookPhaseRunning,
+	// Each attempt may take the timeout of the hook, if it has one.
+	if h.Timeout > 0 {
+		timeout = h.Timeout
+	}
+	if h.Retries > retries {
+		retries = h.Retries
+	}
+	for attempt := 1; ; attempt++ {
+		err = cfg.runHookAttempt(ctx, h, resources, hook, timeout, mu)
+		if err == nil || attempt > retries || ctx.Err() != nil {
+			break
+		}
+		backoff := hookRetryBackoff(attempt)
+		cfg.logger().Warn("hook failed, retrying", "hook", h.Name, "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
 		}
-		cfg.recordRelease(rl)
-
-		// As long as the implementation of WatchUntilReady does not panic, HookPhaseFailed or HookPhaseSucceeded
-		// should always be set by this function. If we fail to do that for any reason, then HookPhaseUnknown is
-		// the most appropriate value to surface.
-		h.LastRun.Phase = release.HookPhaseUnknown
-
-		// Create hook resources
-		if _, err := cfg.KubeClient.Create(resources); err != nil {
-			h.LastRun.CompletedAt = helmtime.Now()
-			h.LastRun.Phase = release.HookPhaseFailed
-			return errors.Wrapf(err, "warning: Hook %s %s failed", hook, h.Path)
+		// The resources of the failed attempt are removed so that they can
+		// be created again.
+		if derr := cfg.deleteHookResources(h, resources, timeout); derr != nil {
+			err = errors.Wrapf(derr, "unable to delete the resources of hook %s before retrying", h.Path)
+			break
 		}
+	}
 
-		// Watch hook resources until they have completed
-		err = cfg.KubeClient.WatchUntilReady(resources, timeout)
-		// Note the time of success/failure
-		h.LastRun.CompletedAt = helmtime.Now()
-		// Mark hook as succeeded or failed
-		if err != nil {
-			h.LastRun.Phase = release.HookPhaseFailed
-			// If a hook is failed, check the annotation of the hook to determine whether the hook should be deleted
-			// under failed condition. If so, then clear the corresponding resource object in the hook
-			if err := cfg.deleteHookByPolicy(h, release.HookFailed); err != nil {
-				return err
-			}
+	// Note the time of success/failure, and mark hook as succeeded or failed
+	mu.Lock()
+	h.LastRun.CompletedAt = helmtime.Now()
+	h.LastRun.Phase = release.HookPhaseSucceeded
+	if err != nil {
+		h.LastRun.Phase = release.HookPhaseFailed
+	}
+	mu.Unlock()
+	if err != nil {
+		// If a hook is failed, check the annotation of the hook to determine whether the hook should be deleted
+		// under failed condition. If so, then clear the corresponding resource object in the hook
+		if err := cfg.deleteHookByPolicy(h, release.HookFailed); err != nil {
 			return err
 		}
-		h.LastRun.Phase = release.HookPhaseSucceeded
+		return hookError(err, h, hook)
 	}
+	return nil
+}
 
-	// If all hooks are successful, check the annotation of each hook to determine whether the hook should be deleted
-	// under succeeded condition. If so, then clear the corresponding resource object in each hook
-	for _, h := range executingHooks {
-		if err := cfg.deleteHookByPolicy(h, release.HookSucceeded); err != nil {
-			return err
+// runHookAttempt creates the resources of a hook and waits for them to
+// complete once, recording the attempt in the last run of the hook.
+func (cfg *Configuration) runHookAttempt(ctx context.Context, h *release.Hook, resources kube.ResourceList, hook release.HookEvent, timeout time.Duration, mu *sync.Mutex) (err error) {
+	attempt := release.HookAttempt{
+		StartedAt: helmtime.Now(),
+		Phase:     release.HookPhaseSucceeded,
+	}
+	defer func() {
+		attempt.CompletedAt = helmtime.Now()
+		if err != nil {
+			attempt.Phase = release.HookPhaseFailed
+			attempt.Error = err.Error()
 		}
+		mu.Lock()
+		h.LastRun.Attempts = append(h.LastRun.Attempts, attempt)
+		mu.Unlock()
+	}()
+
+	// Create hook resources
+	if _, err := cfg.kubeCreate(ctx, resources); err != nil {
+		return errors.Wrapf(err, "warning: Hook %s %s failed", hook, h.Path)
 	}
 
+	// Watch hook resources until they have completed
+	err = cfg.kubeWatchUntilReady(ctx, resources, timeout)
+	// Collect the logs and events before the resources they are about are
+	// deleted.
+	cfg.recordHookLogs(h, resources, mu)
+	return cfg.withEvents(err, resources, attempt.StartedAt.Time)
+}
+
+// recordHookLogs records the tails of the logs of the containers of a hook
+// Job or Pod in its last run. Failing to get them is not an error of the
+// hook.
+func (cfg *Configuration) recordHookLogs(h *release.Hook, resources kube.ResourceList, mu *sync.Mutex) {
+	if h.Kind != "Job" && h.Kind != "Pod" {
+		return
+	}
+	lc, ok := cfg.KubeClient.(kube.LogsInterface)
+	if !ok {
+		return
+	}
+	logs, err := lc.ContainerLogs(resources, hookLogTailLines, hookLogLimitBytes)
+	if err != nil {
+		cfg.logger().Warn("failed to get the logs of hook", "hook", h.Name, "error", err)
+		return
+	}
+	// A Job that was retried has a pod per try; the last ones matter most.
+	if len(logs) > maxHookLogs {
+		logs = logs[len(logs)-maxHookLogs:]
+	}
+
+	hookLogs := make([]release.HookLog, 0, len(logs))
+	for _, l := range logs {
+		hookLogs = append(hookLogs, release.HookLog{
+			Pod:       l.Pod,
+			Container: l.Container,
+			Reason:    l.Reason,
+			ExitCode:  l.ExitCode,
+			Message:   l.Message,
+			Log:       l.Log,
+		})
+	}
+	mu.Lock()
+	h.LastRun.Logs = hookLogs
+	mu.Unlock()
+}
+
+// hookRetryBackoff returns how long to wait before retrying a hook after the
+// given failed attempt. It doubles with each attempt, up to
+// maxHookRetryBackoff.
+func hookRetryBackoff(attempt int) time.Duration {
+	backoff := hookRetryBaseBackoff
+	for i := 1; i < attempt && backoff < maxHookRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxHookRetryBackoff {
+		backoff = maxHookRetryBackoff
+	}
+	return backoff
+}
+
+// deleteHookResources deletes the resources of a hook and waits up to the
+// timeout for them to be gone, if the client can.
+func (cfg *Configuration) deleteHookResources(h *release.Hook, resources kube.ResourceList, timeout time.Duration) error {
+	// Never delete CustomResourceDefinitions; this could cause lots of
+	// cascading garbage collection.
+	if h.Kind == "CustomResourceDefinition" {
+		return nil
+	}
+	if _, errs := cfg.KubeClient.Delete(resources); len(errs) > 0 {
+		return errors.New(joinErrors(errs))
+	}
+	if kubeClient, ok := cfg.KubeClient.(kube.InterfaceExt); ok {
+		return kubeClient.WaitForDelete(resources, timeout)
+	}
 	return nil
 }
 
+// hookError classifies err as the failure of the hook h run for the given
+// event.
+func hookError(err error, h *release.Hook, hook release.HookEvent) error {
+	return errdefs.Wrap(errdefs.TypeHookFailed, err, "hook", h.Name, "kind", h.Kind, "event", hook.String(), "path", h.Path)
+}
+
 // hookByWeight is a sorter for hooks
 type hookByWeight []*release.Hook
 
@@ -0,0 +1,274 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cli-runtime/pkg/resource"
+
+	"helm.sh/helm/v3/pkg/errdefs"
+	"helm.sh/helm/v3/pkg/kube"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// hookKubeClient builds one resource named after the manifest of each hook
+// and records how many hooks are watched at the same time. Watching the
+// resource named fail fails once failAfter hooks are watched, and watching
+// the resource named flaky fails the first flaky times; the others block
+// until ctx is cancelled or release is closed.
+type hookKubeClient struct {
+	kubefake.PrintingKubeClient
+
+	release   chan struct{}
+	failAfter int
+	flaky     int
+
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+	timeouts   []time.Duration
+	order      []string
+}
+
+func (c *hookKubeClient) Build(r io.Reader, _ bool) (kube.ResourceList, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return kube.ResourceList{&resource.Info{Name: string(b)}}, nil
+}
+
+func (c *hookKubeClient) CreateContext(_ context.Context, resources kube.ResourceList) (*kube.Result, error) {
+	return c.Create(resources)
+}
+
+func (c *hookKubeClient) UpdateContext(_ context.Context, original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	return c.Update(original, target, force)
+}
+
+func (c *hookKubeClient) WaitContext(_ context.Context, resources kube.ResourceList, timeout time.Duration, _ bool) error {
+	return c.Wait(resources, timeout)
+}
+
+func (c *hookKubeClient) WatchUntilReadyContext(ctx context.Context, resources kube.ResourceList, timeout time.Duration) error {
+	c.mu.Lock()
+	c.timeouts = append(c.timeouts, timeout)
+	c.order = append(c.order, resources[0].Name)
+	c.running++
+	if c.running > c.maxRunning {
+		c.maxRunning = c.running
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.running--
+		c.mu.Unlock()
+	}()
+
+	if resources[0].Name == "fail" {
+		for c.watched() < c.failAfter {
+			time.Sleep(time.Millisecond)
+		}
+		return errors.New("hook failed")
+	}
+	if resources[0].Name == "flaky" {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.flaky > 0 {
+			c.flaky--
+			return errors.New("hook flaked")
+		}
+		return nil
+	}
+	select {
+	case <-c.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *hookKubeClient) ContainerLogs(resources kube.ResourceList, _, _ int64) ([]kube.ContainerLog, error) {
+	return []kube.ContainerLog{{Pod: resources[0].Name + "-x7k2", Container: "main", Reason: "Error", ExitCode: 1, Log: "boom\n"}}, nil
+}
+
+func (c *hookKubeClient) watched() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running
+}
+
+func hooksFixture(manifests ...string) []*release.Hook {
+	hooks := make([]*release.Hook, 0, len(manifests))
+	for _, m := range manifests {
+		hooks = append(hooks, &release.Hook{
+			Name:     m,
+			Kind:     "Job",
+			Path:     "templates/" + m,
+			Manifest: m,
+			Events:   []release.HookEvent{release.HookPreInstall},
+		})
+	}
+	return hooks
+}
+
+func TestExecHook_Concurrent(t *testing.T) {
+	is := assert.New(t)
+	cfg := actionConfigFixture(t)
+	client := &hookKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}, release: make(chan struct{})}
+	cfg.KubeClient = client
+	cfg.HookConcurrency = 2
+
+	rel := releaseStub()
+	rel.Hooks = hooksFixture("a", "b", "c", "d")
+	is.NoError(cfg.Releases.Create(rel))
+
+	// Release the hooks once two of them are watched at the same time.
+	go func() {
+		for client.watched() < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		close(client.release)
+	}()
+
+	is.NoError(cfg.execHook(context.Background(), rel, release.HookPreInstall, time.Minute))
+	is.Equal(2, client.maxRunning)
+	for _, h := range rel.Hooks {
+		is.Equal(release.HookPhaseSucceeded, h.LastRun.Phase, h.Name)
+		is.False(h.LastRun.CompletedAt.IsZero(), h.Name)
+	}
+}
+
+func TestExecHook_ConcurrentFailure(t *testing.T) {
+	is := assert.New(t)
+	cfg := actionConfigFixture(t)
+	client := &hookKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}, release: make(chan struct{}), failAfter: 3}
+	cfg.KubeClient = client
+	cfg.HookConcurrency = 3
+
+	rel := releaseStub()
+	rel.Hooks = hooksFixture("a", "fail", "b", "next")
+	rel.Hooks[3].Weight = 1
+	is.NoError(cfg.Releases.Create(rel))
+
+	err := cfg.execHook(context.Background(), rel, release.HookPreInstall, time.Minute)
+	is.Error(err)
+	is.Equal(errdefs.TypeHookFailed, errdefs.TypeOf(err))
+	is.Contains(err.Error(), "hook failed")
+
+	// The hooks of the same weight are cancelled; those of the next weight
+	// are never run.
+	for _, h := range rel.Hooks[:3] {
+		is.Equal(release.HookPhaseFailed, h.LastRun.Phase, h.Name)
+	}
+	is.True(rel.Hooks[3].LastRun.StartedAt.IsZero())
+}
+
+func TestExecHook_Retries(t *testing.T) {
+	defer func(base time.Duration) { hookRetryBaseBackoff = base }(hookRetryBaseBackoff)
+	hookRetryBaseBackoff = 0
+
+	is := assert.New(t)
+	cfg := actionConfigFixture(t)
+	client := &hookKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}, flaky: 2}
+	cfg.KubeClient = client
+
+	rel := releaseStub()
+	rel.Hooks = hooksFixture("flaky")
+	rel.Hooks[0].Retries = 2
+	rel.Hooks[0].Timeout = 30 * time.Second
+	is.NoError(cfg.Releases.Create(rel))
+
+	is.NoError(cfg.execHook(context.Background(), rel, release.HookPreInstall, time.Minute))
+	run := rel.Hooks[0].LastRun
+	is.Equal(release.HookPhaseSucceeded, run.Phase)
+	is.Len(run.Attempts, 3)
+	is.Equal(release.HookPhaseFailed, run.Attempts[0].Phase)
+	is.Equal("hook flaked", run.Attempts[0].Error)
+	is.Equal(release.HookPhaseFailed, run.Attempts[1].Phase)
+	is.Equal(release.HookPhaseSucceeded, run.Attempts[2].Phase)
+	is.Empty(run.Attempts[2].Error)
+	is.Equal([]time.Duration{30 * time.Second, 30 * time.Second, 30 * time.Second}, client.timeouts)
+
+	// Once the retries are used up, the hook fails.
+	client.flaky = 2
+	rel.Hooks[0].Retries = 1
+	err := cfg.execHook(context.Background(), rel, release.HookPreInstall, time.Minute)
+	is.Error(err)
+	is.Contains(err.Error(), "hook flaked")
+	is.Equal(release.HookPhaseFailed, rel.Hooks[0].LastRun.Phase)
+	is.Len(rel.Hooks[0].LastRun.Attempts, 2)
+}
+
+func TestExecHook_RetryCancelled(t *testing.T) {
+	defer func(base time.Duration) { hookRetryBaseBackoff = base }(hookRetryBaseBackoff)
+	hookRetryBaseBackoff = time.Hour
+
+	is := assert.New(t)
+	cfg := actionConfigFixture(t)
+	cfg.KubeClient = &hookKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}, flaky: 2}
+
+	rel := releaseStub()
+	rel.Hooks = hooksFixture("flaky")
+	rel.Hooks[0].Retries = 2
+	is.NoError(cfg.Releases.Create(rel))
+
+	// The hook is cancelled while waiting to be retried.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := cfg.execHook(ctx, rel, release.HookPreInstall, time.Minute)
+	is.True(errors.Is(err, context.Canceled), "expected the hook to be cancelled, got %v", err)
+	is.Equal(release.HookPhaseFailed, rel.Hooks[0].LastRun.Phase)
+	is.Len(rel.Hooks[0].LastRun.Attempts, 1)
+}
+
+func TestHookRetryBackoff(t *testing.T) {
+	is := assert.New(t)
+	is.Equal(2*time.Second, hookRetryBackoff(1))
+	is.Equal(4*time.Second, hookRetryBackoff(2))
+	is.Equal(16*time.Second, hookRetryBackoff(4))
+	is.Equal(time.Minute, hookRetryBackoff(10))
+}
+
+func TestExecHook_Logs(t *testing.T) {
+	is := assert.New(t)
+	cfg := actionConfigFixture(t)
+	client := &hookKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}}
+	cfg.KubeClient = client
+
+	rel := releaseStub()
+	rel.Hooks = hooksFixture("fail")
+	rel.Hooks[0].DeletePolicies = []release.HookDeletePolicy{release.HookFailed}
+	is.NoError(cfg.Releases.Create(rel))
+
+	is.Error(cfg.execHook(context.Background(), rel, release.HookPreInstall, time.Minute))
+	is.Equal([]release.HookLog{{Pod: "fail-x7k2", Container: "main", Reason: "Error", ExitCode: 1, Log: "boom\n"}}, rel.Hooks[0].LastRun.Logs)
+
+	// Only the pods of Jobs and Pods have logs.
+	rel.Hooks[0].Kind = "ConfigMap"
+	is.Error(cfg.execHook(context.Background(), rel, release.HookPreInstall, time.Minute))
+	is.Empty(rel.Hooks[0].LastRun.Logs)
+}
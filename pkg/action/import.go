@@ -0,0 +1,230 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// Import is the action for writing the revisions of a release read from an
+// archive created by Export into the configured storage.
+//
+// It provides the implementation of 'helm release import'.
+type Import struct {
+	cfg *Configuration
+
+	// Namespace is the namespace the release is imported into.
+	Namespace string
+	// RewriteNamespace sets the namespace of the imported revisions to
+	// Namespace. Without it, a release exported from another namespace
+	// cannot be imported.
+	RewriteNamespace bool
+	// Validate checks that the live objects match the manifest of the last
+	// deployed revision before the release is imported.
+	Validate bool
+}
+
+// NewImport creates a new Import object with the given configuration.
+func NewImport(cfg *Configuration) *Import {
+	return &Import{
+		cfg: cfg,
+	}
+}
+
+// Run imports the release archive read from in. It returns the imported
+// revisions.
+func (i *Import) Run(in io.Reader) ([]*release.Release, error) {
+	if err := i.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+
+	rels, err := readReleaseArchive(in)
+	if err != nil {
+		return nil, err
+	}
+	name := rels[0].Name
+
+	for _, rel := range rels {
+		if rel.Namespace == i.Namespace {
+			continue
+		}
+		if !i.RewriteNamespace {
+			return nil, errors.Errorf("release %q was exported from namespace %q, not %q; use the option to rewrite the namespace to import it", name, rel.Namespace, i.Namespace)
+		}
+		rel.Namespace = i.Namespace
+	}
+
+	h, err := i.cfg.Releases.History(name)
+	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, err
+	}
+	if len(h) > 0 {
+		return nil, errors.Errorf("release %q already exists in namespace %q", name, i.Namespace)
+	}
+
+	if i.Validate {
+		if err := i.validate(rels); err != nil {
+			return nil, err
+		}
+	}
+
+	i.cfg.logger().Debug("importing release", "release", name, "revisions", len(rels))
+	for n, rel := range rels {
+		if err := i.cfg.Releases.Create(rel); err != nil {
+			// Do not leave a partial history behind.
+			for _, created := range rels[:n] {
+				if _, derr := i.cfg.Releases.Delete(created.Name, created.Version); derr != nil {
+					i.cfg.logger().Warn("failed to remove imported revision", "release", name, "revision", created.Version, "error", derr)
+				}
+			}
+			return nil, errors.Wrapf(err, "failed to import revision %d of release %q", rel.Version, name)
+		}
+	}
+	return rels, nil
+}
+
+// validate checks that the live objects match the manifest of the last
+// deployed revision of rels.
+func (i *Import) validate(rels []*release.Release) error {
+	var last *release.Release
+	for _, rel := range rels {
+		if rel.Info.Status == release.StatusDeployed {
+			last = rel
+		}
+	}
+	if last == nil {
+		return errors.Errorf("release %q has no deployed revision to validate", rels[0].Name)
+	}
+
+	resources, err := i.cfg.KubeClient.Build(bytes.NewBufferString(last.Manifest), false)
+	if err != nil {
+		return errors.Wrap(err, "unable to build kubernetes objects from release manifest")
+	}
+
+	var problems []string
+	err = resources.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		expected, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+		if err != nil {
+			return err
+		}
+		kind := info.Mapping.GroupVersionKind.Kind
+		if err := info.Get(); err != nil {
+			if apierrors.IsNotFound(err) {
+				problems = append(problems, fmt.Sprintf("%s %q is missing", kind, info.Name))
+				return nil
+			}
+			return errors.Wrapf(err, "failed to get %s %q", kind, info.Name)
+		}
+		live, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+		if err != nil {
+			return err
+		}
+		for _, field := range objectDrift(expected, live, "") {
+			problems = append(problems, fmt.Sprintf("%s %q differs at %s", kind, info.Name, field))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("live objects do not match revision %d of release %q:\n%s", last.Version, last.Name, strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// objectDrift returns the paths of the fields set in expected that have a
+// different value in live. Fields only set in live, such as defaults and
+// status, are ignored.
+func objectDrift(expected, live interface{}, path string) []string {
+	switch exp := expected.(type) {
+	case map[string]interface{}:
+		obj, ok := live.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		keys := make([]string, 0, len(exp))
+		for k := range exp {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var drift []string
+		for _, k := range keys {
+			field := path + "." + k
+			value, ok := obj[k]
+			if !ok {
+				if exp[k] != nil {
+					drift = append(drift, field)
+				}
+				continue
+			}
+			drift = append(drift, objectDrift(exp[k], value, field)...)
+		}
+		return drift
+	case []interface{}:
+		list, ok := live.([]interface{})
+		if !ok || len(list) != len(exp) {
+			return []string{path}
+		}
+		var drift []string
+		for n := range exp {
+			drift = append(drift, objectDrift(exp[n], list[n], fmt.Sprintf("%s[%d]", path, n))...)
+		}
+		return drift
+	}
+
+	if a, ok := number(expected); ok {
+		if b, ok := number(live); ok && a == b {
+			return nil
+		}
+		return []string{path}
+	}
+	if !reflect.DeepEqual(expected, live) {
+		return []string{path}
+	}
+	return nil
+}
+
+// number converts the numeric values of decoded objects to float64.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
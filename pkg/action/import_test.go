@@ -0,0 +1,121 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestImport(t *testing.T) {
+	is := assert.New(t)
+
+	config := actionConfigFixture(t)
+	client := NewImport(config)
+	client.Namespace = "default"
+
+	rels, err := client.Run(exportFixture(t))
+	is.NoError(err)
+	is.Len(rels, 2)
+
+	h, err := config.Releases.History("angry-panda")
+	is.NoError(err)
+	is.Len(h, 2)
+
+	last, err := config.Releases.Last("angry-panda")
+	is.NoError(err)
+	is.Equal(2, last.Version)
+	is.Equal(release.StatusDeployed, last.Info.Status)
+	is.Equal(manifestWithHook, last.Hooks[0].Manifest)
+
+	// The release now exists and cannot be imported again.
+	_, err = client.Run(exportFixture(t))
+	is.Error(err)
+}
+
+func TestImportRewriteNamespace(t *testing.T) {
+	is := assert.New(t)
+
+	config := actionConfigFixture(t)
+	client := NewImport(config)
+	client.Namespace = "other"
+
+	_, err := client.Run(exportFixture(t))
+	is.Error(err)
+
+	client.RewriteNamespace = true
+	rels, err := client.Run(exportFixture(t))
+	is.NoError(err)
+	for _, rel := range rels {
+		is.Equal("other", rel.Namespace)
+	}
+
+	got, err := config.Releases.Get("angry-panda", 2)
+	is.NoError(err)
+	is.Equal("other", got.Namespace)
+}
+
+func TestImportValidate(t *testing.T) {
+	is := assert.New(t)
+
+	config := actionConfigFixture(t)
+	client := NewImport(config)
+	client.Namespace = "default"
+	client.Validate = true
+
+	_, err := client.Run(exportFixture(t))
+	is.NoError(err)
+
+	superseded := namedReleaseStub("angry-panda", release.StatusSuperseded)
+	is.Error(client.validate([]*release.Release{superseded}))
+}
+
+func TestObjectDrift(t *testing.T) {
+	expected := map[string]interface{}{
+		"kind": "Deployment",
+		"metadata": map[string]interface{}{
+			"name":   "web",
+			"labels": map[string]interface{}{"app": "web"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": float64(2),
+			"ports":    []interface{}{map[string]interface{}{"port": float64(80)}},
+		},
+	}
+
+	live := map[string]interface{}{
+		"kind": "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"labels":          map[string]interface{}{"app": "web"},
+			"resourceVersion": "42",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"ports":    []interface{}{map[string]interface{}{"port": int64(80), "protocol": "TCP"}},
+		},
+		"status": map[string]interface{}{"readyReplicas": int64(2)},
+	}
+	assert.Empty(t, objectDrift(expected, live, ""))
+
+	live["spec"].(map[string]interface{})["replicas"] = int64(3)
+	delete(live["metadata"].(map[string]interface{}), "labels")
+	assert.Equal(t, []string{".metadata.labels", ".spec.replicas"}, objectDrift(expected, live, ""))
+}
@@ -38,6 +38,7 @@ import (
 	"k8s.io/cli-runtime/pkg/resource"
 	"sigs.k8s.io/yaml"
 
+	"helm.sh/helm/v3/pkg/audit"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
@@ -52,6 +53,7 @@ import (
 	"helm.sh/helm/v3/pkg/repo"
 	"helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
 // NOTESFILE_SUFFIX that we want to treat special. It goes through the templating engine
@@ -133,7 +135,7 @@ func NewInstall(cfg *Configuration) *Install {
 	return in
 }
 
-func (i *Install) installCRDs(crds []chart.CRD) error {
+func (i *Install) installCRDs(ctx context.Context, crds []chart.CRD) error {
 	// We do these one file at a time in the order they were read.
 	totalItems := []*resource.Info{}
 	for _, obj := range crds {
@@ -144,11 +146,11 @@ func (i *Install) installCRDs(crds []chart.CRD) error {
 		}
 
 		// Send them to Kube
-		if _, err := i.cfg.KubeClient.Create(res); err != nil {
+		if _, err := i.cfg.kubeCreate(ctx, res); err != nil {
 			// If the error is CRD already exists, continue.
 			if apierrors.IsAlreadyExists(err) {
 				crdName := res[0].Name
-				i.cfg.Log("CRD %s is already present. Skipping.", crdName)
+				i.cfg.logger().Info("CRD is already present, skipping", "crd", crdName)
 				continue
 			}
 			return errors.Wrapf(err, "failed to install CRD %s", obj.Name)
@@ -162,11 +164,11 @@ func (i *Install) installCRDs(crds []chart.CRD) error {
 		if err != nil {
 			return err
 		}
-		i.cfg.Log("Clearing discovery cache")
+		i.cfg.logger().Debug("clearing discovery cache")
 		discoveryClient.Invalidate()
 		// Give time for the CRD to be recognized.
 
-		if err := i.cfg.KubeClient.Wait(totalItems, 60*time.Second); err != nil {
+		if err := i.cfg.kubeWait(ctx, totalItems, 60*time.Second, false); err != nil {
 			return err
 		}
 
@@ -186,7 +188,21 @@ func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.
 }
 
 // Run executes the installation with Context
-func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
+func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals map[string]interface{}) (_ *release.Release, err error) {
+	ctx, span := startOperation(ctx, "install", i.ReleaseName, i.Namespace)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if i.DryRun || i.ClientOnly {
+		return i.run(ctx, chrt, vals)
+	}
+	i.cfg.notifyStarted("install", i.ReleaseName, i.Namespace, chrt)
+	rel, err := i.run(ctx, chrt, vals)
+	i.cfg.recordAudit(audit.OperationInstall, i.ReleaseName, i.Namespace, chrt, nil, rel, err)
+	i.cfg.notifyDone("install", i.ReleaseName, i.Namespace, chrt, rel, err)
+	return rel, err
+}
+
+func (i *Install) run(ctx context.Context, chrt *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
 	// Check reachability of cluster unless in client-only mode (e.g. `helm template` without `--validate`)
 	if !i.ClientOnly {
 		if err := i.cfg.KubeClient.IsReachable(); err != nil {
@@ -207,8 +223,8 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	if crds := chrt.CRDObjects(); !i.ClientOnly && !i.SkipCRDs && len(crds) > 0 {
 		// On dry run, bail here
 		if i.DryRun {
-			i.cfg.Log("WARNING: This chart or one of its subcharts contains CRDs. Rendering may fail or contain inaccuracies.")
-		} else if err := i.installCRDs(crds); err != nil {
+			i.cfg.logger().Warn("this chart or one of its subcharts contains CRDs; rendering may fail or contain inaccuracies")
+		} else if err := i.installCRDs(ctx, crds); err != nil {
 			return nil, err
 		}
 	}
@@ -227,7 +243,7 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 		mem.SetNamespace(i.Namespace)
 		i.cfg.Releases = storage.Init(mem)
 	} else if !i.ClientOnly && len(i.APIVersions) > 0 {
-		i.cfg.Log("API Version list given outside of client only mode, this list will be ignored")
+		i.cfg.logger().Warn("API Version list given outside of client only mode, this list will be ignored")
 	}
 
 	// Make sure if Atomic is set, that wait is set as well. This makes it so
@@ -250,13 +266,13 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	}
 	valuesToRender, err := chartutil.ToRenderValues(chrt, vals, options, caps)
 	if err != nil {
-		return nil, err
+		return nil, valuesError(chrt, vals, err)
 	}
 
 	rel := i.createRelease(chrt, vals)
 
 	var manifestDoc *bytes.Buffer
-	rel.Hooks, manifestDoc, rel.Info.Notes, err = i.cfg.renderResources(chrt, valuesToRender, i.ReleaseName, i.OutputDir, i.SubNotes, i.UseReleaseName, i.IncludeCRDs, i.PostRenderer, i.DryRun)
+	rel.Hooks, manifestDoc, rel.Info.Notes, err = i.cfg.renderResources(ctx, chrt, valuesToRender, i.ReleaseName, i.OutputDir, i.SubNotes, i.UseReleaseName, i.IncludeCRDs, i.PostRenderer, i.DryRun)
 	// Even for errors, attach this if available
 	if manifestDoc != nil {
 		rel.Manifest = manifestDoc.String()
@@ -274,7 +290,7 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	var toBeAdopted kube.ResourceList
 	resources, err := i.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), !i.DisableOpenAPIValidation)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to build kubernetes objects from release manifest")
+		return nil, buildError(errors.Wrap(err, "unable to build kubernetes objects from release manifest"))
 	}
 
 	// It is safe to use "force" here because these are resources currently rendered by the chart.
@@ -323,7 +339,7 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 		if err != nil {
 			return nil, err
 		}
-		if _, err := i.cfg.KubeClient.Create(resourceList); err != nil && !apierrors.IsAlreadyExists(err) {
+		if _, err := i.cfg.kubeCreate(ctx, resourceList); err != nil && !apierrors.IsAlreadyExists(err) {
 			return nil, err
 		}
 	}
@@ -346,19 +362,19 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	rChan := make(chan resultMessage)
 	doneChan := make(chan struct{})
 	defer close(doneChan)
-	go i.performInstall(rChan, rel, toBeAdopted, resources)
+	go i.performInstall(ctx, rChan, rel, toBeAdopted, resources)
 	go i.handleContext(ctx, rChan, doneChan, rel)
 	result := <-rChan
 	//start preformInstall go routine
 	return result.r, result.e
 }
 
-func (i *Install) performInstall(c chan<- resultMessage, rel *release.Release, toBeAdopted kube.ResourceList, resources kube.ResourceList) {
+func (i *Install) performInstall(ctx context.Context, c chan<- resultMessage, rel *release.Release, toBeAdopted kube.ResourceList, resources kube.ResourceList) {
 
 	// pre-install hooks
 	if !i.DisableHooks {
-		if err := i.cfg.execHook(rel, release.HookPreInstall, i.Timeout); err != nil {
-			i.reportToRun(c, rel, fmt.Errorf("failed pre-install: %s", err))
+		if err := i.cfg.execHook(ctx, rel, release.HookPreInstall, i.Timeout); err != nil {
+			i.reportToRun(ctx, c, rel, errors.Wrap(err, "failed pre-install"))
 			return
 		}
 	}
@@ -367,34 +383,27 @@ func (i *Install) performInstall(c chan<- resultMessage, rel *release.Release, t
 	// do an update, but it's not clear whether we WANT to do an update if the re-use is set
 	// to true, since that is basically an upgrade operation.
 	if len(toBeAdopted) == 0 && len(resources) > 0 {
-		if _, err := i.cfg.KubeClient.Create(resources); err != nil {
-			i.reportToRun(c, rel, err)
+		if _, err := i.cfg.kubeCreate(ctx, resources); err != nil {
+			i.reportToRun(ctx, c, rel, err)
 			return
 		}
 	} else if len(resources) > 0 {
-		if _, err := i.cfg.KubeClient.Update(toBeAdopted, resources, false); err != nil {
-			i.reportToRun(c, rel, err)
+		if _, err := i.cfg.kubeUpdate(ctx, toBeAdopted, resources, false); err != nil {
+			i.reportToRun(ctx, c, rel, err)
 			return
 		}
 	}
 
 	if i.Wait {
-		if i.WaitForJobs {
-			if err := i.cfg.KubeClient.WaitWithJobs(resources, i.Timeout); err != nil {
-				i.reportToRun(c, rel, err)
-				return
-			}
-		} else {
-			if err := i.cfg.KubeClient.Wait(resources, i.Timeout); err != nil {
-				i.reportToRun(c, rel, err)
-				return
-			}
+		if err := i.cfg.kubeWait(ctx, resources, i.Timeout, i.WaitForJobs); err != nil {
+			i.reportToRun(ctx, c, rel, i.cfg.withEvents(err, resources, rel.Info.LastDeployed.Time))
+			return
 		}
 	}
 
 	if !i.DisableHooks {
-		if err := i.cfg.execHook(rel, release.HookPostInstall, i.Timeout); err != nil {
-			i.reportToRun(c, rel, fmt.Errorf("failed post-install: %s", err))
+		if err := i.cfg.execHook(ctx, rel, release.HookPostInstall, i.Timeout); err != nil {
+			i.reportToRun(ctx, c, rel, errors.Wrap(err, "failed post-install"))
 			return
 		}
 	}
@@ -413,39 +422,45 @@ func (i *Install) performInstall(c chan<- resultMessage, rel *release.Release, t
 	// One possible strategy would be to do a timed retry to see if we can get
 	// this stored in the future.
 	if err := i.recordRelease(rel); err != nil {
-		i.cfg.Log("failed to record the release: %s", err)
+		i.cfg.logger().Error("failed to record the release", "release", rel.Name, "error", err)
 	}
 
-	i.reportToRun(c, rel, nil)
+	i.reportToRun(ctx, c, rel, nil)
 }
 func (i *Install) handleContext(ctx context.Context, c chan<- resultMessage, done chan struct{}, rel *release.Release) {
 	select {
 	case <-ctx.Done():
 		err := ctx.Err()
-		i.reportToRun(c, rel, err)
+		i.reportToRun(ctx, c, rel, err)
 	case <-done:
 		return
 	}
 }
-func (i *Install) reportToRun(c chan<- resultMessage, rel *release.Release, err error) {
+func (i *Install) reportToRun(ctx context.Context, c chan<- resultMessage, rel *release.Release, err error) {
 	i.Lock.Lock()
 	if err != nil {
-		rel, err = i.failRelease(rel, err)
+		rel, err = i.failRelease(ctx, rel, err)
 	}
 	c <- resultMessage{r: rel, e: err}
 	i.Lock.Unlock()
 }
-func (i *Install) failRelease(rel *release.Release, err error) (*release.Release, error) {
+func (i *Install) failRelease(ctx context.Context, rel *release.Release, err error) (*release.Release, error) {
 	rel.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", i.ReleaseName, err.Error()))
+	if !i.DisableHooks {
+		i.cfg.execFailureHook(ctx, rel, release.HookInstallFailed, i.Timeout)
+	}
 	if i.Atomic {
-		i.cfg.Log("Install failed and atomic is set, uninstalling release")
+		i.cfg.logger().Info("install failed and atomic is set, uninstalling release", "release", rel.Name)
 		uninstall := NewUninstall(i.cfg)
 		uninstall.DisableHooks = i.DisableHooks
 		uninstall.KeepHistory = false
 		uninstall.Timeout = i.Timeout
-		if _, uninstallErr := uninstall.Run(i.ReleaseName); uninstallErr != nil {
+		if _, uninstallErr := uninstall.RunWithContext(spanContext(ctx), i.ReleaseName); uninstallErr != nil {
 			return rel, errors.Wrapf(uninstallErr, "an error occurred while uninstalling the release. original install error: %s", err)
 		}
+		if !i.DisableHooks {
+			i.cfg.execFailureHook(ctx, rel, release.HookPostAtomicRollback, i.Timeout)
+		}
 		return rel, errors.Wrapf(err, "release %s failed, and has been uninstalled due to atomic being set", i.ReleaseName)
 	}
 	i.recordRelease(rel) // Ignore the error, since we have another error to deal with.
@@ -673,6 +688,12 @@ OUTER:
 //
 // If 'verify' was set on ChartPathOptions, this will attempt to also verify the chart.
 func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (string, error) {
+	return c.LocateChartWithContext(context.Background(), name, settings)
+}
+
+// LocateChartWithContext is LocateChart recording the download of the chart
+// as a child of the tracing span in ctx.
+func (c *ChartPathOptions) LocateChartWithContext(ctx context.Context, name string, settings *cli.EnvSettings) (string, error) {
 	// If there is no registry client and the name is in an OCI registry return
 	// an error and a lookup will not occur.
 	if registry.IsOCI(name) && c.registryClient == nil {
@@ -750,7 +771,7 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 		return "", err
 	}
 
-	filename, _, err := dl.DownloadTo(name, version, settings.RepositoryCache)
+	filename, _, err := dl.DownloadToContext(ctx, name, version, settings.RepositoryCache)
 	if err == nil {
 		lname, err := filepath.Abs(filename)
 		if err != nil {
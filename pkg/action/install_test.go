@@ -28,12 +28,18 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"helm.sh/helm/v3/internal/test"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/errdefs"
+	"helm.sh/helm/v3/pkg/kube"
 	kubefake "helm.sh/helm/v3/pkg/kube/fake"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
@@ -288,6 +294,7 @@ func TestInstallReleaseIncorrectTemplate_DryRun(t *testing.T) {
 	}
 	if err != nil {
 		is.Contains(err.Error(), expectedErr)
+		is.Equal(errdefs.TypeRenderError, errdefs.TypeOf(err))
 	}
 }
 
@@ -318,6 +325,8 @@ func TestInstallRelease_FailedHooks(t *testing.T) {
 	vals := map[string]interface{}{}
 	res, err := instAction.Run(buildChart(), vals)
 	is.Error(err)
+	is.Equal(errdefs.TypeHookFailed, errdefs.TypeOf(err))
+	is.Equal("post-install", errdefs.DetailsOf(err)["event"])
 	is.Contains(res.Info.Description, "failed post-install")
 	is.Equal(release.StatusFailed, res.Info.Status)
 }
@@ -358,6 +367,102 @@ func TestInstallRelease_KubeVersion(t *testing.T) {
 	_, err = instAction.Run(buildChart(withKube(">=99.0.0")), vals)
 	is.Error(err)
 	is.Contains(err.Error(), "chart requires kubeVersion")
+	is.Equal(errdefs.TypeRenderError, errdefs.TypeOf(err))
+}
+
+func TestInstallRelease_SchemaViolation(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	chrt := buildChart()
+	chrt.Schema = []byte(`{"type": "object", "required": ["name"]}`)
+
+	_, err := instAction.Run(chrt, map[string]interface{}{})
+	is.Error(err)
+	is.Equal(errdefs.TypeSchemaViolation, errdefs.TypeOf(err))
+}
+
+func TestValuesError(t *testing.T) {
+	chrt := buildChart()
+	chrt.Schema = []byte(`{"type": "object", "required": ["name"]}`)
+	errValues := errors.New("values failed")
+
+	err := valuesError(chrt, map[string]interface{}{}, errValues)
+	assert.Equal(t, errdefs.TypeSchemaViolation, errdefs.TypeOf(err))
+
+	// Errors of values that validate are left unclassified.
+	err = valuesError(chrt, map[string]interface{}{"name": "value"}, errValues)
+	assert.Equal(t, errValues, err)
+}
+
+func TestBuildError(t *testing.T) {
+	invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "pod", nil)
+	assert.Equal(t, errdefs.TypeSchemaViolation, errdefs.TypeOf(buildError(invalid)))
+
+	validation := errors.New(`error validating "": error validating data: ValidationError(Pod.spec): unknown field "foo"`)
+	assert.Equal(t, errdefs.TypeSchemaViolation, errdefs.TypeOf(buildError(validation)))
+
+	// Other errors are left unclassified.
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("denied"))
+	assert.Equal(t, forbidden, buildError(forbidden))
+}
+
+func TestInstallRelease_BuildForbidden(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.BuildError = apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("denied"))
+	instAction.cfg.KubeClient = failer
+
+	_, err := instAction.Run(buildChart(), map[string]interface{}{})
+	is.Error(err)
+	is.Equal(errdefs.TypeAuthError, errdefs.TypeOf(err))
+}
+
+func TestInstallRelease_WaitEvents(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.ReleaseName = "come-fail-away"
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WaitError = wait.ErrWaitTimeout
+	failer.Events = []kube.Event{
+		{Kind: "Pod", Name: "web-0", Type: "Warning", Reason: "FailedScheduling", Message: "0/3 nodes are available", Count: 4},
+		{Kind: "Pod", Name: "web-0", Type: "Warning", Reason: "BackOff", Message: "Back-off pulling image"},
+	}
+	instAction.Wait = true
+
+	res, err := instAction.Run(buildChart(), map[string]interface{}{})
+	is.Error(err)
+	is.Equal(errdefs.TypeTimeout, errdefs.TypeOf(err))
+	is.Equal([]string{
+		"Warning FailedScheduling Pod/web-0: 0/3 nodes are available (x4)",
+		"Warning BackOff Pod/web-0: Back-off pulling image",
+	}, errdefs.DetailsOf(err)["events"])
+
+	var eventsErr *EventsError
+	is.True(errors.As(err, &eventsErr))
+	is.Len(eventsErr.Events, 2)
+
+	is.Contains(err.Error(), "Recent events:\n  Warning FailedScheduling Pod/web-0")
+	is.Contains(res.Info.Description, "Recent events:\n  Warning FailedScheduling Pod/web-0")
+	is.Equal(release.StatusFailed, res.Info.Status)
+	// Only the events since the install started are reported.
+	is.Equal(res.Info.LastDeployed.Time, failer.EventsSince)
+}
+
+func TestInstallRelease_FailedHooksEvents(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.ReleaseName = "failed-hooks"
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WatchUntilReadyError = fmt.Errorf("job failed: BackoffLimitExceeded")
+	failer.Events = []kube.Event{
+		{Kind: "Job", Name: "migrate", Type: "Warning", Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+	}
+
+	res, err := instAction.Run(buildChart(), map[string]interface{}{})
+	is.Error(err)
+	is.Equal(errdefs.TypeHookFailed, errdefs.TypeOf(err))
+	is.Contains(res.Info.Description, "Warning BackoffLimitExceeded Job/migrate")
 }
 
 func TestInstallRelease_Wait(t *testing.T) {
@@ -726,3 +831,42 @@ func TestNameAndChartGenerateName(t *testing.T) {
 		})
 	}
 }
+
+func TestInstallRelease_FailureHooks(t *testing.T) {
+	is := assert.New(t)
+
+	instAction := installAction(t)
+	res, err := instAction.Run(buildChart(withFailureHook()), map[string]interface{}{})
+	is.NoError(err)
+	is.True(findHook(res, "alert").LastRun.StartedAt.IsZero())
+	is.True(findHook(res, "restored").LastRun.StartedAt.IsZero())
+
+	instAction = installAction(t)
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WaitError = fmt.Errorf("I timed out")
+	instAction.Wait = true
+	res, err = instAction.Run(buildChart(withFailureHook()), map[string]interface{}{})
+	is.Error(err)
+	is.Contains(err.Error(), "I timed out")
+	is.Equal(release.HookPhaseSucceeded, findHook(res, "alert").LastRun.Phase)
+	is.True(findHook(res, "restored").LastRun.StartedAt.IsZero())
+
+	instAction = installAction(t)
+	failer = instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WaitError = fmt.Errorf("I timed out")
+	instAction.Atomic = true
+	res, err = instAction.Run(buildChart(withFailureHook()), map[string]interface{}{})
+	is.Error(err)
+	is.Contains(err.Error(), "uninstalled due to atomic being set")
+	is.Equal(release.HookPhaseSucceeded, findHook(res, "alert").LastRun.Phase)
+	is.Equal(release.HookPhaseSucceeded, findHook(res, "restored").LastRun.Phase)
+}
+
+func findHook(rel *release.Release, name string) *release.Hook {
+	for _, h := range rel.Hooks {
+		if h.Name == name {
+			return h
+		}
+	}
+	return nil
+}
@@ -17,13 +17,16 @@ limitations under the License.
 package action
 
 import (
+	"context"
 	"path"
 	"regexp"
 
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
 )
 
 // ListStates represents zero or more status codes that a list item may have set
@@ -80,6 +83,41 @@ func (s ListStates) FromName(str string) ListStates {
 	return ListUnknown
 }
 
+// requirement returns the label selector requirement matching the release
+// statuses of the state mask.
+func (s ListStates) requirement() labels.Requirement {
+	var in, notIn []string
+	for _, status := range []release.Status{
+		release.StatusDeployed,
+		release.StatusUninstalled,
+		release.StatusSuperseded,
+		release.StatusFailed,
+		release.StatusUninstalling,
+		release.StatusPendingInstall,
+		release.StatusPendingUpgrade,
+		release.StatusPendingRollback,
+	} {
+		if s&s.FromName(status.String()) != 0 {
+			in = append(in, status.String())
+		} else {
+			notIn = append(notIn, status.String())
+		}
+	}
+
+	op, values := selection.In, in
+	switch {
+	case s&ListUnknown != 0 && len(notIn) == 0:
+		op, values = selection.Exists, nil
+	case s&ListUnknown != 0:
+		// Unknown statuses can only be matched by excluding the known ones.
+		op, values = selection.NotIn, notIn
+	case len(in) == 0:
+		op, values = selection.DoesNotExist, nil
+	}
+	req, _ := labels.NewRequirement("status", op, values)
+	return *req
+}
+
 // ListAll is a convenience for enabling all list filters
 const ListAll = ListDeployed | ListUninstalled | ListUninstalling | ListPendingInstall | ListPendingRollback | ListPendingUpgrade | ListSuperseded | ListFailed
 
@@ -158,40 +196,44 @@ func (l *List) Run() ([]*release.Release, error) {
 		}
 	}
 
-	results, err := l.cfg.Releases.List(func(rel *release.Release) bool {
-		// Skip anything that doesn't match the filter.
-		if filter != nil && !filter.MatchString(rel.Name) {
-			return false
-		}
-
-		return true
-	})
-
+	// Skip anything that doesn't match the selector
+	selectorObj, err := labels.Parse(l.Selector)
 	if err != nil {
 		return nil, err
 	}
 
-	if results == nil {
-		return results, nil
-	}
+	// Let the storage driver evaluate the filters on the release labels, so
+	// that releases which are filtered out are never decoded.
+	filterObj := selectorObj.Add(l.StateMask.requirement())
+	opts := driver.ListOptions{Name: filter}
 
 	// by definition, superseded releases are never shown if
 	// only the latest releases are returned. so if requested statemask
 	// is _only_ ListSuperseded, skip the latest release filter
-	if l.StateMask != ListSuperseded {
-		results = filterLatestReleases(results)
+	if l.StateMask == ListSuperseded {
+		opts.Selector = filterObj
+	} else {
+		// State mask application must occur after filtering to
+		// latest releases, otherwise outdated entries can be returned
+		opts.Latest = true
+		opts.Filter = filterObj
 	}
 
-	// State mask application must occur after filtering to
-	// latest releases, otherwise outdated entries can be returned
-	results = l.filterStateMask(results)
+	// Releases are listed in name order, so the default sort only needs
+	// the releases up to the requested page.
+	if l.Limit > 0 && l.Sort == 0 && !l.ByDate && !l.SortReverse {
+		opts.Limit = l.Offset + l.Limit
+	}
 
-	// Skip anything that doesn't match the selector
-	selectorObj, err := labels.Parse(l.Selector)
+	page, err := l.cfg.Releases.ListPage(opts)
 	if err != nil {
 		return nil, err
 	}
-	results = l.filterSelector(results, selectorObj)
+	results := page.Releases
+
+	if results == nil {
+		return results, nil
+	}
 
 	// Unfortunately, we have to sort before truncating, which can incur substantial overhead
 	l.sort(results)
@@ -215,6 +257,42 @@ func (l *List) Run() ([]*release.Release, error) {
 	return results, err
 }
 
+// Watch calls handle for every release created, updated or deleted until ctx
+// is done or handle returns an error. Only releases matching StateMask, Filter
+// and Selector are reported.
+func (l *List) Watch(ctx context.Context, handle func(driver.Event) error) error {
+	if err := l.cfg.KubeClient.IsReachable(); err != nil {
+		return err
+	}
+
+	var filter *regexp.Regexp
+	if l.Filter != "" {
+		var err error
+		filter, err = regexp.Compile(l.Filter)
+		if err != nil {
+			return err
+		}
+	}
+
+	selectorObj, err := labels.Parse(l.Selector)
+	if err != nil {
+		return err
+	}
+
+	return l.cfg.Releases.Watch(ctx, func(ev driver.Event) error {
+		if l.StateMask&l.StateMask.FromName(ev.Release.Info.Status.String()) == 0 {
+			return nil
+		}
+		if filter != nil && !filter.MatchString(ev.Release.Name) {
+			return nil
+		}
+		if !selectorObj.Matches(labels.Set(ev.Release.Labels)) {
+			return nil
+		}
+		return handle(ev)
+	})
+}
+
 // sort is an in-place sort where order is based on the value of a.Sort
 func (l *List) sort(rels []*release.Release) {
 	if l.SortReverse {
@@ -260,33 +338,6 @@ func filterLatestReleases(releases []*release.Release) []*release.Release {
 	return list
 }
 
-func (l *List) filterStateMask(releases []*release.Release) []*release.Release {
-	desiredStateReleases := make([]*release.Release, 0)
-
-	for _, rls := range releases {
-		currentStatus := l.StateMask.FromName(rls.Info.Status.String())
-		mask := l.StateMask & currentStatus
-		if mask == 0 {
-			continue
-		}
-		desiredStateReleases = append(desiredStateReleases, rls)
-	}
-
-	return desiredStateReleases
-}
-
-func (l *List) filterSelector(releases []*release.Release, selector labels.Selector) []*release.Release {
-	desiredStateReleases := make([]*release.Release, 0)
-
-	for _, rls := range releases {
-		if selector.Matches(labels.Set(rls.Labels)) {
-			desiredStateReleases = append(desiredStateReleases, rls)
-		}
-	}
-
-	return desiredStateReleases
-}
-
 // SetStateMask calculates the state mask based on parameters.
 func (l *List) SetStateMask() {
 	if l.All {
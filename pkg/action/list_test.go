@@ -17,12 +17,14 @@ limitations under the License.
 package action
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
 )
 
 func TestListStates(t *testing.T) {
@@ -56,6 +58,22 @@ func TestListStates(t *testing.T) {
 	}
 }
 
+func TestListStatesRequirement(t *testing.T) {
+	for mask, expect := range map[ListStates]string{
+		ListDeployed:               "status in (deployed)",
+		ListDeployed | ListFailed:  "status in (deployed,failed)",
+		ListAll:                    "status in (deployed,failed,pending-install,pending-rollback,pending-upgrade,superseded,uninstalled,uninstalling)",
+		ListAll | ListUnknown:      "status",
+		ListUnknown | ListDeployed: "status notin (failed,pending-install,pending-rollback,pending-upgrade,superseded,uninstalled,uninstalling)",
+		ListStates(0):              "!status",
+	} {
+		req := mask.requirement()
+		if got := req.String(); got != expect {
+			t.Errorf("Expected %q for mask %d, got %q", expect, mask, got)
+		}
+	}
+}
+
 func TestList_Empty(t *testing.T) {
 	lister := NewList(actionConfigFixture(t))
 	list, err := lister.Run()
@@ -366,3 +384,58 @@ func TestSelectorList(t *testing.T) {
 		assert.ElementsMatch(t, expectedFilteredList, res)
 	})
 }
+
+// eventDriver reports a fixed set of events to Watch.
+type eventDriver struct {
+	*driver.Memory
+	events []driver.Event
+}
+
+func (d *eventDriver) Watch(_ context.Context, handle func(driver.Event) error) error {
+	for _, ev := range d.events {
+		if err := handle(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestList_Watch(t *testing.T) {
+	event := func(name string, status release.Status, labels map[string]string) driver.Event {
+		rel := namedReleaseStub(name, status)
+		rel.Labels = labels
+		return driver.Event{Type: driver.EventUpdated, Key: name, Release: rel}
+	}
+	lister := newListFixture(t)
+	lister.cfg.Releases = storage.Init(&eventDriver{
+		Memory: driver.NewMemory(),
+		events: []driver.Event{
+			event("one", release.StatusSuperseded, nil),
+			event("two", release.StatusDeployed, map[string]string{"team": "a"}),
+			event("three", release.StatusFailed, map[string]string{"team": "b"}),
+			event("four", release.StatusDeployed, nil),
+		},
+	})
+
+	watch := func() []string {
+		t.Helper()
+		var names []string
+		if err := lister.Watch(context.Background(), func(ev driver.Event) error {
+			names = append(names, ev.Release.Name)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"two", "three", "four"}, watch())
+
+	lister.StateMask = ListSuperseded | ListFailed
+	assert.Equal(t, []string{"one", "three"}, watch())
+
+	lister.StateMask = ListAll
+	lister.Selector = "team"
+	lister.Filter = "^t"
+	assert.Equal(t, []string{"two", "three"}, watch())
+}
@@ -0,0 +1,213 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// Logs is the action for printing the logs of the pods of a release.
+//
+// It provides the implementation of 'helm logs'.
+type Logs struct {
+	cfg *Configuration
+
+	// Follow streams new log lines until the context is done.
+	Follow bool
+	// Since only returns the log lines newer than this duration, if set.
+	Since time.Duration
+	// Kind only returns the logs of the pods of the resources of this kind,
+	// if set. It is case insensitive.
+	Kind string
+	// Name only returns the logs of the pods of the resources with this
+	// name, if set.
+	Name string
+}
+
+// NewLogs creates a new Logs object with the given configuration.
+func NewLogs(cfg *Configuration) *Logs {
+	return &Logs{
+		cfg: cfg,
+	}
+}
+
+// Run writes the logs of the pods of the named release to out. Each line is
+// prefixed with the pod and container it comes from.
+func (l *Logs) Run(ctx context.Context, out io.Writer, name string) error {
+	if err := l.cfg.KubeClient.IsReachable(); err != nil {
+		return err
+	}
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return errors.Errorf("release name is invalid: %s", name)
+	}
+
+	rel, err := l.cfg.Releases.Last(name)
+	if err != nil {
+		return err
+	}
+	client, err := l.cfg.KubernetesClientSet()
+	if err != nil {
+		return errors.Wrap(err, "unable to get kubernetes client to fetch pod logs")
+	}
+	return l.writeLogs(ctx, out, client, rel)
+}
+
+func (l *Logs) writeLogs(ctx context.Context, out io.Writer, client kubernetes.Interface, rel *release.Release) error {
+	pods, err := l.pods(ctx, client, rel)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return errors.Errorf("no pods found for release %q", rel.Name)
+	}
+
+	w := &lineWriter{out: out}
+	if !l.Follow {
+		for _, pod := range pods {
+			for _, c := range pod.Spec.Containers {
+				if err := l.writeContainerLogs(ctx, w, client, pod, c.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// Following never ends, so the containers are streamed concurrently. The
+	// first stream to fail stops the others, and its error is returned.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	containers := 0
+	for _, pod := range pods {
+		containers += len(pod.Spec.Containers)
+	}
+	errs := make(chan error, containers)
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			wg.Add(1)
+			go func(pod v1.Pod, container string) {
+				defer wg.Done()
+				if err := l.writeContainerLogs(ctx, w, client, pod, container); err != nil {
+					errs <- err
+					cancel()
+				}
+			}(pod, c.Name)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// pods returns the pods of the workloads in the manifest of the release,
+// sorted by name.
+func (l *Logs) pods(ctx context.Context, client kubernetes.Interface, rel *release.Release) ([]v1.Pod, error) {
+	found := map[string]v1.Pod{}
+	for _, m := range releaseutil.SplitManifests(rel.Manifest) {
+		obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode([]byte(m), nil, nil)
+		if err != nil {
+			// Custom resources have no pods we know of.
+			continue
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		if l.Kind != "" && !strings.EqualFold(l.Kind, gvk.Kind) {
+			continue
+		}
+		if l.Name != "" && l.Name != accessor.GetName() {
+			continue
+		}
+		namespace := accessor.GetNamespace()
+		if namespace == "" {
+			namespace = rel.Namespace
+		}
+
+		list, err := kube.PodsOf(ctx, client, namespace, obj)
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range list {
+			found[pod.Namespace+"/"+pod.Name] = pod
+		}
+	}
+
+	pods := make([]v1.Pod, 0, len(found))
+	for _, pod := range found {
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+func (l *Logs) writeContainerLogs(ctx context.Context, w *lineWriter, client kubernetes.Interface, pod v1.Pod, container string) error {
+	opts := &v1.PodLogOptions{Container: container, Follow: l.Follow}
+	if l.Since > 0 {
+		seconds := int64(l.Since.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+	logs, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get logs for pod %s container %s", pod.Name, container)
+	}
+	defer logs.Close()
+
+	prefix := fmt.Sprintf("[%s/%s] ", pod.Name, container)
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		w.writeLine(prefix + scanner.Text())
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return errors.Wrapf(err, "unable to read logs for pod %s container %s", pod.Name, container)
+	}
+	return nil
+}
+
+// lineWriter writes whole lines to out from concurrent streams.
+type lineWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (w *lineWriter) writeLine(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintln(w.out, line)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	fakerest "k8s.io/client-go/rest/fake"
+)
+
+var logsManifest = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: standalone
+spec:
+  containers:
+  - name: main
+    image: busybox
+`
+
+func newLogsPod(name string, labels map[string]string, containers ...string) *v1.Pod {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: c})
+	}
+	return pod
+}
+
+func TestLogs(t *testing.T) {
+	rel := releaseStub()
+	rel.Namespace = "default"
+	rel.Manifest = logsManifest
+	client := fake.NewSimpleClientset(
+		newLogsPod("web-1", map[string]string{"app": "web"}, "nginx", "sidecar"),
+		newLogsPod("standalone", nil, "main"),
+		newLogsPod("other", map[string]string{"app": "other"}, "main"),
+	)
+
+	tests := []struct {
+		name  string
+		kind  string
+		rname string
+		want  string
+		err   string
+	}{{
+		name: "all workloads",
+		want: "[standalone/main] fake logs\n[web-1/nginx] fake logs\n[web-1/sidecar] fake logs\n",
+	}, {
+		name: "by kind",
+		kind: "deployment",
+		want: "[web-1/nginx] fake logs\n[web-1/sidecar] fake logs\n",
+	}, {
+		name:  "by name",
+		rname: "standalone",
+		want:  "[standalone/main] fake logs\n",
+	}, {
+		name: "no match",
+		kind: "StatefulSet",
+		err:  `no pods found for release "angry-panda"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := NewLogs(actionConfigFixture(t))
+			logs.Kind = tt.kind
+			logs.Name = tt.rname
+
+			var out bytes.Buffer
+			err := logs.writeLogs(context.Background(), &out, client, rel)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestLogsFollow(t *testing.T) {
+	rel := releaseStub()
+	rel.Namespace = "default"
+	rel.Manifest = logsManifest
+	client := fake.NewSimpleClientset(newLogsPod("web-1", map[string]string{"app": "web"}, "nginx", "sidecar"))
+
+	logs := NewLogs(actionConfigFixture(t))
+	logs.Follow = true
+	var out bytes.Buffer
+	assert.NoError(t, logs.writeLogs(context.Background(), &out, client, rel))
+	assert.Contains(t, out.String(), "[web-1/nginx] fake logs\n")
+	assert.Contains(t, out.String(), "[web-1/sidecar] fake logs\n")
+}
+
+// failingLogsClient fails to stream the logs of the sidecar containers, and
+// streams the logs of the other containers until the request is cancelled.
+type failingLogsClient struct{ kubernetes.Interface }
+
+func (c failingLogsClient) CoreV1() corev1.CoreV1Interface {
+	return failingLogsCoreV1{c.Interface.CoreV1()}
+}
+
+type failingLogsCoreV1 struct{ corev1.CoreV1Interface }
+
+func (c failingLogsCoreV1) Pods(namespace string) corev1.PodInterface {
+	return failingLogsPods{c.CoreV1Interface.Pods(namespace)}
+}
+
+type failingLogsPods struct{ corev1.PodInterface }
+
+func (p failingLogsPods) GetLogs(_ string, opts *v1.PodLogOptions) *rest.Request {
+	client := &fakerest.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: fakerest.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if opts.Container == "sidecar" {
+				return nil, errors.New("connection reset")
+			}
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}),
+	}
+	return client.Request()
+}
+
+func TestLogsFollowError(t *testing.T) {
+	rel := releaseStub()
+	rel.Namespace = "default"
+	rel.Manifest = logsManifest
+	client := failingLogsClient{fake.NewSimpleClientset(newLogsPod("web-1", map[string]string{"app": "web"}, "nginx", "sidecar"))}
+
+	logs := NewLogs(actionConfigFixture(t))
+	logs.Follow = true
+	errc := make(chan error, 1)
+	go func() {
+		errc <- logs.writeLogs(context.Background(), &bytes.Buffer{}, client, rel)
+	}()
+	select {
+	case err := <-errc:
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "container sidecar")
+		assert.Contains(t, err.Error(), "connection reset")
+	case <-time.After(5 * time.Second):
+		t.Fatal("following the logs did not stop after a stream failed")
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/notify"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// notifyStarted notifies the start of an operation on the named release.
+func (cfg *Configuration) notifyStarted(op, name, namespace string, ch *chart.Chart) {
+	cfg.notify(notify.EventStarted, op, name, namespace, ch, nil, nil)
+}
+
+// notifyDone notifies the completion of an operation on the named release.
+// rel is the revision the operation created or acted upon, if any. A
+// successful rollback is notified as rolled back.
+func (cfg *Configuration) notifyDone(op, name, namespace string, ch *chart.Chart, rel *release.Release, err error) {
+	t := notify.EventSucceeded
+	switch {
+	case err != nil:
+		t = notify.EventFailed
+	case op == "rollback":
+		t = notify.EventRolledBack
+	}
+	cfg.notify(t, op, name, namespace, ch, rel, err)
+}
+
+func (cfg *Configuration) notify(t notify.EventType, op, name, namespace string, ch *chart.Chart, rel *release.Release, err error) {
+	if cfg.Notifier == nil {
+		return
+	}
+
+	ev := &notify.Event{
+		Type:      t,
+		Operation: op,
+		Release:   name,
+		Namespace: namespace,
+		Time:      Timestamper().Time,
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	if rel != nil {
+		ev.Release = rel.Name
+		ev.Namespace = rel.Namespace
+		ev.Revision = rel.Version
+		ch = rel.Chart
+	}
+	if ch != nil && ch.Metadata != nil {
+		ev.Chart = ch.Metadata.Name
+		ev.Version = ch.Metadata.Version
+	}
+
+	// Failures are logged by the notifier and must not fail the operation.
+	cfg.Notifier.Notify(ev)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/notify"
+)
+
+// notifyFixture configures config to notify a local endpoint and returns a
+// function returning the events received so far.
+func notifyFixture(t *testing.T, config *Configuration) func() []notify.Event {
+	t.Helper()
+
+	var mu sync.Mutex
+	var events []notify.Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev notify.Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	n, err := notify.New(&notify.Config{Endpoints: []*notify.Endpoint{{Name: "test", URL: srv.URL}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Notifier = n
+
+	return func() []notify.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]notify.Event(nil), events...)
+	}
+}
+
+func eventTypes(events []notify.Event) []string {
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Operation+" "+string(ev.Type))
+	}
+	return types
+}
+
+func TestNotifyInstallUpgradeRollback(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	instAction := installAction(t)
+	events := notifyFixture(t, instAction.cfg)
+
+	_, err := instAction.Run(buildChart(), map[string]interface{}{})
+	req.NoError(err)
+
+	upAction := NewUpgrade(instAction.cfg)
+	upAction.Namespace = "spaced"
+	_, err = upAction.Run("test-install-release", buildChart(), map[string]interface{}{})
+	req.NoError(err)
+
+	rbAction := NewRollback(instAction.cfg)
+	rbAction.Version = 1
+	req.NoError(rbAction.Run("test-install-release"))
+
+	got := events()
+	is.Equal([]string{
+		"install started", "install succeeded",
+		"upgrade started", "upgrade succeeded",
+		"rollback started", "rollback rolled-back",
+	}, eventTypes(got))
+
+	is.Equal("test-install-release", got[1].Release)
+	is.Equal("spaced", got[1].Namespace)
+	is.Equal(1, got[1].Revision)
+	is.Equal("hello", got[1].Chart)
+	is.Equal(2, got[3].Revision)
+	is.Equal("spaced", got[4].Namespace)
+	is.Equal(3, got[5].Revision)
+}
+
+func TestNotifyFailedUpgrade(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	instAction := installAction(t)
+	_, err := instAction.Run(buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	events := notifyFixture(t, instAction.cfg)
+
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.UpdateError = errors.New("update failed")
+	upAction := NewUpgrade(instAction.cfg)
+	upAction.Namespace = "spaced"
+	_, err = upAction.Run("test-install-release", buildChart(), map[string]interface{}{})
+	req.Error(err)
+
+	got := events()
+	is.Equal([]string{"upgrade started", "upgrade failed"}, eventTypes(got))
+	is.Contains(got[1].Error, "update failed")
+}
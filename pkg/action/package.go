@@ -120,12 +120,9 @@ func (p *Package) Clearsign(filename string) error {
 		return err
 	}
 
-	passphraseFetcher := promptUser
-	if p.PassphraseFile != "" {
-		passphraseFetcher, err = passphraseFileFetcher(p.PassphraseFile, os.Stdin)
-		if err != nil {
-			return err
-		}
+	passphraseFetcher, err := NewPassphraseFetcher(p.PassphraseFile)
+	if err != nil {
+		return err
 	}
 
 	if err := signer.DecryptKey(passphraseFetcher); err != nil {
@@ -140,6 +137,16 @@ func (p *Package) Clearsign(filename string) error {
 	return ioutil.WriteFile(filename+".prov", []byte(sig), 0644)
 }
 
+// NewPassphraseFetcher returns a fetcher of the passphrases of OpenPGP keys. It
+// reads the passphrase from passphraseFile, or from stdin if it is "-", and
+// prompts for it if passphraseFile is empty.
+func NewPassphraseFetcher(passphraseFile string) (provenance.PassphraseFetcher, error) {
+	if passphraseFile == "" {
+		return promptUser, nil
+	}
+	return passphraseFileFetcher(passphraseFile, os.Stdin)
+}
+
 // promptUser implements provenance.PassphraseFetcher
 func promptUser(name string) ([]byte, error) {
 	fmt.Printf("Password for key %q >  ", name)
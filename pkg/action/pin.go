@@ -0,0 +1,69 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// Pin is the action for protecting a release revision from being pruned
+// from the release history.
+//
+// It provides the implementation of 'helm history pin' and 'helm history unpin'.
+type Pin struct {
+	cfg *Configuration
+
+	// Unpin removes the protection from the revision instead of adding it.
+	Unpin bool
+}
+
+// NewPin creates a new Pin object with the given configuration.
+func NewPin(cfg *Configuration) *Pin {
+	return &Pin{
+		cfg: cfg,
+	}
+}
+
+// Run pins (or unpins) the given revision of the named release.
+func (p *Pin) Run(name string, revision int) error {
+	if err := p.cfg.KubeClient.IsReachable(); err != nil {
+		return err
+	}
+
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return errors.Errorf("release name is invalid: %s", name)
+	}
+
+	if revision <= 0 {
+		return errInvalidRevision
+	}
+
+	rel, err := p.cfg.Releases.Get(name, revision)
+	if err != nil {
+		return err
+	}
+
+	if rel.Info.Pinned == !p.Unpin {
+		return nil
+	}
+
+	p.cfg.logger().Debug("setting the pin of revision", "release", name, "revision", revision, "pinned", !p.Unpin)
+	rel.Info.Pinned = !p.Unpin
+	return p.cfg.Releases.Update(rel)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPin(t *testing.T) {
+	is := assert.New(t)
+
+	config := actionConfigFixture(t)
+	rel := releaseStub()
+	is.NoError(config.Releases.Create(rel))
+
+	pin := NewPin(config)
+	is.NoError(pin.Run(rel.Name, rel.Version))
+
+	got, err := config.Releases.Get(rel.Name, rel.Version)
+	is.NoError(err)
+	is.True(got.Info.Pinned)
+
+	pin.Unpin = true
+	is.NoError(pin.Run(rel.Name, rel.Version))
+
+	got, err = config.Releases.Get(rel.Name, rel.Version)
+	is.NoError(err)
+	is.False(got.Info.Pinned)
+
+	is.Error(pin.Run(rel.Name, 2))
+	is.Equal(errInvalidRevision, pin.Run(rel.Name, 0))
+}
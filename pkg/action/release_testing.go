@@ -20,13 +20,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 
+	"helm.sh/helm/v3/pkg/audit"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
 // ReleaseTesting is the action for testing a release.
@@ -38,6 +41,32 @@ type ReleaseTesting struct {
 	// Used for fetching logs from test pods
 	Namespace string
 	Filters   map[string][]string
+	// Parallel is the maximum number of tests of the same weight that are
+	// run concurrently. Tests are run one at a time if it is less than 2.
+	Parallel int
+	// Retries is the maximum number of times a failed test is run again.
+	// A test that allows more retries with its own annotation gets those.
+	Retries int
+}
+
+// TestResult is the result of a test of a release.
+type TestResult struct {
+	// Name is the name of the test.
+	Name string `json:"name"`
+	// Phase is the phase of the last run of the test. It is empty if the
+	// test was skipped or did not run because its setup failed.
+	Phase release.HookPhase `json:"phase,omitempty"`
+	// Skipped is true if the test was filtered out.
+	Skipped bool `json:"skipped,omitempty"`
+	// Duration is how long the last run of the test took.
+	Duration time.Duration `json:"duration"`
+	// Attempts is the number of times the test was run.
+	Attempts int `json:"attempts,omitempty"`
+	// Error is why the last attempt of the test failed, if it did.
+	Error string `json:"error,omitempty"`
+	// Log is an excerpt of the logs of the test, each line prefixed with
+	// the pod and container it comes from.
+	Log string `json:"log,omitempty"`
 }
 
 // NewReleaseTesting creates a new ReleaseTesting object with the given configuration.
@@ -50,6 +79,21 @@ func NewReleaseTesting(cfg *Configuration) *ReleaseTesting {
 
 // Run executes 'helm test' against the given release.
 func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
+	return r.RunWithContext(context.Background(), name)
+}
+
+// RunWithContext executes 'helm test' against the given release, recording
+// tracing spans as children of the span in ctx.
+func (r *ReleaseTesting) RunWithContext(ctx context.Context, name string) (_ *release.Release, err error) {
+	ctx, span := startOperation(ctx, "test", name, r.Namespace)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	rel, err := r.run(ctx, name)
+	r.cfg.recordAudit(audit.OperationTest, name, r.Namespace, nil, nil, rel, err)
+	return rel, err
+}
+
+func (r *ReleaseTesting) run(ctx context.Context, name string) (*release.Release, error) {
 	if err := r.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
@@ -64,40 +108,113 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 		return rel, err
 	}
 
+	// Filters only select the tests; the hooks setting them up and tearing
+	// them down always run.
 	skippedHooks := []*release.Hook{}
 	executingHooks := []*release.Hook{}
-	if len(r.Filters["!name"]) != 0 {
-		for _, h := range rel.Hooks {
-			if contains(r.Filters["!name"], h.Name) {
-				skippedHooks = append(skippedHooks, h)
-			} else {
-				executingHooks = append(executingHooks, h)
-			}
+	for _, h := range rel.Hooks {
+		if isTest(h) && !r.selected(h) {
+			skippedHooks = append(skippedHooks, h)
+		} else {
+			executingHooks = append(executingHooks, h)
 		}
-		rel.Hooks = executingHooks
 	}
-	if len(r.Filters["name"]) != 0 {
-		executingHooks = nil
-		for _, h := range rel.Hooks {
-			if contains(r.Filters["name"], h.Name) {
-				executingHooks = append(executingHooks, h)
-			} else {
-				skippedHooks = append(skippedHooks, h)
-			}
+	rel.Hooks = executingHooks
+	// The results of earlier runs must not be mistaken for those of tests
+	// that do not run because their setup failed.
+	for _, h := range executingHooks {
+		if isTest(h) {
+			h.LastRun = release.HookExecution{}
 		}
-		rel.Hooks = executingHooks
 	}
 
-	if err := r.cfg.execHook(rel, release.HookTest, r.Timeout); err != nil {
-		rel.Hooks = append(skippedHooks, rel.Hooks...)
-		r.cfg.Releases.Update(rel)
-		return rel, err
+	err = r.cfg.execHook(ctx, rel, release.HookPreTest, r.Timeout)
+	if err != nil {
+		err = errors.Wrap(err, "pre-test hooks failed")
+	} else {
+		// All the tests run, so that each has a result.
+		err = r.cfg.execHookWithOptions(ctx, rel, release.HookTest, r.Timeout, hookRunOptions{
+			concurrency: r.Parallel,
+			retries:     r.Retries,
+			keepGoing:   true,
+		})
+	}
+	// The tests are torn down even if they failed.
+	if perr := r.cfg.execHook(ctx, rel, release.HookPostTest, r.Timeout); perr != nil {
+		if err == nil {
+			err = errors.Wrap(perr, "post-test hooks failed")
+		} else {
+			r.cfg.logger().Warn("post-test hooks failed", "release", rel.Name, "error", perr)
+		}
 	}
 
 	rel.Hooks = append(skippedHooks, rel.Hooks...)
+	if err != nil {
+		r.cfg.Releases.Update(rel)
+		return rel, err
+	}
 	return rel, r.cfg.Releases.Update(rel)
 }
 
+// selected returns true if the filters select the test.
+func (r *ReleaseTesting) selected(h *release.Hook) bool {
+	if contains(r.Filters["!name"], h.Name) {
+		return false
+	}
+	return len(r.Filters["name"]) == 0 || contains(r.Filters["name"], h.Name)
+}
+
+// Results returns the result of each test of a release tested by Run, in
+// the order of its hooks. The tests the filters did not select are skipped.
+func (r *ReleaseTesting) Results(rel *release.Release) []TestResult {
+	var results []TestResult
+	for _, h := range rel.Hooks {
+		if !isTest(h) {
+			continue
+		}
+		result := TestResult{Name: h.Name}
+		if !r.selected(h) {
+			// The last run of the test is from an earlier invocation.
+			result.Skipped = true
+			results = append(results, result)
+			continue
+		}
+
+		run := h.LastRun
+		result.Phase = run.Phase
+		result.Attempts = len(run.Attempts)
+		if !run.StartedAt.IsZero() && !run.CompletedAt.IsZero() {
+			result.Duration = run.CompletedAt.Sub(run.StartedAt)
+		}
+		if n := len(run.Attempts); n > 0 {
+			result.Error = run.Attempts[n-1].Error
+		}
+		var log strings.Builder
+		for _, l := range run.Logs {
+			prefix := fmt.Sprintf("[%s/%s] ", l.Pod, l.Container)
+			if l.Log == "" {
+				continue
+			}
+			for _, line := range strings.Split(strings.TrimRight(l.Log, "\n"), "\n") {
+				log.WriteString(prefix + line + "\n")
+			}
+		}
+		result.Log = log.String()
+		results = append(results, result)
+	}
+	return results
+}
+
+// isTest returns true if the hook is a test.
+func isTest(h *release.Hook) bool {
+	for _, e := range h.Events {
+		if e == release.HookTest {
+			return true
+		}
+	}
+	return false
+}
+
 // GetPodLogs will write the logs for all test pods in the given release into
 // the given writer. These can be immediately output to the user or captured for
 // other uses
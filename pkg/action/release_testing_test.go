@@ -0,0 +1,101 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func releaseTestingFixture(t *testing.T, tests ...string) (*ReleaseTesting, *hookKubeClient) {
+	t.Helper()
+	cfg := actionConfigFixture(t)
+	client := &hookKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}, release: make(chan struct{})}
+	close(client.release)
+	cfg.KubeClient = client
+
+	rel := releaseStub()
+	rel.Info.Status = release.StatusDeployed
+	rel.Hooks = hooksFixture(append([]string{"setup", "teardown"}, tests...)...)
+	rel.Hooks[0].Events = []release.HookEvent{release.HookPreTest}
+	rel.Hooks[1].Events = []release.HookEvent{release.HookPostTest}
+	for _, h := range rel.Hooks[2:] {
+		h.Events = []release.HookEvent{release.HookTest}
+	}
+	if err := cfg.Releases.Create(rel); err != nil {
+		t.Fatal(err)
+	}
+	return NewReleaseTesting(cfg), client
+}
+
+func TestReleaseTesting_SetupAndTeardown(t *testing.T) {
+	is := assert.New(t)
+
+	rt, client := releaseTestingFixture(t, "test-a", "test-b")
+	rt.Filters["name"] = []string{"test-a"}
+	rel, err := rt.Run("angry-panda")
+	is.NoError(err)
+	is.Equal([]string{"setup", "test-a", "teardown"}, client.order)
+	is.Len(rel.Hooks, 4)
+
+	// The tests are torn down even if they fail.
+	rt, client = releaseTestingFixture(t, "fail", "test-b")
+	rt.Filters["!name"] = []string{"test-b"}
+	_, err = rt.Run("angry-panda")
+	is.Error(err)
+	is.Contains(err.Error(), "hook failed")
+	is.Equal([]string{"setup", "fail", "teardown"}, client.order)
+}
+
+func TestReleaseTesting_Results(t *testing.T) {
+	defer func(base time.Duration) { hookRetryBaseBackoff = base }(hookRetryBaseBackoff)
+	hookRetryBaseBackoff = 0
+
+	is := assert.New(t)
+	rt, client := releaseTestingFixture(t, "fail", "flaky", "ok", "slow")
+	client.flaky = 1
+	rt.Parallel = 3
+	rt.Retries = 1
+	rt.Filters["!name"] = []string{"slow"}
+
+	rel, err := rt.Run("angry-panda")
+	is.Error(err)
+	is.Contains(err.Error(), "hook failed")
+
+	results := map[string]TestResult{}
+	for _, r := range rt.Results(rel) {
+		results[r.Name] = r
+	}
+	is.Len(results, 4)
+	is.Equal(release.HookPhaseFailed, results["fail"].Phase)
+	is.Equal(2, results["fail"].Attempts)
+	is.Equal("hook failed", results["fail"].Error)
+	is.Equal("[fail-x7k2/main] boom\n", results["fail"].Log)
+	is.Equal(release.HookPhaseSucceeded, results["flaky"].Phase)
+	is.Equal(2, results["flaky"].Attempts)
+	is.Empty(results["flaky"].Error)
+	is.Equal(release.HookPhaseSucceeded, results["ok"].Phase)
+	is.Equal(1, results["ok"].Attempts)
+	is.True(results["slow"].Skipped)
+	is.Empty(results["slow"].Phase)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// Rename is the action for renaming a release, or moving its record to
+// another namespace, without reinstalling it.
+//
+// Every stored revision is rewritten and the ownership metadata of the live
+// resources is updated. If any step fails, the changes made so far are
+// reverted.
+//
+// It provides the implementation of 'helm release rename' and
+// 'helm release move'.
+type Rename struct {
+	cfg *Configuration
+
+	// NewName is the new name of the release. If empty, the release keeps
+	// its name.
+	NewName string
+	// NewNamespace is the namespace the release record is moved to. If
+	// empty, the release stays in its namespace. Only releases whose
+	// resources are all cluster-scoped can be moved.
+	NewNamespace string
+}
+
+// NewRename creates a new Rename object with the given configuration.
+func NewRename(cfg *Configuration) *Rename {
+	return &Rename{
+		cfg: cfg,
+	}
+}
+
+// Run renames the named release. It returns the revisions of the release
+// under its new name.
+func (r *Rename) Run(name string) ([]*release.Release, error) {
+	if err := r.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, errors.Errorf("release name is invalid: %s", name)
+	}
+	newName := name
+	if r.NewName != "" {
+		if err := chartutil.ValidateReleaseName(r.NewName); err != nil {
+			return nil, errors.Errorf("new release name is invalid: %s", r.NewName)
+		}
+		newName = r.NewName
+	}
+
+	rels, err := r.cfg.Releases.History(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the history of release %q", name)
+	}
+	releaseutil.SortByRevision(rels)
+
+	namespace := rels[0].Namespace
+	newNamespace := namespace
+	if r.NewNamespace != "" {
+		newNamespace = r.NewNamespace
+	}
+	if newName == name && newNamespace == namespace {
+		return nil, errors.Errorf("release %q is already named %q in namespace %q", name, newName, newNamespace)
+	}
+
+	target := r.cfg.Releases
+	if newNamespace != namespace {
+		if target, err = r.cfg.releasesInNamespace(newNamespace); err != nil {
+			return nil, err
+		}
+	}
+	useNamespace(target, newNamespace)
+	h, err := target.History(newName)
+	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, err
+	}
+	if len(h) > 0 {
+		return nil, errors.Errorf("release %q already exists in namespace %q", newName, newNamespace)
+	}
+
+	last := rels[len(rels)-1]
+	resources, err := r.cfg.KubeClient.Build(bytes.NewBufferString(last.Manifest), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build kubernetes objects from release manifest")
+	}
+	if newNamespace != namespace {
+		for _, info := range resources {
+			if info.Namespaced() {
+				return nil, errors.Errorf("release %q cannot be moved to namespace %q: %s is namespaced", name, newNamespace, resourceString(info))
+			}
+		}
+	}
+
+	r.cfg.logger().Debug("renaming release", "release", name, "namespace", namespace, "newName", newName, "newNamespace", newNamespace)
+
+	// Store the renamed revisions first, so the release is never lost.
+	renamed := make([]*release.Release, 0, len(rels))
+	for _, rel := range rels {
+		rn := *rel
+		rn.Name = newName
+		rn.Namespace = newNamespace
+		if err := target.Create(&rn); err != nil {
+			r.deleteRevisions(target, renamed)
+			return nil, errors.Wrapf(err, "failed to store revision %d as release %q", rel.Version, newName)
+		}
+		renamed = append(renamed, &rn)
+	}
+
+	annotated, err := setOwnershipAnnotations(resources, newName, newNamespace)
+	if err != nil {
+		r.restoreOwnership(annotated, name, namespace)
+		r.deleteRevisions(target, renamed)
+		return nil, err
+	}
+
+	useNamespace(r.cfg.Releases, namespace)
+	for i, rel := range rels {
+		if _, err := r.cfg.Releases.Delete(rel.Name, rel.Version); err != nil {
+			r.recreateRevisions(rels[:i])
+			r.restoreOwnership(annotated, name, namespace)
+			r.deleteRevisions(target, renamed)
+			return nil, errors.Wrapf(err, "failed to delete revision %d of release %q", rel.Version, name)
+		}
+	}
+	return renamed, nil
+}
+
+// deleteRevisions removes the given revisions from s while reverting a
+// rename.
+func (r *Rename) deleteRevisions(s *storage.Storage, rels []*release.Release) {
+	for _, rel := range rels {
+		useNamespace(s, rel.Namespace)
+		if _, err := s.Delete(rel.Name, rel.Version); err != nil {
+			r.cfg.logger().Warn("rollback: failed to delete revision", "release", rel.Name, "revision", rel.Version, "error", err)
+		}
+	}
+}
+
+// recreateRevisions stores the original revisions again while reverting a
+// rename.
+func (r *Rename) recreateRevisions(rels []*release.Release) {
+	for _, rel := range rels {
+		if err := r.cfg.Releases.Create(rel); err != nil {
+			r.cfg.logger().Warn("rollback: failed to restore revision", "release", rel.Name, "revision", rel.Version, "error", err)
+		}
+	}
+}
+
+// restoreOwnership sets the original ownership annotations while reverting
+// a rename.
+func (r *Rename) restoreOwnership(resources kube.ResourceList, name, namespace string) {
+	if _, err := setOwnershipAnnotations(resources, name, namespace); err != nil {
+		r.cfg.logger().Warn("rollback: failed to restore the ownership metadata", "error", err)
+	}
+}
+
+// useNamespace selects the namespace accessed by drivers storing the
+// releases of every namespace, which read and delete releases in the
+// namespace they last stored a release in. The other drivers are bound to a
+// namespace.
+func useNamespace(s *storage.Storage, namespace string) {
+	if d, ok := s.Driver.(driver.NamespaceSetter); ok {
+		d.SetNamespace(namespace)
+	}
+}
+
+// setOwnershipAnnotations updates the release ownership annotations of the
+// live resources. It returns the resources that were updated, which is a
+// subset of resources if an error is returned. Resources that no longer
+// exist are skipped.
+func setOwnershipAnnotations(resources kube.ResourceList, name, namespace string) (kube.ResourceList, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				helmReleaseNameAnnotation:      name,
+				helmReleaseNamespaceAnnotation: namespace,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var updated kube.ResourceList
+	err = resources.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		helper := resource.NewHelper(info.Client, info.Mapping)
+		if _, err := helper.Patch(info.Namespace, info.Name, types.MergePatchType, patch, nil); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return errors.Wrapf(err, "failed to update the ownership metadata of %s", resourceString(info))
+		}
+		updated.Append(info)
+		return nil
+	})
+	return updated, err
+}
@@ -0,0 +1,184 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+func renameFixture(t *testing.T, d driver.Driver) *Configuration {
+	t.Helper()
+	config := actionConfigFixture(t)
+	config.Releases.Driver = d
+	for _, version := range []int{1, 2} {
+		rel := namedReleaseStub("angry-panda", release.StatusSuperseded)
+		rel.Namespace = "default"
+		rel.Version = version
+		if version == 2 {
+			rel.Info.Status = release.StatusDeployed
+		}
+		if err := config.Releases.Create(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return config
+}
+
+func TestRename(t *testing.T) {
+	is := assert.New(t)
+
+	config := renameFixture(t, driver.NewMemory())
+	client := NewRename(config)
+	client.NewName = "happy-panda"
+
+	rels, err := client.Run("angry-panda")
+	is.NoError(err)
+	is.Len(rels, 2)
+
+	h, err := config.Releases.History("happy-panda")
+	is.NoError(err)
+	is.Len(h, 2)
+
+	last, err := config.Releases.Last("happy-panda")
+	is.NoError(err)
+	is.Equal(2, last.Version)
+	is.Equal("default", last.Namespace)
+	is.Equal(release.StatusDeployed, last.Info.Status)
+
+	_, err = config.Releases.History("angry-panda")
+	is.True(errors.Is(err, driver.ErrReleaseNotFound))
+}
+
+func TestRenameErrors(t *testing.T) {
+	config := renameFixture(t, driver.NewMemory())
+	other := namedReleaseStub("happy-panda", release.StatusDeployed)
+	other.Namespace = "default"
+	if err := config.Releases.Create(other); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		release   string
+		newName   string
+		namespace string
+		expect    string
+	}{
+		{"missing release", "sad-panda", "happy-panda", "", "failed to read the history"},
+		{"invalid name", "angry-panda", "Happy_Panda", "", "new release name is invalid"},
+		{"unchanged", "angry-panda", "angry-panda", "default", "is already named"},
+		{"existing release", "angry-panda", "happy-panda", "", "already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewRename(config)
+			client.NewName = tt.newName
+			client.NewNamespace = tt.namespace
+			_, err := client.Run(tt.release)
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Errorf("expected error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+
+	// The failed renames left the release untouched.
+	h, err := config.Releases.History("angry-panda")
+	assert.NoError(t, err)
+	assert.Len(t, h, 2)
+}
+
+func TestRenameMove(t *testing.T) {
+	for name, newDriver := range map[string]func(t *testing.T) driver.Driver{
+		"memory": func(t *testing.T) driver.Driver { return driver.NewMemory() },
+		"file": func(t *testing.T) driver.Driver {
+			d, err := driver.NewFile(t.TempDir(), t.Logf, "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return d
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			is := assert.New(t)
+
+			d := newDriver(t)
+			config := renameFixture(t, d)
+			client := NewRename(config)
+			client.NewNamespace = "other"
+
+			rels, err := client.Run("angry-panda")
+			is.NoError(err)
+			is.Len(rels, 2)
+
+			ns := d.(driver.NamespaceSetter)
+			ns.SetNamespace("other")
+			h, err := config.Releases.History("angry-panda")
+			is.NoError(err)
+			is.Len(h, 2)
+			last, err := config.Releases.Last("angry-panda")
+			is.NoError(err)
+			is.Equal("other", last.Namespace)
+			is.Equal(2, last.Version)
+
+			ns.SetNamespace("default")
+			_, err = config.Releases.History("angry-panda")
+			is.True(errors.Is(err, driver.ErrReleaseNotFound))
+		})
+	}
+}
+
+// failingDeleteDriver fails to delete revision 2 of a release.
+type failingDeleteDriver struct {
+	*driver.Memory
+	name string
+}
+
+func (d *failingDeleteDriver) Delete(key string) (*release.Release, error) {
+	if key == fmt.Sprintf("sh.helm.release.v1.%s.v2", d.name) {
+		return nil, errors.New("delete failed")
+	}
+	return d.Memory.Delete(key)
+}
+
+func TestRenameRollback(t *testing.T) {
+	is := assert.New(t)
+
+	config := renameFixture(t, driver.NewMemory())
+	config.Releases.Driver = &failingDeleteDriver{Memory: config.Releases.Driver.(*driver.Memory), name: "angry-panda"}
+
+	client := NewRename(config)
+	client.NewName = "happy-panda"
+	_, err := client.Run("angry-panda")
+	is.Error(err)
+	is.Contains(err.Error(), "failed to delete revision 2")
+
+	// Revision 1 was deleted before the failure and has been restored.
+	h, err := config.Releases.History("angry-panda")
+	is.NoError(err)
+	is.Len(h, 2)
+
+	_, err = config.Releases.History("happy-panda")
+	is.True(errors.Is(err, driver.ErrReleaseNotFound))
+}
@@ -18,15 +18,18 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/pkg/audit"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/release"
 	helmtime "helm.sh/helm/v3/pkg/time"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
 // Rollback is the action for rolling back to a given release.
@@ -44,7 +47,9 @@ type Rollback struct {
 	Recreate      bool // will (if true) recreate pods after a rollback.
 	Force         bool // will (if true) force resource upgrade through uninstall/recreate if needed
 	CleanupOnFail bool
-	MaxHistory    int // MaxHistory limits the maximum number of revisions saved per release
+	MaxHistory    int           // MaxHistory limits the maximum number of revisions saved per release
+	MaxHistoryAge time.Duration // MaxHistoryAge limits how long revisions are saved after they were last deployed
+	MinHistory    int           // MinHistory is the minimum number of revisions kept when pruning by age
 }
 
 // NewRollback creates a new Rollback object with the given configuration.
@@ -56,32 +61,63 @@ func NewRollback(cfg *Configuration) *Rollback {
 
 // Run executes 'helm rollback' against the given release.
 func (r *Rollback) Run(name string) error {
+	return r.RunWithContext(context.Background(), name)
+}
+
+// RunWithContext executes 'helm rollback' against the given release,
+// recording tracing spans as children of the span in ctx.
+func (r *Rollback) RunWithContext(ctx context.Context, name string) (err error) {
+	ctx, span := startOperation(ctx, "rollback", name, "")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if r.DryRun {
+		return r.run(ctx, name)
+	}
+	prev := r.cfg.observedRelease(name)
+	namespace := ""
+	if prev != nil {
+		namespace = prev.Namespace
+	}
+	r.cfg.notifyStarted("rollback", name, namespace, nil)
+	err = r.run(ctx, name)
+	var rel *release.Release
+	if err == nil {
+		rel = r.cfg.observedRelease(name)
+	}
+	r.cfg.recordAudit(audit.OperationRollback, name, namespace, nil, prev, rel, err)
+	r.cfg.notifyDone("rollback", name, namespace, nil, rel, err)
+	return err
+}
+
+func (r *Rollback) run(ctx context.Context, name string) error {
 	if err := r.cfg.KubeClient.IsReachable(); err != nil {
 		return err
 	}
 
 	r.cfg.Releases.MaxHistory = r.MaxHistory
+	r.cfg.Releases.MaxHistoryAge = r.MaxHistoryAge
+	r.cfg.Releases.MinHistory = r.MinHistory
 
-	r.cfg.Log("preparing rollback of %s", name)
+	r.cfg.logger().Debug("preparing rollback", "release", name)
 	currentRelease, targetRelease, err := r.prepareRollback(name)
 	if err != nil {
 		return err
 	}
 
 	if !r.DryRun {
-		r.cfg.Log("creating rolled back release for %s", name)
+		r.cfg.logger().Debug("creating rolled back release", "release", name)
 		if err := r.cfg.Releases.Create(targetRelease); err != nil {
 			return err
 		}
 	}
 
-	r.cfg.Log("performing rollback of %s", name)
-	if _, err := r.performRollback(currentRelease, targetRelease); err != nil {
+	r.cfg.logger().Debug("performing rollback", "release", name)
+	if _, err := r.performRollback(ctx, currentRelease, targetRelease); err != nil {
 		return err
 	}
 
 	if !r.DryRun {
-		r.cfg.Log("updating status for rolled back release for %s", name)
+		r.cfg.logger().Debug("updating status for rolled back release", "release", name)
 		if err := r.cfg.Releases.Update(targetRelease); err != nil {
 			return err
 		}
@@ -110,7 +146,7 @@ func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Rele
 		previousVersion = currentRelease.Version - 1
 	}
 
-	r.cfg.Log("rolling back %s (current: v%d, target: v%d)", name, currentRelease.Version, previousVersion)
+	r.cfg.logger().Info("rolling back", "release", name, "current", currentRelease.Version, "target", previousVersion)
 
 	previousRelease, err := r.cfg.Releases.Get(name, previousVersion)
 	if err != nil {
@@ -140,9 +176,9 @@ func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Rele
 	return currentRelease, targetRelease, nil
 }
 
-func (r *Rollback) performRollback(currentRelease, targetRelease *release.Release) (*release.Release, error) {
+func (r *Rollback) performRollback(ctx context.Context, currentRelease, targetRelease *release.Release) (*release.Release, error) {
 	if r.DryRun {
-		r.cfg.Log("dry run for %s", targetRelease.Name)
+		r.cfg.logger().Debug("dry run", "release", targetRelease.Name)
 		return targetRelease, nil
 	}
 
@@ -157,25 +193,25 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 
 	// pre-rollback hooks
 	if !r.DisableHooks {
-		if err := r.cfg.execHook(targetRelease, release.HookPreRollback, r.Timeout); err != nil {
+		if err := r.cfg.execHook(ctx, targetRelease, release.HookPreRollback, r.Timeout); err != nil {
 			return targetRelease, err
 		}
 	} else {
-		r.cfg.Log("rollback hooks disabled for %s", targetRelease.Name)
+		r.cfg.logger().Debug("rollback hooks disabled", "release", targetRelease.Name)
 	}
 
-	results, err := r.cfg.KubeClient.Update(current, target, r.Force)
+	results, err := r.cfg.kubeUpdate(ctx, current, target, r.Force)
 
 	if err != nil {
 		msg := fmt.Sprintf("Rollback %q failed: %s", targetRelease.Name, err)
-		r.cfg.Log("warning: %s", msg)
+		r.cfg.logger().Warn("rollback failed", "release", targetRelease.Name, "error", err)
 		currentRelease.Info.Status = release.StatusSuperseded
 		targetRelease.Info.Status = release.StatusFailed
 		targetRelease.Info.Description = msg
 		r.cfg.recordRelease(currentRelease)
 		r.cfg.recordRelease(targetRelease)
 		if r.CleanupOnFail {
-			r.cfg.Log("Cleanup on fail set, cleaning up %d resources", len(results.Created))
+			r.cfg.logger().Info("cleanup on fail set, cleaning up resources", "count", len(results.Created))
 			_, errs := r.cfg.KubeClient.Delete(results.Created)
 			if errs != nil {
 				var errorList []string
@@ -184,42 +220,33 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 				}
 				return targetRelease, errors.Wrapf(fmt.Errorf("unable to cleanup resources: %s", strings.Join(errorList, ", ")), "an error occurred while cleaning up resources. original rollback error: %s", err)
 			}
-			r.cfg.Log("Resource cleanup complete")
+			r.cfg.logger().Info("resource cleanup complete")
 		}
 		return targetRelease, err
 	}
 
 	if r.Recreate {
 		// NOTE: Because this is not critical for a release to succeed, we just
-		// log if an error occurs and continue onward. If we ever introduce log
-		// levels, we should make these error level logs so users are notified
-		// that they'll need to go do the cleanup on their own
+		// log if an error occurs and continue onward. These are error level
+		// logs so users are notified that they'll need to go do the cleanup on
+		// their own
 		if err := recreate(r.cfg, results.Updated); err != nil {
-			r.cfg.Log(err.Error())
+			r.cfg.logger().Error("failed to recreate pods", "error", err)
 		}
 	}
 
 	if r.Wait {
-		if r.WaitForJobs {
-			if err := r.cfg.KubeClient.WaitWithJobs(target, r.Timeout); err != nil {
-				targetRelease.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", targetRelease.Name, err.Error()))
-				r.cfg.recordRelease(currentRelease)
-				r.cfg.recordRelease(targetRelease)
-				return targetRelease, errors.Wrapf(err, "release %s failed", targetRelease.Name)
-			}
-		} else {
-			if err := r.cfg.KubeClient.Wait(target, r.Timeout); err != nil {
-				targetRelease.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", targetRelease.Name, err.Error()))
-				r.cfg.recordRelease(currentRelease)
-				r.cfg.recordRelease(targetRelease)
-				return targetRelease, errors.Wrapf(err, "release %s failed", targetRelease.Name)
-			}
+		if err := r.cfg.kubeWait(ctx, target, r.Timeout, r.WaitForJobs); err != nil {
+			targetRelease.SetStatus(release.StatusFailed, fmt.Sprintf("Release %q failed: %s", targetRelease.Name, err.Error()))
+			r.cfg.recordRelease(currentRelease)
+			r.cfg.recordRelease(targetRelease)
+			return targetRelease, errors.Wrapf(err, "release %s failed", targetRelease.Name)
 		}
 	}
 
 	// post-rollback hooks
 	if !r.DisableHooks {
-		if err := r.cfg.execHook(targetRelease, release.HookPostRollback, r.Timeout); err != nil {
+		if err := r.cfg.execHook(ctx, targetRelease, release.HookPostRollback, r.Timeout); err != nil {
 			return targetRelease, err
 		}
 	}
@@ -230,7 +257,7 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 	}
 	// Supersede all previous deployments, see issue #2941.
 	for _, rel := range deployed {
-		r.cfg.Log("superseding previous deployment %d", rel.Version)
+		r.cfg.logger().Debug("superseding previous deployment", "release", rel.Name, "revision", rel.Version)
 		rel.Info.Status = release.StatusSuperseded
 		r.cfg.recordRelease(rel)
 	}
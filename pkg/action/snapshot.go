@@ -0,0 +1,228 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/internal/diffutil"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// snapshotExt is the extension of the files of a snapshot.
+const snapshotExt = ".yaml"
+
+// snapshotIndex is the file that lists the files of a snapshot, so that only
+// those are ever replaced or removed.
+const snapshotIndex = ".helm-snapshot"
+
+var (
+	sourceComment    = regexp.MustCompile(`(?m)^# Source: (.+)$`)
+	unsafeInFilename = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// SnapshotFiles splits a rendered manifest into one file per resource, named
+// after the kind, namespace and name of the resource. The resources are
+// normalised, with their keys sorted and comments other than their source
+// removed, so that snapshots only differ when the resources do.
+func SnapshotFiles(manifest string) (map[string]string, error) {
+	manifests := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(manifests))
+	for k := range manifests {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	files := map[string]string{}
+	for _, k := range keys {
+		m := manifests[k]
+		var source string
+		if match := sourceComment.FindStringSubmatch(m); match != nil {
+			source = match[1]
+		}
+		var content map[string]interface{}
+		if err := yaml.Unmarshal([]byte(m), &content); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse a resource of %s", source)
+		}
+		if len(content) == 0 {
+			continue
+		}
+		data, err := yaml.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		if source != "" {
+			data = append([]byte(fmt.Sprintf("# Source: %s\n", source)), data...)
+		}
+
+		name := snapshotFilename(content, source)
+		for i := 2; files[name] != ""; i++ {
+			name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(snapshotFilename(content, source), snapshotExt), i, snapshotExt)
+		}
+		files[name] = string(data)
+	}
+	return files, nil
+}
+
+// snapshotFilename returns the name of the file of a resource, like
+// deployment_default_web.yaml.
+func snapshotFilename(content map[string]interface{}, source string) string {
+	metadata, _ := content["metadata"].(map[string]interface{})
+	kind, _ := content["kind"].(string)
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+
+	var parts []string
+	for _, p := range []string{kind, namespace, name} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if kind == "" || name == "" {
+		// Not a resource; name it after its template.
+		parts = []string{strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))}
+	}
+	filename := unsafeInFilename.ReplaceAllString(strings.ToLower(strings.Join(parts, "_")), "_")
+	if filename == "" {
+		filename = "unknown"
+	}
+	return filename + snapshotExt
+}
+
+// WriteSnapshot writes the files of a snapshot to dir, and removes the files of
+// resources no longer rendered. Other files of dir are left alone, but dir must
+// be empty if it is not a snapshot already.
+func WriteSnapshot(dir string, files map[string]string) error {
+	old, err := readSnapshot(dir)
+	if err != nil {
+		return err
+	}
+	for name := range old {
+		if _, ok := files[name]; !ok {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, defaultDirectoryPermission); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name, data := range files {
+		names = append(names, name)
+		if old[name] == data {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			return err
+		}
+	}
+	sort.Strings(names)
+	index := strings.Join(names, "\n")
+	if index != "" {
+		index += "\n"
+	}
+	return ioutil.WriteFile(filepath.Join(dir, snapshotIndex), []byte(index), 0644)
+}
+
+// DiffSnapshot returns a unified diff from the snapshot in dir to the files,
+// which is empty if they are the same.
+func DiffSnapshot(dir string, files map[string]string) (string, error) {
+	old, err := readSnapshot(dir)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	for name := range old {
+		if _, ok := files[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diff strings.Builder
+	for _, name := range names {
+		if old[name] == files[name] {
+			continue
+		}
+		from, to := filepath.ToSlash(filepath.Join(dir, name)), "rendered/"+name
+		if _, ok := old[name]; !ok {
+			from = "/dev/null"
+		}
+		if _, ok := files[name]; !ok {
+			to = "/dev/null"
+		}
+		d, err := diffutil.Unified(old[name], files[name], from, to)
+		if err != nil {
+			return "", err
+		}
+		diff.WriteString(d)
+	}
+	return diff.String(), nil
+}
+
+// readSnapshot reads the files of the snapshot in dir, as listed by its index.
+// A missing or empty directory is an empty snapshot; any other directory
+// without an index is not a snapshot.
+func readSnapshot(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	index, err := ioutil.ReadFile(filepath.Join(dir, snapshotIndex))
+	if os.IsNotExist(err) {
+		if len(entries) > 0 {
+			return nil, errors.Errorf("%s is not a snapshot and is not empty", dir)
+		}
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Fields(string(index)) {
+		// Only files named like those of a snapshot are read, so that an
+		// edited index cannot point outside of dir.
+		if name != filepath.Base(name) || filepath.Ext(name) != snapshotExt {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files[name] = string(data)
+	}
+	return files, nil
+}
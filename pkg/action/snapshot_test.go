@@ -0,0 +1,181 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var snapshotManifest = `---
+# Source: web/templates/service.yaml
+# A comment
+apiVersion: v1
+kind: Service
+metadata:
+  namespace: prod
+  name: web
+spec:
+  type: ClusterIP
+  ports: [{port: 80}]
+---
+# Source: web/templates/configmaps.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+---
+# Source: web/templates/configmaps.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+data:
+  key: value
+---
+# Source: web/templates/list.yaml
+items: []
+---
+# Source: web/templates/empty.yaml
+`
+
+func TestSnapshotFiles(t *testing.T) {
+	files, err := SnapshotFiles(snapshotManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"service_prod_web.yaml": "# Source: web/templates/service.yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: prod\nspec:\n  ports:\n  - port: 80\n  type: ClusterIP\n",
+		"configmap_web.yaml":    "# Source: web/templates/configmaps.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n",
+		"configmap_web-2.yaml":  "# Source: web/templates/configmaps.yaml\napiVersion: v1\ndata:\n  key: value\nkind: ConfigMap\nmetadata:\n  name: web\n",
+		"list.yaml":             "# Source: web/templates/list.yaml\nitems: []\n",
+	}
+	if !reflect.DeepEqual(expect, files) {
+		t.Errorf("expected files %q, got %q", expect, files)
+	}
+}
+
+func TestWriteAndDiffSnapshot(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snapshot")
+	files := map[string]string{
+		"configmap_web.yaml": "kind: ConfigMap\nmetadata:\n  name: web\n",
+		"service_web.yaml":   "kind: Service\nmetadata:\n  name: web\n",
+	}
+
+	diff, err := DiffSnapshot(dir, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `--- /dev/null
++++ rendered/configmap_web.yaml
+@@ -0,0 +1,3 @@
++kind: ConfigMap
++metadata:
++  name: web
+--- /dev/null
++++ rendered/service_web.yaml
+@@ -0,0 +1,3 @@
++kind: Service
++metadata:
++  name: web
+`
+	if diff != expect {
+		t.Errorf("expected diff\n%s\ngot\n%s", expect, diff)
+	}
+
+	if err := WriteSnapshot(dir, files); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"README.md": "Not a resource", "values.yaml": "name: web\n"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff, err := DiffSnapshot(dir, files); err != nil || diff != "" {
+		t.Errorf("expected no diff, got %q (%v)", diff, err)
+	}
+
+	changed := map[string]string{
+		"configmap_web.yaml": "kind: ConfigMap\nmetadata:\n  name: api\n",
+	}
+	diff, err = DiffSnapshot(dir, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `--- ` + filepath.ToSlash(dir) + `/configmap_web.yaml
++++ rendered/configmap_web.yaml
+@@ -1,3 +1,3 @@
+ kind: ConfigMap
+ metadata:
+-  name: web
++  name: api
+--- ` + filepath.ToSlash(dir) + `/service_web.yaml
++++ /dev/null
+@@ -1,3 +0,0 @@
+-kind: Service
+-metadata:
+-  name: web
+`
+	if diff != expect {
+		t.Errorf("expected diff\n%s\ngot\n%s", expect, diff)
+	}
+
+	if err := WriteSnapshot(dir, changed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "service_web.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected the file of the removed resource to be removed, got %v", err)
+	}
+	for _, name := range []string{"README.md", "values.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be left alone, got %v", name, err)
+		}
+	}
+	if diff, err := DiffSnapshot(dir, changed); err != nil || diff != "" {
+		t.Errorf("expected no diff, got %q (%v)", diff, err)
+	}
+}
+
+func TestWriteSnapshotNotASnapshot(t *testing.T) {
+	// Like a chart, which is not a snapshot.
+	dir := t.TempDir()
+	for _, name := range []string{"Chart.yaml", "values.yaml"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("name: web\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{"configmap_web.yaml": "kind: ConfigMap\nmetadata:\n  name: web\n"}
+
+	expect := dir + " is not a snapshot and is not empty"
+	if err := WriteSnapshot(dir, files); err == nil || err.Error() != expect {
+		t.Errorf("expected error %q, got %v", expect, err)
+	}
+	if _, err := DiffSnapshot(dir, files); err == nil || err.Error() != expect {
+		t.Errorf("expected error %q, got %v", expect, err)
+	}
+	for _, name := range []string{"Chart.yaml", "values.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be left alone, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "configmap_web.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected no snapshot to be written, got %v", err)
+	}
+}
@@ -17,6 +17,11 @@ limitations under the License.
 package action
 
 import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 )
 
@@ -49,3 +54,17 @@ func (s *Status) Run(name string) (*release.Release, error) {
 
 	return s.cfg.releaseContent(name, s.Version)
 }
+
+// Resources returns the live state of the resources in the manifest of the
+// release. Resources that no longer exist are reported as missing.
+func (s *Status) Resources(rel *release.Release) ([]kube.ResourceStatus, error) {
+	sc, ok := s.cfg.KubeClient.(kube.StatusInterface)
+	if !ok {
+		return nil, errors.New("the kubernetes client cannot report the state of resources")
+	}
+	resources, err := s.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build kubernetes objects from release manifest")
+	}
+	return sc.Statuses(resources)
+}
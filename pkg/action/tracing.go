@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"helm.sh/helm/v3/pkg/kube"
+)
+
+// tracer records the spans of release operations.
+var tracer = otel.Tracer("helm.sh/helm/v3/pkg/action")
+
+// startOperation starts the span of a release operation. The namespace is
+// omitted if it is not known yet.
+func startOperation(ctx context.Context, op, name, namespace string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attribute.String("release", name)}
+	if namespace != "" {
+		attrs = append(attrs, attribute.String("namespace", namespace))
+	}
+	return tracer.Start(ctx, "helm."+op, trace.WithAttributes(attrs...))
+}
+
+// spanContext returns a context that only keeps the span of ctx. It is used
+// for the cleanup of a failed operation, which must run even if ctx, maybe
+// the reason it failed, is cancelled or past its deadline.
+func spanContext(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// kubeCreate creates resources, tracing the creation as a child of the span
+// in ctx if the client supports it.
+func (cfg *Configuration) kubeCreate(ctx context.Context, resources kube.ResourceList) (*kube.Result, error) {
+	if kc, ok := cfg.KubeClient.(kube.ContextInterface); ok {
+		return kc.CreateContext(ctx, resources)
+	}
+	return cfg.KubeClient.Create(resources)
+}
+
+// kubeUpdate updates resources, tracing the update as a child of the span
+// in ctx if the client supports it.
+func (cfg *Configuration) kubeUpdate(ctx context.Context, original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	if kc, ok := cfg.KubeClient.(kube.ContextInterface); ok {
+		return kc.UpdateContext(ctx, original, target, force)
+	}
+	return cfg.KubeClient.Update(original, target, force)
+}
+
+// kubeWait waits for resources to be ready, tracing the wait as a child of
+// the span in ctx if the client supports it.
+func (cfg *Configuration) kubeWait(ctx context.Context, resources kube.ResourceList, timeout time.Duration, withJobs bool) error {
+	if kc, ok := cfg.KubeClient.(kube.ContextInterface); ok {
+		return kc.WaitContext(ctx, resources, timeout, withJobs)
+	}
+	if withJobs {
+		return cfg.KubeClient.WaitWithJobs(resources, timeout)
+	}
+	return cfg.KubeClient.Wait(resources, timeout)
+}
+
+// kubeWatchUntilReady watches hook resources until they are ready, tracing
+// the watch as a child of the span in ctx if the client supports it.
+func (cfg *Configuration) kubeWatchUntilReady(ctx context.Context, resources kube.ResourceList, timeout time.Duration) error {
+	if kc, ok := cfg.KubeClient.(kube.ContextInterface); ok {
+		return kc.WatchUntilReadyContext(ctx, resources, timeout)
+	}
+	return cfg.KubeClient.WatchUntilReady(resources, timeout)
+}
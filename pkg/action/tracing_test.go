@@ -0,0 +1,80 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+)
+
+func TestInstallTracing(t *testing.T) {
+	// The global tracer provider delegates to the first provider set, so
+	// this is the only test recording spans.
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	instAction := installAction(t)
+	_, err := instAction.RunWithContext(ctx, buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	parent.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	for _, name := range []string{"helm.install", "helm.render", "engine.Render", "helm.hooks pre-install", "helm.hooks post-install", "helm.hook"} {
+		assert.Contains(t, spans, name)
+	}
+
+	parentOf := func(child, name string) {
+		t.Helper()
+		if s, ok := spans[child]; ok {
+			assert.Equal(t, spans[name].SpanContext().SpanID(), s.Parent().SpanID(), "parent of %s", child)
+		}
+	}
+	parentOf("helm.install", "parent")
+	parentOf("helm.render", "helm.install")
+	parentOf("engine.Render", "helm.render")
+	parentOf("helm.hooks post-install", "helm.install")
+	parentOf("helm.hook", "helm.hooks post-install")
+
+	// The uninstall of a failed atomic install is traced as part of it.
+	recorded := len(recorder.Ended())
+	instAction = installAction(t)
+	instAction.Atomic = true
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WaitError = errors.New("I timed out")
+	_, err = instAction.RunWithContext(context.Background(), buildChart(), map[string]interface{}{})
+	require.Error(t, err)
+
+	spans = map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended()[recorded:] {
+		spans[s.Name()] = s
+	}
+	require.Contains(t, spans, "helm.uninstall")
+	parentOf("helm.uninstall", "helm.install")
+}
@@ -17,16 +17,19 @@ limitations under the License.
 package action
 
 import (
+	"context"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/pkg/audit"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
 	helmtime "helm.sh/helm/v3/pkg/time"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
 // Uninstall is the action for uninstalling releases.
@@ -52,6 +55,34 @@ func NewUninstall(cfg *Configuration) *Uninstall {
 
 // Run uninstalls the given release.
 func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error) {
+	return u.RunWithContext(context.Background(), name)
+}
+
+// RunWithContext uninstalls the given release, recording tracing spans as
+// children of the span in ctx.
+func (u *Uninstall) RunWithContext(ctx context.Context, name string) (_ *release.UninstallReleaseResponse, err error) {
+	ctx, span := startOperation(ctx, "uninstall", name, "")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if u.DryRun {
+		return u.run(ctx, name)
+	}
+	rel := u.cfg.observedRelease(name)
+	namespace := ""
+	if rel != nil {
+		namespace = rel.Namespace
+	}
+	u.cfg.notifyStarted("uninstall", name, namespace, nil)
+	res, err := u.run(ctx, name)
+	if res != nil && res.Release != nil {
+		rel = res.Release
+	}
+	u.cfg.recordAudit(audit.OperationUninstall, name, namespace, nil, nil, rel, err)
+	u.cfg.notifyDone("uninstall", name, namespace, nil, rel, err)
+	return res, err
+}
+
+func (u *Uninstall) run(ctx context.Context, name string) (*release.UninstallReleaseResponse, error) {
 	if err := u.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
@@ -92,24 +123,24 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 		return nil, errors.Errorf("the release named %q is already deleted", name)
 	}
 
-	u.cfg.Log("uninstall: Deleting %s", name)
+	u.cfg.logger().Debug("uninstall: deleting release", "release", name)
 	rel.Info.Status = release.StatusUninstalling
 	rel.Info.Deleted = helmtime.Now()
 	rel.Info.Description = "Deletion in progress (or silently failed)"
 	res := &release.UninstallReleaseResponse{Release: rel}
 
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(rel, release.HookPreDelete, u.Timeout); err != nil {
+		if err := u.cfg.execHook(ctx, rel, release.HookPreDelete, u.Timeout); err != nil {
 			return res, err
 		}
 	} else {
-		u.cfg.Log("delete hooks disabled for %s", name)
+		u.cfg.logger().Debug("delete hooks disabled", "release", name)
 	}
 
 	// From here on out, the release is currently considered to be in StatusUninstalling
 	// state.
 	if err := u.cfg.Releases.Update(rel); err != nil {
-		u.cfg.Log("uninstall: Failed to store updated release: %s", err)
+		u.cfg.logger().Warn("uninstall: failed to store updated release", "release", name, "error", err)
 	}
 
 	deletedResources, kept, errs := u.deleteRelease(rel)
@@ -128,7 +159,7 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	}
 
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(rel, release.HookPostDelete, u.Timeout); err != nil {
+		if err := u.cfg.execHook(ctx, rel, release.HookPostDelete, u.Timeout); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -141,7 +172,7 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	}
 
 	if !u.KeepHistory {
-		u.cfg.Log("purge requested for %s", name)
+		u.cfg.logger().Debug("purge requested", "release", name)
 		err := u.purgeReleases(rels...)
 		if err != nil {
 			errs = append(errs, errors.Wrap(err, "uninstall: Failed to purge the release"))
@@ -156,7 +187,7 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	}
 
 	if err := u.cfg.Releases.Update(rel); err != nil {
-		u.cfg.Log("uninstall: Failed to store updated release: %s", err)
+		u.cfg.logger().Warn("uninstall: failed to store updated release", "release", name, "error", err)
 	}
 
 	if len(errs) > 0 {
@@ -0,0 +1,50 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/unittest"
+)
+
+// UnitTest is the action for running the unit tests of the templates of a
+// chart.
+//
+// It provides the implementation of 'helm unittest'.
+type UnitTest struct {
+	UpdateSnapshots bool
+}
+
+// NewUnitTest creates a new UnitTest object.
+func NewUnitTest() *UnitTest {
+	return &UnitTest{}
+}
+
+// Run executes 'helm unittest' against the chart in the given directory.
+func (u *UnitTest) Run(path string) (*unittest.Result, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, errors.Errorf("%s is not a chart directory", path)
+	}
+	return unittest.Run(path, unittest.Options{UpdateSnapshots: u.UpdateSnapshots})
+}
@@ -28,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/resource"
 
+	"helm.sh/helm/v3/pkg/audit"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/kube"
@@ -35,6 +36,7 @@ import (
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
 // Upgrade is the action for upgrading releases.
@@ -84,6 +86,10 @@ type Upgrade struct {
 	Recreate bool
 	// MaxHistory limits the maximum number of revisions saved per release
 	MaxHistory int
+	// MaxHistoryAge limits how long revisions are saved after they were last deployed
+	MaxHistoryAge time.Duration
+	// MinHistory is the minimum number of revisions kept when pruning by age
+	MinHistory int
 	// Atomic, if true, will roll back on failure.
 	Atomic bool
 	// CleanupOnFail will, if true, cause the upgrade to delete newly-created resources on a failed update.
@@ -127,7 +133,22 @@ func (u *Upgrade) Run(name string, chart *chart.Chart, vals map[string]interface
 }
 
 // RunWithContext executes the upgrade on the given release with context.
-func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
+func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.Chart, vals map[string]interface{}) (_ *release.Release, err error) {
+	ctx, span := startOperation(ctx, "upgrade", name, u.Namespace)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if u.DryRun {
+		return u.run(ctx, name, chart, vals)
+	}
+	u.cfg.notifyStarted("upgrade", name, u.Namespace, chart)
+	prev := u.cfg.observedRelease(name)
+	rel, err := u.run(ctx, name, chart, vals)
+	u.cfg.recordAudit(audit.OperationUpgrade, name, u.Namespace, chart, prev, rel, err)
+	u.cfg.notifyDone("upgrade", name, u.Namespace, chart, rel, err)
+	return rel, err
+}
+
+func (u *Upgrade) run(ctx context.Context, name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
 	if err := u.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
@@ -139,22 +160,24 @@ func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.
 	if err := chartutil.ValidateReleaseName(name); err != nil {
 		return nil, errors.Errorf("release name is invalid: %s", name)
 	}
-	u.cfg.Log("preparing upgrade for %s", name)
-	currentRelease, upgradedRelease, err := u.prepareUpgrade(name, chart, vals)
+	u.cfg.logger().Debug("preparing upgrade", "release", name)
+	currentRelease, upgradedRelease, err := u.prepareUpgrade(ctx, name, chart, vals)
 	if err != nil {
 		return nil, err
 	}
 
 	u.cfg.Releases.MaxHistory = u.MaxHistory
+	u.cfg.Releases.MaxHistoryAge = u.MaxHistoryAge
+	u.cfg.Releases.MinHistory = u.MinHistory
 
-	u.cfg.Log("performing update for %s", name)
+	u.cfg.logger().Debug("performing update", "release", name)
 	res, err := u.performUpgrade(ctx, currentRelease, upgradedRelease)
 	if err != nil {
 		return res, err
 	}
 
 	if !u.DryRun {
-		u.cfg.Log("updating status for upgraded release for %s", name)
+		u.cfg.logger().Debug("updating status for upgraded release", "release", name)
 		if err := u.cfg.Releases.Update(upgradedRelease); err != nil {
 			return res, err
 		}
@@ -164,7 +187,7 @@ func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.
 }
 
 // prepareUpgrade builds an upgraded release for an upgrade operation.
-func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, *release.Release, error) {
+func (u *Upgrade) prepareUpgrade(ctx context.Context, name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, *release.Release, error) {
 	if chart == nil {
 		return nil, nil, errMissingChart
 	}
@@ -228,10 +251,10 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 	}
 	valuesToRender, err := chartutil.ToRenderValues(chart, vals, options, caps)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, valuesError(chart, vals, err)
 	}
 
-	hooks, manifestDoc, notesTxt, err := u.cfg.renderResources(chart, valuesToRender, "", "", u.SubNotes, false, false, u.PostRenderer, u.DryRun)
+	hooks, manifestDoc, notesTxt, err := u.cfg.renderResources(ctx, chart, valuesToRender, "", "", u.SubNotes, false, false, u.PostRenderer, u.DryRun)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -274,7 +297,7 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 	}
 	target, err := u.cfg.KubeClient.Build(bytes.NewBufferString(upgradedRelease.Manifest), !u.DisableOpenAPIValidation)
 	if err != nil {
-		return upgradedRelease, errors.Wrap(err, "unable to build kubernetes objects from new release manifest")
+		return upgradedRelease, buildError(errors.Wrap(err, "unable to build kubernetes objects from new release manifest"))
 	}
 
 	// It is safe to use force only on target because these are resources currently rendered by the chart.
@@ -310,7 +333,7 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 	})
 
 	if u.DryRun {
-		u.cfg.Log("dry run for %s", upgradedRelease.Name)
+		u.cfg.logger().Debug("dry run", "release", upgradedRelease.Name)
 		if len(u.Description) > 0 {
 			upgradedRelease.Info.Description = u.Description
 		} else {
@@ -319,7 +342,7 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 		return upgradedRelease, nil
 	}
 
-	u.cfg.Log("creating upgraded release for %s", upgradedRelease.Name)
+	u.cfg.logger().Debug("creating upgraded release", "release", upgradedRelease.Name)
 	if err := u.cfg.Releases.Create(upgradedRelease); err != nil {
 		return nil, err
 	}
@@ -327,7 +350,7 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 	ctxChan := make(chan resultMessage)
 	doneChan := make(chan interface{})
 	defer close(doneChan)
-	go u.releasingUpgrade(rChan, upgradedRelease, current, target, originalRelease)
+	go u.releasingUpgrade(ctx, rChan, upgradedRelease, current, target, originalRelease)
 	go u.handleContext(ctx, doneChan, ctxChan, upgradedRelease)
 	select {
 	case result := <-rChan:
@@ -340,10 +363,10 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 // Function used to lock the Mutex, this is important for the case when the atomic flag is set.
 // In that case the upgrade will finish before the rollback is finished so it is necessary to wait for the rollback to finish.
 // The rollback will be trigger by the function failRelease
-func (u *Upgrade) reportToPerformUpgrade(c chan<- resultMessage, rel *release.Release, created kube.ResourceList, err error) {
+func (u *Upgrade) reportToPerformUpgrade(ctx context.Context, c chan<- resultMessage, rel *release.Release, created kube.ResourceList, err error) {
 	u.Lock.Lock()
 	if err != nil {
-		rel, err = u.failRelease(rel, created, err)
+		rel, err = u.failRelease(ctx, rel, created, err)
 	}
 	c <- resultMessage{r: rel, e: err}
 	u.Lock.Unlock()
@@ -356,60 +379,52 @@ func (u *Upgrade) handleContext(ctx context.Context, done chan interface{}, c ch
 		err := ctx.Err()
 
 		// when the atomic flag is set the ongoing release finish first and doesn't give time for the rollback happens.
-		u.reportToPerformUpgrade(c, upgradedRelease, kube.ResourceList{}, err)
+		u.reportToPerformUpgrade(ctx, c, upgradedRelease, kube.ResourceList{}, err)
 	case <-done:
 		return
 	}
 }
-func (u *Upgrade) releasingUpgrade(c chan<- resultMessage, upgradedRelease *release.Release, current kube.ResourceList, target kube.ResourceList, originalRelease *release.Release) {
+func (u *Upgrade) releasingUpgrade(ctx context.Context, c chan<- resultMessage, upgradedRelease *release.Release, current kube.ResourceList, target kube.ResourceList, originalRelease *release.Release) {
 	// pre-upgrade hooks
 
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(upgradedRelease, release.HookPreUpgrade, u.Timeout); err != nil {
-			u.reportToPerformUpgrade(c, upgradedRelease, kube.ResourceList{}, fmt.Errorf("pre-upgrade hooks failed: %s", err))
+		if err := u.cfg.execHook(ctx, upgradedRelease, release.HookPreUpgrade, u.Timeout); err != nil {
+			u.reportToPerformUpgrade(ctx, c, upgradedRelease, kube.ResourceList{}, errors.Wrap(err, "pre-upgrade hooks failed"))
 			return
 		}
 	} else {
-		u.cfg.Log("upgrade hooks disabled for %s", upgradedRelease.Name)
+		u.cfg.logger().Debug("upgrade hooks disabled", "release", upgradedRelease.Name)
 	}
 
-	results, err := u.cfg.KubeClient.Update(current, target, u.Force)
+	results, err := u.cfg.kubeUpdate(ctx, current, target, u.Force)
 	if err != nil {
 		u.cfg.recordRelease(originalRelease)
-		u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
+		u.reportToPerformUpgrade(ctx, c, upgradedRelease, results.Created, err)
 		return
 	}
 
 	if u.Recreate {
 		// NOTE: Because this is not critical for a release to succeed, we just
-		// log if an error occurs and continue onward. If we ever introduce log
-		// levels, we should make these error level logs so users are notified
-		// that they'll need to go do the cleanup on their own
+		// log if an error occurs and continue onward. These are error level
+		// logs so users are notified that they'll need to go do the cleanup on
+		// their own
 		if err := recreate(u.cfg, results.Updated); err != nil {
-			u.cfg.Log(err.Error())
+			u.cfg.logger().Error("failed to recreate pods", "error", err)
 		}
 	}
 
 	if u.Wait {
-		if u.WaitForJobs {
-			if err := u.cfg.KubeClient.WaitWithJobs(target, u.Timeout); err != nil {
-				u.cfg.recordRelease(originalRelease)
-				u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
-				return
-			}
-		} else {
-			if err := u.cfg.KubeClient.Wait(target, u.Timeout); err != nil {
-				u.cfg.recordRelease(originalRelease)
-				u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
-				return
-			}
+		if err := u.cfg.kubeWait(ctx, target, u.Timeout, u.WaitForJobs); err != nil {
+			u.cfg.recordRelease(originalRelease)
+			u.reportToPerformUpgrade(ctx, c, upgradedRelease, results.Created, u.cfg.withEvents(err, target, upgradedRelease.Info.LastDeployed.Time))
+			return
 		}
 	}
 
 	// post-upgrade hooks
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(upgradedRelease, release.HookPostUpgrade, u.Timeout); err != nil {
-			u.reportToPerformUpgrade(c, upgradedRelease, results.Created, fmt.Errorf("post-upgrade hooks failed: %s", err))
+		if err := u.cfg.execHook(ctx, upgradedRelease, release.HookPostUpgrade, u.Timeout); err != nil {
+			u.reportToPerformUpgrade(ctx, c, upgradedRelease, results.Created, errors.Wrap(err, "post-upgrade hooks failed"))
 			return
 		}
 	}
@@ -423,18 +438,21 @@ func (u *Upgrade) releasingUpgrade(c chan<- resultMessage, upgradedRelease *rele
 	} else {
 		upgradedRelease.Info.Description = "Upgrade complete"
 	}
-	u.reportToPerformUpgrade(c, upgradedRelease, nil, nil)
+	u.reportToPerformUpgrade(ctx, c, upgradedRelease, nil, nil)
 }
 
-func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, err error) (*release.Release, error) {
+func (u *Upgrade) failRelease(ctx context.Context, rel *release.Release, created kube.ResourceList, err error) (*release.Release, error) {
 	msg := fmt.Sprintf("Upgrade %q failed: %s", rel.Name, err)
-	u.cfg.Log("warning: %s", msg)
+	u.cfg.logger().Warn("upgrade failed", "release", rel.Name, "error", err)
 
 	rel.Info.Status = release.StatusFailed
 	rel.Info.Description = msg
 	u.cfg.recordRelease(rel)
+	if !u.DisableHooks {
+		u.cfg.execFailureHook(ctx, rel, release.HookUpgradeFailed, u.Timeout)
+	}
 	if u.CleanupOnFail && len(created) > 0 {
-		u.cfg.Log("Cleanup on fail set, cleaning up %d resources", len(created))
+		u.cfg.logger().Info("cleanup on fail set, cleaning up resources", "count", len(created))
 		_, errs := u.cfg.KubeClient.Delete(created)
 		if errs != nil {
 			var errorList []string
@@ -443,10 +461,10 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 			}
 			return rel, errors.Wrapf(fmt.Errorf("unable to cleanup resources: %s", strings.Join(errorList, ", ")), "an error occurred while cleaning up resources. original upgrade error: %s", err)
 		}
-		u.cfg.Log("Resource cleanup complete")
+		u.cfg.logger().Info("resource cleanup complete")
 	}
 	if u.Atomic {
-		u.cfg.Log("Upgrade failed and atomic is set, rolling back to last successful release")
+		u.cfg.logger().Info("upgrade failed and atomic is set, rolling back to last successful release", "release", rel.Name)
 
 		// As a protection, get the last successful release before rollback.
 		// If there are no successful releases, bail out
@@ -476,9 +494,12 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 		rollin.Recreate = u.Recreate
 		rollin.Force = u.Force
 		rollin.Timeout = u.Timeout
-		if rollErr := rollin.Run(rel.Name); rollErr != nil {
+		if rollErr := rollin.RunWithContext(spanContext(ctx), rel.Name); rollErr != nil {
 			return rel, errors.Wrapf(rollErr, "an error occurred while rolling back the release. original upgrade error: %s", err)
 		}
+		if !u.DisableHooks {
+			u.cfg.execFailureHook(ctx, rel, release.HookPostAtomicRollback, u.Timeout)
+		}
 		return rel, errors.Wrapf(err, "release %s failed, and has been rolled back due to atomic being set", rel.Name)
 	}
 
@@ -496,13 +517,13 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 func (u *Upgrade) reuseValues(chart *chart.Chart, current *release.Release, newVals map[string]interface{}) (map[string]interface{}, error) {
 	if u.ResetValues {
 		// If ResetValues is set, we completely ignore current.Config.
-		u.cfg.Log("resetting values to the chart's original version")
+		u.cfg.logger().Debug("resetting values to the chart's original version")
 		return newVals, nil
 	}
 
 	// If the ReuseValues flag is set, we always copy the old values over the new config's values.
 	if u.ReuseValues {
-		u.cfg.Log("reusing the old release's values")
+		u.cfg.logger().Debug("reusing the old release's values")
 
 		// We have to regenerate the old coalesced values:
 		oldVals, err := chartutil.CoalesceValues(current.Chart, current.Config)
@@ -518,15 +539,17 @@ func (u *Upgrade) reuseValues(chart *chart.Chart, current *release.Release, newV
 	}
 
 	if len(newVals) == 0 && len(current.Config) > 0 {
-		u.cfg.Log("copying values from %s (v%d) to new release.", current.Name, current.Version)
+		u.cfg.logger().Debug("copying values to new release", "release", current.Name, "revision", current.Version)
 		newVals = current.Config
 	}
 	return newVals, nil
 }
 
 func validateManifest(c kube.Interface, manifest []byte, openAPIValidation bool) error {
-	_, err := c.Build(bytes.NewReader(manifest), openAPIValidation)
-	return err
+	if _, err := c.Build(bytes.NewReader(manifest), openAPIValidation); err != nil {
+		return buildError(err)
+	}
+	return nil
 }
 
 // recreate captures all the logic for recreating pods for both upgrade and
@@ -388,3 +388,34 @@ func TestUpgradeRelease_Interrupted_Atomic(t *testing.T) {
 	is.Equal(updatedRes.Info.Status, release.StatusDeployed)
 
 }
+
+func TestUpgradeRelease_FailureHooks(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "nuketown"
+	rel.Info.Status = release.StatusDeployed
+	upAction.cfg.Releases.Create(rel)
+
+	failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WatchUntilReadyError = fmt.Errorf("arming key removed")
+	upAction.Atomic = true
+
+	res, err := upAction.Run(rel.Name, buildChart(withFailureHook()), map[string]interface{}{})
+	req.Error(err)
+	is.Contains(err.Error(), "arming key removed")
+	is.Contains(err.Error(), "rolled back")
+
+	// The failure hooks run before the rollback, and their own failure is
+	// not the one reported.
+	alert := findHook(res, "alert")
+	req.NotNil(alert)
+	is.Equal(release.HookPhaseFailed, alert.LastRun.Phase)
+
+	// The hooks of a successful rollback run after it.
+	restored := findHook(res, "restored")
+	req.NotNil(restored)
+	is.Equal(release.HookPhaseFailed, restored.LastRun.Phase)
+}
@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/resource"
 
+	"helm.sh/helm/v3/pkg/errdefs"
 	"helm.sh/helm/v3/pkg/kube"
 )
 
@@ -56,7 +57,9 @@ func existingResourceConflict(resources kube.ResourceList, releaseName, releaseN
 
 		// Allow adoption of the resource if it is managed by Helm and is annotated with correct release name and namespace.
 		if err := checkOwnership(existing, releaseName, releaseNamespace); err != nil {
-			return fmt.Errorf("%s exists and cannot be imported into the current release: %s", resourceString(info), err)
+			return errdefs.Wrap(errdefs.TypeResourceConflict,
+				fmt.Errorf("%s exists and cannot be imported into the current release: %s", resourceString(info), err),
+				"resource", resourceString(info))
 		}
 
 		requireUpdate.Append(info)
@@ -0,0 +1,275 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package audit records who ran which Helm operation on a release and what it
+changed.
+
+Records are written to a Sink, such as a JSON-lines file, a Kubernetes Event
+or a webhook.
+*/
+package audit // import "helm.sh/helm/v3/pkg/audit"
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// Operation is a Helm operation recorded by the audit subsystem.
+type Operation string
+
+// The audited operations.
+const (
+	OperationInstall   Operation = "install"
+	OperationUpgrade   Operation = "upgrade"
+	OperationRollback  Operation = "rollback"
+	OperationUninstall Operation = "uninstall"
+	OperationTest      Operation = "test"
+)
+
+// Outcome is the result of an audited operation.
+type Outcome string
+
+// The outcomes of an operation.
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Record describes one Helm operation on a release.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Operation Operation `json:"operation"`
+	Release   string    `json:"release"`
+	Namespace string    `json:"namespace"`
+	// Revision is the revision of the release created or acted upon. It is
+	// 0 if the operation failed before a revision was known.
+	Revision int `json:"revision,omitempty"`
+	// User is the identity the operation was run as.
+	User Identity `json:"user"`
+	// Flags are the command-line flags the operation was run with.
+	Flags map[string]string `json:"flags,omitempty"`
+	// Chart is the chart the release was deployed from.
+	Chart *ChartInfo `json:"chart,omitempty"`
+	// ValuesDiff lists the user-supplied values changed by the operation.
+	ValuesDiff []ValueChange `json:"valuesDiff,omitempty"`
+	Outcome    Outcome       `json:"outcome"`
+	// Error is the error the operation failed with.
+	Error string `json:"error,omitempty"`
+}
+
+// Identity is the identity an operation was run as, as far as it can be
+// determined from the Kubernetes client credentials.
+type Identity struct {
+	// Username is the user name, empty if the credentials do not name the
+	// user, as is the case for bearer tokens.
+	Username string   `json:"username,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	// Impersonated is true if the operation impersonated Username.
+	Impersonated bool `json:"impersonated,omitempty"`
+	// AuthMethod is how the client authenticates: impersonation, basic,
+	// client-certificate, token, exec, auth-provider or unknown.
+	AuthMethod string `json:"authMethod"`
+}
+
+// ChartInfo identifies a chart.
+type ChartInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Digest is the SHA-256 digest of the chart content, see ChartDigest.
+	Digest string `json:"digest"`
+}
+
+// The kinds of value changes.
+const (
+	ValueAdded   = "added"
+	ValueRemoved = "removed"
+	ValueChanged = "changed"
+)
+
+// ValueChange is a changed value. Only the path of the value is recorded, as
+// values may hold secrets.
+type ValueChange struct {
+	// Path is the dotted path of the value, such as "image.tag".
+	Path string `json:"path"`
+	// Change is one of ValueAdded, ValueRemoved or ValueChanged.
+	Change string `json:"change"`
+}
+
+// Sink receives audit records.
+type Sink interface {
+	Write(rec *Record) error
+}
+
+// multiSink writes records to several sinks.
+type multiSink []Sink
+
+// MultiSink returns a Sink writing each record to all sinks. All sinks are
+// written to even if one of them fails; the first error is returned.
+func MultiSink(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Write(rec *Record) error {
+	var first error
+	for _, s := range m {
+		if err := s.Write(rec); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// DiffValues returns the values changed between old and new, ordered by path.
+// Maps are compared key by key; other values, including lists, are compared
+// as a whole.
+func DiffValues(old, new map[string]interface{}) []ValueChange {
+	var changes []ValueChange
+	diffValues("", old, new, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffValues(prefix string, old, new map[string]interface{}, changes *[]ValueChange) {
+	for k, nv := range new {
+		path := prefix + k
+		ov, ok := old[k]
+		if !ok {
+			*changes = append(*changes, ValueChange{Path: path, Change: ValueAdded})
+			continue
+		}
+		om, oIsMap := ov.(map[string]interface{})
+		nm, nIsMap := nv.(map[string]interface{})
+		if oIsMap && nIsMap {
+			diffValues(path+".", om, nm, changes)
+			continue
+		}
+		if !reflect.DeepEqual(ov, nv) {
+			*changes = append(*changes, ValueChange{Path: path, Change: ValueChanged})
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			*changes = append(*changes, ValueChange{Path: prefix + k, Change: ValueRemoved})
+		}
+	}
+}
+
+// ChartDigest returns the SHA-256 digest of the content of ch and its
+// dependencies. It does not depend on how the chart was packaged, so the same
+// chart loaded from a directory or an archive has the same digest.
+func ChartDigest(ch *chart.Chart) string {
+	h := sha256.New()
+	writeChart(h, ch)
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func writeChart(w io.Writer, ch *chart.Chart) {
+	// The encoding of a chart is deterministic: templates and files are
+	// lists and maps are encoded with sorted keys.
+	b, _ := json.Marshal(struct {
+		Metadata  *chart.Metadata
+		Lock      *chart.Lock
+		Templates []*chart.File
+		Values    map[string]interface{}
+		Schema    []byte
+		Files     []*chart.File
+	}{ch.Metadata, ch.Lock, ch.Templates, ch.Values, ch.Schema, ch.Files})
+	w.Write(b)
+	for _, dep := range ch.Dependencies() {
+		writeChart(w, dep)
+	}
+}
+
+// NewChartInfo returns the information identifying ch.
+func NewChartInfo(ch *chart.Chart) *ChartInfo {
+	if ch == nil || ch.Metadata == nil {
+		return nil
+	}
+	return &ChartInfo{
+		Name:    ch.Metadata.Name,
+		Version: ch.Metadata.Version,
+		Digest:  ChartDigest(ch),
+	}
+}
+
+// IdentityFromConfig determines the identity of the Kubernetes client
+// configured by config. The user of a client certificate is read from the
+// certificate's common name and its groups from the organizations.
+func IdentityFromConfig(config *rest.Config) Identity {
+	if config == nil {
+		return Identity{AuthMethod: "unknown"}
+	}
+	if config.Impersonate.UserName != "" {
+		return Identity{
+			Username:     config.Impersonate.UserName,
+			Groups:       config.Impersonate.Groups,
+			Impersonated: true,
+			AuthMethod:   "impersonation",
+		}
+	}
+	switch {
+	case config.Username != "":
+		return Identity{Username: config.Username, AuthMethod: "basic"}
+	case len(config.CertData) > 0 || config.CertFile != "":
+		id := Identity{AuthMethod: "client-certificate"}
+		data := config.CertData
+		if len(data) == 0 {
+			data, _ = ioutil.ReadFile(config.CertFile)
+		}
+		if block, _ := pem.Decode(data); block != nil {
+			if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+				id.Username = cert.Subject.CommonName
+				id.Groups = cert.Subject.Organization
+			}
+		}
+		return id
+	case config.BearerToken != "" || config.BearerTokenFile != "":
+		return Identity{AuthMethod: "token"}
+	case config.ExecProvider != nil:
+		return Identity{AuthMethod: "exec"}
+	case config.AuthProvider != nil:
+		return Identity{AuthMethod: "auth-provider"}
+	}
+	return Identity{AuthMethod: "unknown"}
+}
+
+// RedactFlags returns flags with the values of flags that may hold secrets
+// replaced. Values set on the command line are recorded by their path in the
+// values diff instead.
+func RedactFlags(flags map[string]string) map[string]string {
+	redacted := make(map[string]string, len(flags))
+	for name, value := range flags {
+		if strings.Contains(name, "password") || strings.Contains(name, "token") || strings.HasPrefix(name, "set") {
+			value = "REDACTED"
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
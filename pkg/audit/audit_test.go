@@ -0,0 +1,189 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestDiffValues(t *testing.T) {
+	old := map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+		"replicas": 1,
+		"ports":    []interface{}{80},
+		"debug":    true,
+	}
+	new := map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.1", "pullPolicy": "Always"},
+		"replicas": 1,
+		"ports":    []interface{}{80, 443},
+	}
+
+	expect := []ValueChange{
+		{Path: "debug", Change: ValueRemoved},
+		{Path: "image.pullPolicy", Change: ValueAdded},
+		{Path: "image.tag", Change: ValueChanged},
+		{Path: "ports", Change: ValueChanged},
+	}
+	if got := DiffValues(old, new); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+
+	if got := DiffValues(nil, map[string]interface{}{"a": 1}); !reflect.DeepEqual(got, []ValueChange{{Path: "a", Change: ValueAdded}}) {
+		t.Errorf("unexpected diff against no values: %v", got)
+	}
+	if got := DiffValues(old, old); len(got) != 0 {
+		t.Errorf("expected no changes, got %v", got)
+	}
+}
+
+func TestChartDigest(t *testing.T) {
+	newChart := func(tag string) *chart.Chart {
+		ch := &chart.Chart{
+			Metadata:  &chart.Metadata{Name: "hello", Version: "0.1.0"},
+			Templates: []*chart.File{{Name: "templates/cm.yaml", Data: []byte("kind: ConfigMap")}},
+			Values:    map[string]interface{}{"tag": tag},
+		}
+		ch.AddDependency(&chart.Chart{Metadata: &chart.Metadata{Name: "dep", Version: "1.0.0"}})
+		return ch
+	}
+
+	a, b := ChartDigest(newChart("1")), ChartDigest(newChart("1"))
+	if a != b {
+		t.Errorf("expected equal charts to have the same digest, got %s and %s", a, b)
+	}
+	if a == ChartDigest(newChart("2")) {
+		t.Error("expected different charts to have different digests")
+	}
+
+	changedDep := newChart("1")
+	changedDep.Dependencies()[0].Metadata.Version = "1.0.1"
+	if a == ChartDigest(changedDep) {
+		t.Error("expected a changed dependency to change the digest")
+	}
+
+	info := NewChartInfo(newChart("1"))
+	if info.Name != "hello" || info.Version != "0.1.0" || info.Digest != a {
+		t.Errorf("unexpected chart info %+v", info)
+	}
+	if NewChartInfo(nil) != nil {
+		t.Error("expected no chart info without a chart")
+	}
+}
+
+func TestIdentityFromConfig(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jane", Organization: []string{"admins"}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	tests := []struct {
+		name   string
+		config *rest.Config
+		expect Identity
+	}{
+		{"no config", nil, Identity{AuthMethod: "unknown"}},
+		{
+			"impersonation",
+			&rest.Config{BearerToken: "t", Impersonate: rest.ImpersonationConfig{UserName: "bob", Groups: []string{"dev"}}},
+			Identity{Username: "bob", Groups: []string{"dev"}, Impersonated: true, AuthMethod: "impersonation"},
+		},
+		{"basic", &rest.Config{Username: "alice", Password: "secret"}, Identity{Username: "alice", AuthMethod: "basic"}},
+		{
+			"client certificate",
+			&rest.Config{TLSClientConfig: rest.TLSClientConfig{CertData: certData}},
+			Identity{Username: "jane", Groups: []string{"admins"}, AuthMethod: "client-certificate"},
+		},
+		{"token", &rest.Config{BearerToken: "t"}, Identity{AuthMethod: "token"}},
+		{"anonymous", &rest.Config{}, Identity{AuthMethod: "unknown"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IdentityFromConfig(tt.config); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expected %+v, got %+v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestRedactFlags(t *testing.T) {
+	got := RedactFlags(map[string]string{
+		"namespace":  "prod",
+		"set":        "password=hunter2",
+		"set-string": "a=b",
+		"password":   "hunter2",
+		"kube-token": "abc",
+	})
+	expect := map[string]string{
+		"namespace":  "prod",
+		"set":        "REDACTED",
+		"set-string": "REDACTED",
+		"password":   "REDACTED",
+		"kube-token": "REDACTED",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+}
+
+type recordingSink struct {
+	records []*Record
+	err     error
+}
+
+func (s *recordingSink) Write(rec *Record) error {
+	s.records = append(s.records, rec)
+	return s.err
+}
+
+func TestMultiSink(t *testing.T) {
+	failing := &recordingSink{err: errors.New("unavailable")}
+	ok := &recordingSink{}
+
+	err := MultiSink(failing, ok).Write(&Record{Release: "hello"})
+	if err == nil || err.Error() != "unavailable" {
+		t.Errorf("expected the error of the failing sink, got %v", err)
+	}
+	if len(failing.records) != 1 || len(ok.records) != 1 {
+		t.Error("expected the record to be written to all sinks")
+	}
+}
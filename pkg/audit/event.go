@@ -0,0 +1,107 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RecordAnnotation is the annotation of the events created by EventSink that
+// holds the JSON encoded record.
+const RecordAnnotation = "helm.sh/audit-record"
+
+// EventSink creates a Kubernetes Event in the namespace of the release for
+// each record.
+type EventSink struct {
+	client kubernetes.Interface
+}
+
+// NewEventSink returns a sink creating events with client.
+func NewEventSink(client kubernetes.Interface) *EventSink {
+	return &EventSink{client: client}
+}
+
+// Write creates the event describing rec. The event refers to the release
+// and holds the full record in the RecordAnnotation annotation.
+func (s *EventSink) Write(rec *Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "audit: failed to encode record")
+	}
+
+	eventType := v1.EventTypeNormal
+	if rec.Outcome != OutcomeSuccess {
+		eventType = v1.EventTypeWarning
+	}
+	user := rec.User.Username
+	if user == "" {
+		user = "unknown user"
+	}
+	message := fmt.Sprintf("%s of release %s by %s: %s", rec.Operation, rec.Release, user, rec.Outcome)
+	if rec.Error != "" {
+		message += ": " + rec.Error
+	}
+
+	now := metav1.NewTime(rec.Time)
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rec.Release + "." + utilrand.String(10),
+			Namespace: rec.Namespace,
+			Labels: map[string]string{
+				"owner": "helm",
+				"name":  rec.Release,
+			},
+			Annotations: map[string]string{RecordAnnotation: string(b)},
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: "helm.sh/v3",
+			Kind:       "Release",
+			Name:       rec.Release,
+			Namespace:  rec.Namespace,
+		},
+		Reason:         eventReason(rec.Operation),
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: "helm"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := s.client.CoreV1().Events(rec.Namespace).Create(context.Background(), event, metav1.CreateOptions{}); err != nil {
+		return errors.Wrap(err, "audit: failed to create event")
+	}
+	return nil
+}
+
+// eventReason returns the reason of the events recording op, such as
+// "HelmInstall".
+func eventReason(op Operation) string {
+	s := string(op)
+	if s == "" {
+		return "Helm"
+	}
+	return "Helm" + strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,60 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FileSink appends records to a file, one JSON object per line.
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSink returns a sink appending records to the file at path. The file
+// is created if it does not exist.
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Write appends rec to the file.
+func (s *FileSink) Write(rec *Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "audit: failed to encode record")
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "audit: failed to open log file")
+	}
+	// A single write keeps lines from concurrent helm processes intact.
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return errors.Wrap(err, "audit: failed to write log file")
+	}
+	return f.Close()
+}
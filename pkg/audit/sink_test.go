@@ -0,0 +1,182 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testRecord(outcome Outcome) *Record {
+	return &Record{
+		Time:      time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC),
+		Operation: OperationUpgrade,
+		Release:   "hello",
+		Namespace: "prod",
+		Revision:  3,
+		User:      Identity{Username: "jane", AuthMethod: "client-certificate"},
+		Flags:     map[string]string{"wait": "true"},
+		Chart:     &ChartInfo{Name: "hello", Version: "0.1.0", Digest: "sha256:abc"},
+		ValuesDiff: []ValueChange{
+			{Path: "image.tag", Change: ValueChanged},
+		},
+		Outcome: outcome,
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink := NewFileSink(path)
+
+	first, second := testRecord(OutcomeSuccess), testRecord(OutcomeFailure)
+	second.Error = "timed out"
+	for _, rec := range []*Record{first, second} {
+		if err := sink.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []*Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("line %q is not a JSON record: %s", scanner.Text(), err)
+		}
+		got = append(got, &rec)
+	}
+	if !reflect.DeepEqual(got, []*Record{first, second}) {
+		t.Errorf("unexpected records %v", got)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected the log to be private, got mode %v", perm)
+	}
+
+	if err := NewFileSink(filepath.Join(path, "nested")).Write(first); err == nil {
+		t.Error("expected an error writing to an invalid path")
+	}
+}
+
+func TestEventSink(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	sink := NewEventSink(client)
+
+	failed := testRecord(OutcomeFailure)
+	failed.Error = "timed out"
+	for _, rec := range []*Record{testRecord(OutcomeSuccess), failed} {
+		if err := sink.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := client.CoreV1().Events("prod").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events.Items))
+	}
+
+	types := map[string]v1.Event{}
+	for _, ev := range events.Items {
+		types[ev.Type] = ev
+	}
+	ok, warn := types[v1.EventTypeNormal], types[v1.EventTypeWarning]
+	if ok.Reason != "HelmUpgrade" || ok.InvolvedObject.Name != "hello" || ok.InvolvedObject.Kind != "Release" {
+		t.Errorf("unexpected event %+v", ok)
+	}
+	if ok.Message != "upgrade of release hello by jane: success" {
+		t.Errorf("unexpected message %q", ok.Message)
+	}
+	if warn.Message != "upgrade of release hello by jane: failure: timed out" {
+		t.Errorf("unexpected message %q", warn.Message)
+	}
+
+	var rec Record
+	if err := json.Unmarshal([]byte(ok.Annotations[RecordAnnotation]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&rec, testRecord(OutcomeSuccess)) {
+		t.Errorf("unexpected record in annotation %+v", rec)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got []*Record
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected a POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("unexpected authorization %q", auth)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rec Record
+		if err := json.Unmarshal(b, &rec); err != nil {
+			t.Errorf("body %q is not a JSON record: %s", b, err)
+		}
+		got = append(got, &rec)
+		if rec.Outcome == OutcomeFailure {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL)
+	sink.Header.Set("Authorization", "Bearer secret")
+
+	if err := sink.Write(testRecord(OutcomeSuccess)); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0], testRecord(OutcomeSuccess)) {
+		t.Errorf("unexpected records %v", got)
+	}
+
+	if err := sink.Write(testRecord(OutcomeFailure)); err == nil {
+		t.Error("expected an error when the webhook fails")
+	}
+}
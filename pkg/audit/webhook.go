@@ -0,0 +1,76 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WebhookSink posts each record as JSON to a URL.
+type WebhookSink struct {
+	url string
+
+	// Client is the HTTP client used to post records.
+	Client *http.Client
+	// Header holds additional headers sent with each record, such as
+	// Authorization.
+	Header http.Header
+}
+
+// NewWebhookSink returns a sink posting records to url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		Header: http.Header{},
+	}
+}
+
+// Write posts rec to the webhook. Any response status other than 2xx is an
+// error.
+func (s *WebhookSink) Write(rec *Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "audit: failed to encode record")
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "audit: invalid webhook")
+	}
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "audit: failed to post record")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("audit: webhook responded with %s", resp.Status)
+	}
+	return nil
+}
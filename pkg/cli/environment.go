@@ -14,7 +14,8 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-/*Package cli describes the operating environment for the Helm CLI.
+/*
+Package cli describes the operating environment for the Helm CLI.
 
 Helm's environment encapsulates all of the service dependencies Helm has.
 These dependencies are expressed as interfaces so that alternate implementations
@@ -27,6 +28,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -58,8 +60,15 @@ type EnvSettings struct {
 	KubeCaFile string
 	// Debug indicates whether or not Helm is running in Debug mode.
 	Debug bool
+	// LogLevel is the minimum level of the diagnostic output: debug, info,
+	// warn or error. If empty, it is debug in Debug mode and error otherwise.
+	LogLevel string
+	// LogFormat is the format of the diagnostic output: text or json.
+	LogFormat string
 	// RegistryConfig is the path to the registry config file.
 	RegistryConfig string
+	// NotificationsConfig is the path to the release notifications config file.
+	NotificationsConfig string
 	// RepositoryConfig is the path to the repositories file.
 	RepositoryConfig string
 	// RepositoryCache is the path to the repository cache directory.
@@ -68,22 +77,35 @@ type EnvSettings struct {
 	PluginsDirectory string
 	// MaxHistory is the max release history maintained.
 	MaxHistory int
+	// MaxHistoryAge is how long release history is maintained after it was last deployed.
+	MaxHistoryAge time.Duration
+	// MinHistory is the min release history maintained when pruning by age.
+	MinHistory int
+	// HookConcurrency is the maximum number of hooks of the same weight run
+	// concurrently.
+	HookConcurrency int
 }
 
 func New() *EnvSettings {
 	env := &EnvSettings{
-		namespace:        os.Getenv("HELM_NAMESPACE"),
-		MaxHistory:       envIntOr("HELM_MAX_HISTORY", defaultMaxHistory),
-		KubeContext:      os.Getenv("HELM_KUBECONTEXT"),
-		KubeToken:        os.Getenv("HELM_KUBETOKEN"),
-		KubeAsUser:       os.Getenv("HELM_KUBEASUSER"),
-		KubeAsGroups:     envCSV("HELM_KUBEASGROUPS"),
-		KubeAPIServer:    os.Getenv("HELM_KUBEAPISERVER"),
-		KubeCaFile:       os.Getenv("HELM_KUBECAFILE"),
-		PluginsDirectory: envOr("HELM_PLUGINS", helmpath.DataPath("plugins")),
-		RegistryConfig:   envOr("HELM_REGISTRY_CONFIG", helmpath.ConfigPath("registry/config.json")),
-		RepositoryConfig: envOr("HELM_REPOSITORY_CONFIG", helmpath.ConfigPath("repositories.yaml")),
-		RepositoryCache:  envOr("HELM_REPOSITORY_CACHE", helmpath.CachePath("repository")),
+		namespace:           os.Getenv("HELM_NAMESPACE"),
+		MaxHistory:          envIntOr("HELM_MAX_HISTORY", defaultMaxHistory),
+		MaxHistoryAge:       envDurationOr("HELM_MAX_HISTORY_AGE", 0),
+		MinHistory:          envIntOr("HELM_MIN_HISTORY", 0),
+		HookConcurrency:     envIntOr("HELM_HOOK_CONCURRENCY", 1),
+		KubeContext:         os.Getenv("HELM_KUBECONTEXT"),
+		KubeToken:           os.Getenv("HELM_KUBETOKEN"),
+		KubeAsUser:          os.Getenv("HELM_KUBEASUSER"),
+		KubeAsGroups:        envCSV("HELM_KUBEASGROUPS"),
+		KubeAPIServer:       os.Getenv("HELM_KUBEAPISERVER"),
+		KubeCaFile:          os.Getenv("HELM_KUBECAFILE"),
+		LogLevel:            os.Getenv("HELM_LOG_LEVEL"),
+		LogFormat:           envOr("HELM_LOG_FORMAT", "text"),
+		PluginsDirectory:    envOr("HELM_PLUGINS", helmpath.DataPath("plugins")),
+		RegistryConfig:      envOr("HELM_REGISTRY_CONFIG", helmpath.ConfigPath("registry/config.json")),
+		NotificationsConfig: envOr("HELM_NOTIFICATIONS_CONFIG", helmpath.ConfigPath("notifications.yaml")),
+		RepositoryConfig:    envOr("HELM_REPOSITORY_CONFIG", helmpath.ConfigPath("repositories.yaml")),
+		RepositoryCache:     envOr("HELM_REPOSITORY_CACHE", helmpath.CachePath("repository")),
 	}
 	env.Debug, _ = strconv.ParseBool(os.Getenv("HELM_DEBUG"))
 
@@ -112,7 +134,11 @@ func (s *EnvSettings) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.KubeAPIServer, "kube-apiserver", s.KubeAPIServer, "the address and the port for the Kubernetes API server")
 	fs.StringVar(&s.KubeCaFile, "kube-ca-file", s.KubeCaFile, "the certificate authority file for the Kubernetes API server connection")
 	fs.BoolVar(&s.Debug, "debug", s.Debug, "enable verbose output")
+	fs.IntVar(&s.HookConcurrency, "hook-concurrency", s.HookConcurrency, "maximum number of hooks of the same weight run concurrently")
+	fs.StringVar(&s.LogLevel, "log-level", s.LogLevel, "minimum level of the log output: debug, info, warn or error (default debug with --debug, error otherwise)")
+	fs.StringVar(&s.LogFormat, "log-format", s.LogFormat, "format of the log output: text or json")
 	fs.StringVar(&s.RegistryConfig, "registry-config", s.RegistryConfig, "path to the registry config file")
+	fs.StringVar(&s.NotificationsConfig, "notifications-config", s.NotificationsConfig, "path to the release notifications config file")
 	fs.StringVar(&s.RepositoryConfig, "repository-config", s.RepositoryConfig, "path to the file containing repository names and URLs")
 	fs.StringVar(&s.RepositoryCache, "repository-cache", s.RepositoryCache, "path to the file containing cached repository indexes")
 }
@@ -136,6 +162,18 @@ func envIntOr(name string, def int) int {
 	return ret
 }
 
+func envDurationOr(name string, def time.Duration) time.Duration {
+	envVal, ok := os.LookupEnv(name)
+	if !ok {
+		return def
+	}
+	ret, err := time.ParseDuration(envVal)
+	if err != nil {
+		return def
+	}
+	return ret
+}
+
 func envCSV(name string) (ls []string) {
 	trimmed := strings.Trim(os.Getenv(name), ", ")
 	if trimmed != "" {
@@ -146,17 +184,23 @@ func envCSV(name string) (ls []string) {
 
 func (s *EnvSettings) EnvVars() map[string]string {
 	envvars := map[string]string{
-		"HELM_BIN":               os.Args[0],
-		"HELM_CACHE_HOME":        helmpath.CachePath(""),
-		"HELM_CONFIG_HOME":       helmpath.ConfigPath(""),
-		"HELM_DATA_HOME":         helmpath.DataPath(""),
-		"HELM_DEBUG":             fmt.Sprint(s.Debug),
-		"HELM_PLUGINS":           s.PluginsDirectory,
-		"HELM_REGISTRY_CONFIG":   s.RegistryConfig,
-		"HELM_REPOSITORY_CACHE":  s.RepositoryCache,
-		"HELM_REPOSITORY_CONFIG": s.RepositoryConfig,
-		"HELM_NAMESPACE":         s.Namespace(),
-		"HELM_MAX_HISTORY":       strconv.Itoa(s.MaxHistory),
+		"HELM_BIN":                  os.Args[0],
+		"HELM_CACHE_HOME":           helmpath.CachePath(""),
+		"HELM_CONFIG_HOME":          helmpath.ConfigPath(""),
+		"HELM_DATA_HOME":            helmpath.DataPath(""),
+		"HELM_DEBUG":                fmt.Sprint(s.Debug),
+		"HELM_HOOK_CONCURRENCY":     strconv.Itoa(s.HookConcurrency),
+		"HELM_LOG_FORMAT":           s.LogFormat,
+		"HELM_LOG_LEVEL":            s.LogLevel,
+		"HELM_PLUGINS":              s.PluginsDirectory,
+		"HELM_REGISTRY_CONFIG":      s.RegistryConfig,
+		"HELM_REPOSITORY_CACHE":     s.RepositoryCache,
+		"HELM_REPOSITORY_CONFIG":    s.RepositoryConfig,
+		"HELM_NAMESPACE":            s.Namespace(),
+		"HELM_NOTIFICATIONS_CONFIG": s.NotificationsConfig,
+		"HELM_MAX_HISTORY":          strconv.Itoa(s.MaxHistory),
+		"HELM_MAX_HISTORY_AGE":      s.MaxHistoryAge.String(),
+		"HELM_MIN_HISTORY":          strconv.Itoa(s.MinHistory),
 
 		// broken, these are populated from helm flags and not kubeconfig.
 		"HELM_KUBECONTEXT":   s.KubeContext,
@@ -132,6 +132,57 @@ func TestEnvSettings(t *testing.T) {
 	}
 }
 
+func TestEnvSettingsLogging(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		envvars map[string]string
+		level   string
+		format  string
+	}{
+		{
+			name:   "defaults",
+			format: "text",
+		},
+		{
+			name:    "with envvars set",
+			envvars: map[string]string{"HELM_LOG_LEVEL": "info", "HELM_LOG_FORMAT": "json"},
+			level:   "info",
+			format:  "json",
+		},
+		{
+			name:    "with flags and envvars set",
+			args:    "--log-level=error --log-format=text",
+			envvars: map[string]string{"HELM_LOG_LEVEL": "info", "HELM_LOG_FORMAT": "json"},
+			level:   "error",
+			format:  "text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer resetEnv()()
+
+			for k, v := range tt.envvars {
+				os.Setenv(k, v)
+			}
+
+			flags := pflag.NewFlagSet("testing", pflag.ContinueOnError)
+
+			settings := New()
+			settings.AddFlags(flags)
+			flags.Parse(strings.Split(tt.args, " "))
+
+			if settings.LogLevel != tt.level {
+				t.Errorf("expected log level %q, got %q", tt.level, settings.LogLevel)
+			}
+			if settings.LogFormat != tt.format {
+				t.Errorf("expected log format %q, got %q", tt.format, settings.LogFormat)
+			}
+		})
+	}
+}
+
 func resetEnv() func() {
 	origEnv := os.Environ()
 
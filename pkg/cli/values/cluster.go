@@ -0,0 +1,59 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ClusterReader reads the Secrets and ConfigMaps of the cluster that values
+// files refer to, like secret://namespace/name/key or
+// configmap://namespace/name/key.
+type ClusterReader interface {
+	// SecretData returns the value of a key of a Secret.
+	SecretData(namespace, name, key string) ([]byte, error)
+	// ConfigMapData returns the value of a key of a ConfigMap.
+	ConfigMapData(namespace, name, key string) ([]byte, error)
+}
+
+// isClusterRef returns whether a values file refers to a Secret or a
+// ConfigMap.
+func isClusterRef(filePath string) bool {
+	return strings.HasPrefix(filePath, "secret://") || strings.HasPrefix(filePath, "configmap://")
+}
+
+// readClusterFile reads a values file from the key of a Secret or ConfigMap.
+func (opts *Options) readClusterFile(filePath string) ([]byte, error) {
+	u, err := url.Parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	if u.Host == "" || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.Errorf("invalid values file %s, expected %s://namespace/name/key", filePath, u.Scheme)
+	}
+	if opts.Cluster == nil {
+		return nil, errors.Errorf("unable to read values file %s without a connection to a cluster", filePath)
+	}
+	if u.Scheme == "secret" {
+		return opts.Cluster.SecretData(u.Host, parts[0], parts[1])
+	}
+	return opts.Cluster.ConfigMapData(u.Host, parts[0], parts[1])
+}
@@ -25,38 +25,100 @@ import (
 	"github.com/pkg/errors"
 	"sigs.k8s.io/yaml"
 
+	"helm.sh/helm/v3/pkg/encryption"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/provenance"
 	"helm.sh/helm/v3/pkg/strvals"
 )
 
 type Options struct {
-	ValueFiles   []string
-	StringValues []string
-	Values       []string
-	FileValues   []string
+	ValueFiles    []string
+	StringValues  []string
+	Values        []string
+	FileValues    []string
+	JSONValues    []string
+	LiteralValues []string
+
+	// Cluster reads the Secrets and ConfigMaps values files refer to, like
+	// secret://namespace/name/key. Such values files cannot be read if it is
+	// nil.
+	Cluster ClusterReader
+
+	// AgeIdentityFiles are files of age identities, and PGPKeyring is an
+	// OpenPGP keyring, encrypted values files are decrypted with.
+	AgeIdentityFiles []string
+	PGPKeyring       string
+	// PGPPassphrase fetches the passphrases of the private keys of
+	// PGPKeyring. Keys protected by a passphrase cannot be used if it is nil.
+	PGPPassphrase provenance.PassphraseFetcher
+
+	// secrets are the values read from the cluster or decrypted.
+	secrets []secretValue
+	// keys are the keys encrypted values files are decrypted with.
+	keys *encryption.Keys
 }
 
 // MergeValues merges values from files specified via -f/--values and directly
-// via --set, --set-string, or --set-file, marshaling them to YAML
+// via --set-json, --set, --set-string, --set-file or --set-literal, marshaling
+// them to YAML
+//
+// Values files may be keys of Secrets and ConfigMaps of the cluster, like
+// secret://namespace/name/key or configmap://namespace/name/key. The values
+// read from them are redacted by Redact.
+//
+// Values files may be encrypted as a whole or by fields, see the encryption
+// package. They are decrypted in memory with the age identities of
+// AgeIdentityFiles or the keys of PGPKeyring, and the decrypted values are
+// redacted by Redact too.
 func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, error) {
 	base := map[string]interface{}{}
+	opts.secrets = nil
 
 	// User specified a values files via -f/--values
 	for _, filePath := range opts.ValueFiles {
 		currentMap := map[string]interface{}{}
 
-		bytes, err := readFile(filePath, p)
+		var bytes []byte
+		var err error
+		if isClusterRef(filePath) {
+			bytes, err = opts.readClusterFile(filePath)
+		} else {
+			bytes, err = readFile(filePath, p)
+		}
 		if err != nil {
 			return nil, err
 		}
 
+		// The values of a local file encrypted by fields that were not
+		// encrypted are public.
+		var public map[string]interface{}
+		encrypted := encryption.IsEncrypted(bytes)
+		if encrypted {
+			if !isClusterRef(filePath) {
+				_ = yaml.Unmarshal(bytes, &public)
+			}
+			if bytes, err = opts.decrypt(bytes); err != nil {
+				return nil, errors.Wrapf(err, "failed to decrypt %s", filePath)
+			}
+		}
+
 		if err := yaml.Unmarshal(bytes, &currentMap); err != nil {
 			return nil, errors.Wrapf(err, "failed to parse %s", filePath)
 		}
+		if isClusterRef(filePath) || encrypted {
+			opts.secrets = append(opts.secrets, secretValues(currentMap, public, nil)...)
+		}
 		// Merge with the previous map
 		base = mergeMaps(base, currentMap)
 	}
 
+	// User specified a value via --set-json
+	for _, value := range opts.JSONValues {
+		if err := strvals.ParseJSON(value, base); err != nil {
+			return nil, errors.Wrapf(err, "failed parsing --set-json data %s", value)
+		}
+	}
+
 	// User specified a value via --set
 	for _, value := range opts.Values {
 		if err := strvals.ParseInto(value, base); err != nil {
@@ -85,9 +147,44 @@ func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, er
 		}
 	}
 
+	// User specified a value via --set-literal
+	for _, value := range opts.LiteralValues {
+		if err := strvals.ParseLiteralInto(value, base); err != nil {
+			return nil, errors.Wrap(err, "failed parsing --set-literal data")
+		}
+	}
+
 	return base, nil
 }
 
+// decrypt decrypts a values file, loading the keys the first time.
+func (opts *Options) decrypt(data []byte) ([]byte, error) {
+	if opts.keys == nil {
+		keys := &encryption.Keys{}
+		for _, f := range opts.AgeIdentityFiles {
+			ids, err := encryption.ReadAgeIdentities(f)
+			if err != nil {
+				return nil, err
+			}
+			keys.AgeIdentities = append(keys.AgeIdentities, ids...)
+		}
+		if opts.PGPKeyring != "" {
+			s, err := provenance.NewFromKeyring(opts.PGPKeyring, "")
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to load keyring")
+			}
+			if opts.PGPPassphrase != nil {
+				if err := encryption.DecryptPGPKeys(s.KeyRing, opts.PGPPassphrase); err != nil {
+					return nil, err
+				}
+			}
+			keys.PGPKeyRing = s.KeyRing
+		}
+		opts.keys = keys
+	}
+	return encryption.Decrypt(data, opts.keys)
+}
+
 func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
 	out := make(map[string]interface{}, len(a))
 	for k, v := range a {
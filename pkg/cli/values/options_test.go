@@ -17,8 +17,18 @@ limitations under the License.
 package values
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+
+	"filippo.io/age"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/encryption"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/provenance"
 )
 
 func TestMergeValues(t *testing.T) {
@@ -75,3 +85,206 @@ func TestMergeValues(t *testing.T) {
 		t.Errorf("Expected a map with different keys to merge properly with another map. Expected: %v, got %v", expectedMap, testMap)
 	}
 }
+
+func TestMergeValuesOrder(t *testing.T) {
+	opts := &Options{
+		JSONValues:    []string{`image={"repository":"nginx","tag":"1.0"},ports=[80,443]`},
+		Values:        []string{"image.tag=1.1"},
+		StringValues:  []string{"replicas=3"},
+		LiteralValues: []string{`password=p@ss,w{0}rd\1`, "image.pullPolicy=null"},
+	}
+	vals, err := opts.MergeValues(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "1.1",
+			"pullPolicy": "null",
+		},
+		"ports":    []interface{}{float64(80), float64(443)},
+		"replicas": "3",
+		"password": `p@ss,w{0}rd\1`,
+	}
+	if !reflect.DeepEqual(expect, vals) {
+		t.Errorf("expected values %v, got %v", expect, vals)
+	}
+
+	opts = &Options{JSONValues: []string{"image={"}}
+	if _, err := opts.MergeValues(getter.Providers{}); err == nil {
+		t.Error("expected an error parsing invalid JSON")
+	}
+}
+
+type fakeCluster map[string]string
+
+func (c fakeCluster) SecretData(namespace, name, key string) ([]byte, error) {
+	return c.data("secret", namespace, name, key)
+}
+
+func (c fakeCluster) ConfigMapData(namespace, name, key string) ([]byte, error) {
+	return c.data("configmap", namespace, name, key)
+}
+
+func (c fakeCluster) data(kind, namespace, name, key string) ([]byte, error) {
+	data, ok := c[kind+"/"+namespace+"/"+name+"/"+key]
+	if !ok {
+		return nil, errors.Errorf("%s %s/%s not found", kind, namespace, name)
+	}
+	return []byte(data), nil
+}
+
+func TestMergeValuesFromCluster(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "values.yaml")
+	if err := ioutil.WriteFile(file, []byte("replicas: 1\ndb:\n  host: localhost\n  password: changeme\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{
+		ValueFiles: []string{file, "configmap://prod/web/values.yaml", "secret://prod/web/values.yaml"},
+		Values:     []string{"replicas=5"},
+		Cluster: fakeCluster{
+			"configmap/prod/web/values.yaml": "replicas: 3\ndb:\n  host: db.prod\n",
+			"secret/prod/web/values.yaml":    "db:\n  password: s3cr3t\n  tls: {cert: abc, key: def}\n",
+		},
+	}
+	vals, err := opts.MergeValues(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"replicas": int64(5),
+		"db": map[string]interface{}{
+			"host":     "db.prod",
+			"password": "s3cr3t",
+			"tls":      map[string]interface{}{"cert": "abc", "key": "def"},
+		},
+	}
+	if !reflect.DeepEqual(expect, vals) {
+		t.Errorf("expected values %v, got %v", expect, vals)
+	}
+
+	// replicas is set after it is read from the configmap.
+	redacted := map[string]interface{}{
+		"replicas": int64(5),
+		"db": map[string]interface{}{
+			"host":     Redacted,
+			"password": Redacted,
+			"tls":      map[string]interface{}{"cert": Redacted, "key": Redacted},
+		},
+	}
+	if r := opts.Redact(vals); !reflect.DeepEqual(redacted, r) {
+		t.Errorf("expected redacted values %v, got %v", redacted, r)
+	}
+	if !reflect.DeepEqual(expect, vals) {
+		t.Errorf("expected the values not to be changed by Redact, got %v", vals)
+	}
+
+	for _, tt := range []struct {
+		file string
+		err  string
+	}{
+		{"secret://prod/web", "invalid values file secret://prod/web, expected secret://namespace/name/key"},
+		{"configmap:///web/values.yaml", "invalid values file configmap:///web/values.yaml, expected configmap://namespace/name/key"},
+		{"secret://dev/web/values.yaml", "secret dev/web not found"},
+	} {
+		opts := &Options{ValueFiles: []string{tt.file}, Cluster: fakeCluster{}}
+		if _, err := opts.MergeValues(getter.Providers{}); err == nil || err.Error() != tt.err {
+			t.Errorf("%s: expected error %q, got %v", tt.file, tt.err, err)
+		}
+	}
+
+	opts = &Options{ValueFiles: []string{"secret://prod/web/values.yaml"}}
+	if _, err := opts.MergeValues(getter.Providers{}); err == nil {
+		t.Error("expected an error reading a secret without a cluster")
+	}
+}
+
+func TestMergeValuesEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile := filepath.Join(dir, "key.txt")
+	if err := ioutil.WriteFile(identityFile, []byte(id.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := provenance.NewFromKeyring("../../provenance/testdata/helm-test-key.secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err := encryption.EncryptFields([]byte("replicas: 1\ndb:\n  host: localhost\n  password: s3cr3t\n"), []string{"db.password"}, &encryption.Keys{AgeRecipients: []age.Recipient{id.Recipient()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	whole, err := encryption.Encrypt([]byte("db:\n  user: admin\n"), &encryption.Keys{PGPRecipients: signer.KeyRing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fieldsFile, wholeFile := filepath.Join(dir, "fields.yaml"), filepath.Join(dir, "whole.yaml")
+	for file, data := range map[string][]byte{fieldsFile: fields, wholeFile: whole} {
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &Options{
+		ValueFiles:       []string{fieldsFile, wholeFile},
+		AgeIdentityFiles: []string{identityFile},
+		PGPKeyring:       "../../provenance/testdata/helm-test-key.secret",
+	}
+	vals, err := opts.MergeValues(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"replicas": float64(1),
+		"db":       map[string]interface{}{"host": "localhost", "password": "s3cr3t", "user": "admin"},
+	}
+	if !reflect.DeepEqual(expect, vals) {
+		t.Errorf("expected values %v, got %v", expect, vals)
+	}
+	redacted := map[string]interface{}{
+		"replicas": float64(1),
+		"db":       map[string]interface{}{"host": "localhost", "password": Redacted, "user": Redacted},
+	}
+	if r := opts.Redact(vals); !reflect.DeepEqual(redacted, r) {
+		t.Errorf("expected redacted values %v, got %v", redacted, r)
+	}
+
+	opts = &Options{ValueFiles: []string{fieldsFile}}
+	if _, err := opts.MergeValues(getter.Providers{}); err == nil || !strings.HasPrefix(err.Error(), "failed to decrypt "+fieldsFile) {
+		t.Errorf("expected an error decrypting without keys, got %v", err)
+	}
+
+	// The secret keys of the keyring are protected by a passphrase.
+	const passwordKeyring = "../../provenance/testdata/helm-password-key.secret"
+	protected, err := provenance.NewFromKeyring(passwordKeyring, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if whole, err = encryption.Encrypt([]byte("db:\n  user: admin\n"), &encryption.Keys{PGPRecipients: protected.KeyRing}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(wholeFile, whole, 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts = &Options{ValueFiles: []string{wholeFile}, PGPKeyring: passwordKeyring}
+	if _, err := opts.MergeValues(getter.Providers{}); err == nil {
+		t.Error("expected an error decrypting without the passphrase of the keyring")
+	}
+	opts = &Options{
+		ValueFiles:    []string{wholeFile},
+		PGPKeyring:    passwordKeyring,
+		PGPPassphrase: func(string) ([]byte, error) { return []byte("secret"), nil },
+	}
+	if vals, err = opts.MergeValues(getter.Providers{}); err != nil {
+		t.Fatal(err)
+	}
+	if expect := map[string]interface{}{"db": map[string]interface{}{"user": "admin"}}; !reflect.DeepEqual(expect, vals) {
+		t.Errorf("expected values %v, got %v", expect, vals)
+	}
+}
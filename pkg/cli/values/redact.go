@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"reflect"
+)
+
+// Redacted replaces secret values when they are printed.
+const Redacted = "<redacted>"
+
+// secretValue is a value read from a Secret or a ConfigMap, or decrypted.
+type secretValue struct {
+	path  []string
+	value interface{}
+}
+
+// Redact returns a copy of values merged by MergeValues, in which the values
+// read from Secrets and ConfigMaps or decrypted, and not overridden after, are
+// replaced by Redacted, so that they can be printed.
+func (opts *Options) Redact(vals map[string]interface{}) map[string]interface{} {
+	for _, sv := range opts.secrets {
+		vals = redact(vals, sv)
+	}
+	return vals
+}
+
+// redact returns a copy of vals with the secret value replaced by Redacted, if
+// it is still there. Only the maps on its path are copied.
+func redact(vals map[string]interface{}, sv secretValue) map[string]interface{} {
+	v, ok := vals[sv.path[0]]
+	if !ok {
+		return vals
+	}
+	if len(sv.path) > 1 {
+		m, isMap := v.(map[string]interface{})
+		if !isMap {
+			return vals
+		}
+		v = redact(m, secretValue{path: sv.path[1:], value: sv.value})
+	} else if reflect.DeepEqual(v, sv.value) {
+		v = Redacted
+	} else {
+		return vals
+	}
+	out := make(map[string]interface{}, len(vals))
+	for k, v := range vals {
+		out[k] = v
+	}
+	out[sv.path[0]] = v
+	return out
+}
+
+// secretValues returns the values of vals that are not maps, with their paths,
+// leaving out those that are the same in public. Values read from the cluster
+// have no public values, while the public values of a decrypted file are the
+// ones that were not encrypted.
+func secretValues(vals, public map[string]interface{}, prefix []string) []secretValue {
+	var values []secretValue
+	for k, v := range vals {
+		path := append(append([]string{}, prefix...), k)
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			p, _ := public[k].(map[string]interface{})
+			values = append(values, secretValues(m, p, path)...)
+			continue
+		}
+		if p, ok := public[k]; ok && reflect.DeepEqual(p, v) {
+			continue
+		}
+		values = append(values, secretValue{path: path, value: v})
+	}
+	return values
+}
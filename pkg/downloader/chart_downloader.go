@@ -16,6 +16,7 @@ limitations under the License.
 package downloader
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/url"
@@ -25,6 +26,9 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"helm.sh/helm/v3/internal/fileutil"
 	"helm.sh/helm/v3/internal/urlutil"
@@ -33,8 +37,12 @@ import (
 	"helm.sh/helm/v3/pkg/provenance"
 	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
+// tracer records the spans of chart downloads.
+var tracer = otel.Tracer("helm.sh/helm/v3/pkg/downloader")
+
 // VerificationStrategy describes a strategy for determining whether to verify a chart.
 type VerificationStrategy int
 
@@ -87,17 +95,37 @@ type ChartDownloader struct {
 // Returns a string path to the location where the file was downloaded and a verification
 // (if provenance was verified), or an error if something bad happened.
 func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *provenance.Verification, error) {
+	return c.DownloadToContext(context.Background(), ref, version, dest)
+}
+
+// DownloadToContext is DownloadTo recording tracing spans for resolving,
+// fetching and verifying the chart as children of the span in ctx.
+func (c *ChartDownloader) DownloadToContext(ctx context.Context, ref, version, dest string) (_ string, _ *provenance.Verification, err error) {
+	ctx, span := tracer.Start(ctx, "downloader.DownloadTo", trace.WithAttributes(
+		attribute.String("chart", ref),
+		attribute.String("version", version),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	_, resolveSpan := tracer.Start(ctx, "downloader.ResolveChartVersion")
 	u, err := c.ResolveChartVersion(ref, version)
+	tracing.EndSpan(resolveSpan, err)
 	if err != nil {
 		return "", nil, err
 	}
+	span.SetAttributes(attribute.String("url", u.Redacted()))
 
 	g, err := c.Getters.ByScheme(u.Scheme)
 	if err != nil {
 		return "", nil, err
 	}
 
+	_, fetchSpan := tracer.Start(ctx, "downloader.Fetch")
 	data, err := g.Get(u.String(), c.Options...)
+	if err == nil {
+		fetchSpan.SetAttributes(attribute.Int("bytes", data.Len()))
+	}
+	tracing.EndSpan(fetchSpan, err)
 	if err != nil {
 		return "", nil, err
 	}
@@ -130,7 +158,9 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 		}
 
 		if c.Verify != VerifyLater {
+			_, verifySpan := tracer.Start(ctx, "downloader.Verify")
 			ver, err = VerifyChart(destfile, c.Keyring)
+			tracing.EndSpan(verifySpan, err)
 			if err != nil {
 				// Fail always in this case, since it means the verification step
 				// failed.
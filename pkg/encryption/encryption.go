@@ -0,0 +1,289 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package encryption encrypts and decrypts values files, with age keys or
+OpenPGP keys.
+
+A values file is either encrypted as a whole, in which case it is an armored
+age file or OpenPGP message:
+
+	-----BEGIN AGE ENCRYPTED FILE-----
+	...
+	-----END AGE ENCRYPTED FILE-----
+
+or some of its fields are, in which case their values are replaced by their
+encrypted JSON encoding:
+
+	image: nginx
+	password: ENC[age:YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSB...]
+
+Decrypting a file encrypted by fields only replaces the encrypted values, so
+the rest of the file keeps its values and comments.
+*/
+package encryption // import "helm.sh/helm/v3/pkg/encryption"
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"filippo.io/age"
+	agearmor "filippo.io/age/armor"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"                //nolint
+	pgparmor "golang.org/x/crypto/openpgp/armor" //nolint
+
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+const (
+	schemeAge = "age"
+	schemePGP = "pgp"
+
+	pgpMessageType = "PGP MESSAGE"
+	pgpHeader      = "-----BEGIN " + pgpMessageType + "-----"
+)
+
+// Keys are the keys values are encrypted and decrypted with.
+//
+// Values are encrypted either with age or with OpenPGP, so only one kind of
+// recipients may be set when encrypting.
+type Keys struct {
+	// AgeRecipients are the age public keys values are encrypted for.
+	AgeRecipients []age.Recipient
+	// AgeIdentities are the age private keys values are decrypted with.
+	AgeIdentities []age.Identity
+	// PGPRecipients are the OpenPGP entities values are encrypted for.
+	PGPRecipients openpgp.EntityList
+	// PGPKeyRing holds the OpenPGP private keys values are decrypted with.
+	// Their private keys must be decrypted already, see DecryptPGPKeys.
+	PGPKeyRing openpgp.EntityList
+}
+
+// ReadAgeIdentities reads the age identities of a file, like one generated by
+// age-keygen.
+func ReadAgeIdentities(filename string) ([]age.Identity, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read age identities from %s", filename)
+	}
+	return ids, nil
+}
+
+// DecryptPGPKeys decrypts the private keys of a keyring that are protected by
+// a passphrase, asking fn for the passphrase of each entity. The subkeys of an
+// entity, which usually are the ones values are encrypted for, are decrypted
+// with the passphrase of its primary key.
+func DecryptPGPKeys(ring openpgp.EntityList, fn provenance.PassphraseFetcher) error {
+	for _, e := range ring {
+		if e.PrivateKey == nil {
+			continue
+		}
+		var passphrase []byte
+		fetch := func(name string) ([]byte, error) {
+			if passphrase != nil {
+				return passphrase, nil
+			}
+			p, err := fn(name)
+			passphrase = p
+			return p, err
+		}
+		s := &provenance.Signatory{KeyRing: ring, Entity: e}
+		if err := s.DecryptKey(fetch); err != nil {
+			return errors.Wrap(err, "failed to decrypt OpenPGP key")
+		}
+		for _, sub := range e.Subkeys {
+			if sub.PrivateKey == nil || !sub.PrivateKey.Encrypted {
+				continue
+			}
+			p, err := fetch(keyName(e))
+			if err != nil {
+				return errors.Wrap(err, "failed to decrypt OpenPGP key")
+			}
+			if err := sub.PrivateKey.Decrypt(p); err != nil {
+				return errors.Wrap(err, "failed to decrypt OpenPGP key")
+			}
+		}
+	}
+	return nil
+}
+
+// keyName returns the name of an entity, like provenance.Signatory.DecryptKey
+// does.
+func keyName(e *openpgp.Entity) string {
+	for name := range e.Identities {
+		if name != "" {
+			return name
+		}
+	}
+	return "Unknown"
+}
+
+// ParseAgeRecipients parses age public keys, like age1ql3z7hjy54pw3h....
+func ParseAgeRecipients(keys []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, k := range keys {
+		r, err := age.ParseX25519Recipient(k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid age recipient %q", k)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// IsEncrypted returns whether values are encrypted, as a whole or by fields.
+func IsEncrypted(data []byte) bool {
+	return armoredScheme(data) != "" || encryptedDocument(data) != nil
+}
+
+// Encrypt encrypts values as a whole, and armors them.
+func Encrypt(data []byte, keys *Keys) ([]byte, error) {
+	scheme, err := keys.scheme()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := keys.seal(scheme, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	if scheme == schemeAge {
+		w = agearmor.NewWriter(&buf)
+	} else if w, err = pgparmor.Encode(&buf, pgpMessageType, nil); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(ciphertext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts values encrypted as a whole or by fields. Values that are
+// not encrypted are returned as they are.
+func Decrypt(data []byte, keys *Keys) ([]byte, error) {
+	scheme := armoredScheme(data)
+	if scheme == "" {
+		return decryptFields(data, keys)
+	}
+
+	var r io.Reader = bytes.NewReader(bytes.TrimSpace(data))
+	if scheme == schemeAge {
+		r = agearmor.NewReader(r)
+	} else {
+		block, err := pgparmor.Decode(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid OpenPGP message")
+		}
+		r = block.Body
+	}
+	ciphertext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid armor")
+	}
+	return keys.open(scheme, ciphertext)
+}
+
+// armoredScheme returns the scheme of values encrypted as a whole, or the
+// empty string if they are not.
+func armoredScheme(data []byte) string {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte(agearmor.Header)):
+		return schemeAge
+	case bytes.HasPrefix(data, []byte(pgpHeader)):
+		return schemePGP
+	}
+	return ""
+}
+
+// scheme returns the scheme the keys encrypt with.
+func (k *Keys) scheme() (string, error) {
+	switch {
+	case len(k.AgeRecipients) > 0 && len(k.PGPRecipients) > 0:
+		return "", errors.New("cannot encrypt with both age and OpenPGP keys")
+	case len(k.AgeRecipients) > 0:
+		return schemeAge, nil
+	case len(k.PGPRecipients) > 0:
+		return schemePGP, nil
+	}
+	return "", errors.New("no age recipient or OpenPGP key to encrypt with")
+}
+
+// seal encrypts a plaintext for the recipients of a scheme.
+func (k *Keys) seal(scheme string, plaintext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	if scheme == schemeAge {
+		w, err = age.Encrypt(&buf, k.AgeRecipients...)
+	} else {
+		w, err = openpgp.Encrypt(&buf, k.PGPRecipients, nil, nil, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encrypt")
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// open decrypts a ciphertext with the private keys of a scheme.
+func (k *Keys) open(scheme string, ciphertext []byte) ([]byte, error) {
+	var r io.Reader
+	if scheme == schemeAge {
+		if len(k.AgeIdentities) == 0 {
+			return nil, errors.New("values are encrypted with age, but no age identity was given")
+		}
+		var err error
+		if r, err = age.Decrypt(bytes.NewReader(ciphertext), k.AgeIdentities...); err != nil {
+			return nil, errors.Wrap(err, "unable to decrypt")
+		}
+	} else {
+		if len(k.PGPKeyRing) == 0 {
+			return nil, errors.New("values are encrypted with OpenPGP, but no keyring was given")
+		}
+		md, err := openpgp.ReadMessage(bytes.NewReader(ciphertext), k.PGPKeyRing, nil, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decrypt")
+		}
+		r = md.UnverifiedBody
+	}
+	plaintext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decrypt")
+	}
+	return plaintext, nil
+}
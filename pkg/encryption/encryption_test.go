@@ -0,0 +1,280 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+const (
+	testKeyring = "../provenance/testdata/helm-test-key.secret"
+	testKeyName = `Helm Testing (This key should only be used for testing. DO NOT TRUST.) <helm-testing@helm.sh>`
+)
+
+const testValues = `# The image to run.
+image: nginx
+replicas: 3
+database:
+  host: db.example.com
+  password: s3cr3t # Rotate me.
+  users: [admin, app]
+`
+
+func ageKeys(t *testing.T) *Keys {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Keys{AgeRecipients: []age.Recipient{id.Recipient()}, AgeIdentities: []age.Identity{id}}
+}
+
+func pgpKeys(t *testing.T) *Keys {
+	t.Helper()
+	s, err := provenance.NewFromKeyring(testKeyring, testKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Keys{PGPRecipients: s.KeyRing[:1], PGPKeyRing: s.KeyRing}
+}
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	for name, keys := range map[string]*Keys{"age": ageKeys(t), "pgp": pgpKeys(t)} {
+		encrypted, err := Encrypt([]byte(testValues), keys)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !IsEncrypted(encrypted) || bytes.Contains(encrypted, []byte("s3cr3t")) {
+			t.Errorf("%s: expected encrypted values, got\n%s", name, encrypted)
+		}
+		if fields, err := EncryptedFields(encrypted); err != nil || len(fields) != 0 {
+			t.Errorf("%s: expected no encrypted fields, got %v (%v)", name, fields, err)
+		}
+
+		decrypted, err := Decrypt(encrypted, keys)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if string(decrypted) != testValues {
+			t.Errorf("%s: expected\n%s\ngot\n%s", name, testValues, decrypted)
+		}
+	}
+}
+
+func TestEncryptAndDecryptFields(t *testing.T) {
+	for name, keys := range map[string]*Keys{"age": ageKeys(t), "pgp": pgpKeys(t)} {
+		encrypted, err := EncryptFields([]byte(testValues), []string{"replicas", "database.password", "database.users"}, keys)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !IsEncrypted(encrypted) || bytes.Contains(encrypted, []byte("s3cr3t")) || bytes.Contains(encrypted, []byte("admin")) {
+			t.Errorf("%s: expected encrypted fields, got\n%s", name, encrypted)
+		}
+		for _, s := range []string{"# The image to run.\nimage: nginx\n", "host: db.example.com\n", " # Rotate me.\n"} {
+			if !strings.Contains(string(encrypted), s) {
+				t.Errorf("%s: expected %q to be left as it is, got\n%s", name, s, encrypted)
+			}
+		}
+
+		fields, err := EncryptedFields(encrypted)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if expect := []string{"replicas", "database.password", "database.users"}; !reflect.DeepEqual(fields, expect) {
+			t.Errorf("%s: expected encrypted fields %v, got %v", name, expect, fields)
+		}
+
+		// Encrypting a field again leaves it as it is.
+		again, err := EncryptFields(encrypted, []string{"database.password"}, keys)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !bytes.Equal(again, encrypted) {
+			t.Errorf("%s: expected an encrypted field to be left as it is, got\n%s", name, again)
+		}
+
+		decrypted, err := Decrypt(encrypted, keys)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		var expect, got map[string]interface{}
+		if err := yaml.Unmarshal([]byte(testValues), &expect); err != nil {
+			t.Fatal(err)
+		}
+		if err := yaml.Unmarshal(decrypted, &got); err != nil {
+			t.Fatalf("%s: %s\n%s", name, err, decrypted)
+		}
+		if !reflect.DeepEqual(expect, got) {
+			t.Errorf("%s: expected %v, got %v", name, expect, got)
+		}
+	}
+}
+
+func TestEncryptErrors(t *testing.T) {
+	keys := ageKeys(t)
+	if _, err := EncryptFields([]byte(testValues), []string{"database.port"}, keys); err == nil || err.Error() != "no field database.port in the values" {
+		t.Errorf("expected an error for a missing field, got %v", err)
+	}
+	if _, err := EncryptFields([]byte("- a\n- b\n"), []string{"a"}, keys); err == nil || err.Error() != "values must be a map" {
+		t.Errorf("expected an error for values that are not a map, got %v", err)
+	}
+
+	both := &Keys{AgeRecipients: keys.AgeRecipients, PGPRecipients: pgpKeys(t).PGPRecipients}
+	if _, err := Encrypt([]byte(testValues), both); err == nil || err.Error() != "cannot encrypt with both age and OpenPGP keys" {
+		t.Errorf("expected an error encrypting with both age and OpenPGP keys, got %v", err)
+	}
+	if _, err := Encrypt([]byte(testValues), &Keys{}); err == nil || err.Error() != "no age recipient or OpenPGP key to encrypt with" {
+		t.Errorf("expected an error encrypting without keys, got %v", err)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	encrypted, err := EncryptFields([]byte(testValues), []string{"database.password"}, ageKeys(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(encrypted, &Keys{}); err == nil || err.Error() != "values are encrypted with age, but no age identity was given" {
+		t.Errorf("expected an error decrypting without an identity, got %v", err)
+	}
+	if _, err := Decrypt(encrypted, ageKeys(t)); err == nil || !strings.HasPrefix(err.Error(), "unable to decrypt") {
+		t.Errorf("expected an error decrypting with another identity, got %v", err)
+	}
+
+	encrypted, err = Encrypt([]byte(testValues), pgpKeys(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(encrypted, &Keys{}); err == nil || err.Error() != "values are encrypted with OpenPGP, but no keyring was given" {
+		t.Errorf("expected an error decrypting without a keyring, got %v", err)
+	}
+
+	if plain, err := Decrypt([]byte(testValues), &Keys{}); err != nil || string(plain) != testValues {
+		t.Errorf("expected values that are not encrypted to be left as they are, got %q (%v)", plain, err)
+	}
+}
+
+func TestDecryptFieldsOnlyValues(t *testing.T) {
+	keys := ageKeys(t)
+	encrypted, err := EncryptFields([]byte("password: s3cr3t\nenabled: true\n"), []string{"password", "enabled"}, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]string
+	if err := yaml.Unmarshal(encrypted, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	// The encrypted values are quoted, and one is left in a comment.
+	values := "# was " + fields["password"] + "\npassword: \"" + fields["password"] + "\"\nenabled: '" + fields["enabled"] + "'\n"
+	decrypted, err := Decrypt([]byte(values), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "# was " + fields["password"] + "\npassword: \"s3cr3t\"\nenabled: true\n"; string(decrypted) != expect {
+		t.Errorf("expected\n%s\ngot\n%s", expect, decrypted)
+	}
+
+	commented := []byte("# password: " + fields["password"] + "\npassword: s3cr3t\n")
+	if IsEncrypted(commented) {
+		t.Error("expected values with an encrypted value in a comment only not to be encrypted")
+	}
+	if plain, err := Decrypt(commented, &Keys{}); err != nil || !bytes.Equal(plain, commented) {
+		t.Errorf("expected values with an encrypted value in a comment only to be left as they are, got %q (%v)", plain, err)
+	}
+}
+
+func TestDecryptPGPKeys(t *testing.T) {
+	const keyring = "../provenance/testdata/helm-password-key.secret"
+	s, err := provenance.NewFromKeyring(keyring, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := Encrypt([]byte(testValues), &Keys{PGPRecipients: s.KeyRing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(encrypted, &Keys{PGPKeyRing: s.KeyRing}); err == nil {
+		t.Error("expected an error decrypting with keys protected by a passphrase")
+	}
+
+	wrong := func(string) ([]byte, error) { return []byte("secrets_and_lies"), nil }
+	if err := DecryptPGPKeys(s.KeyRing, wrong); err == nil {
+		t.Error("expected an error decrypting keys with a bogus passphrase")
+	}
+
+	if s, err = provenance.NewFromKeyring(keyring, ""); err != nil {
+		t.Fatal(err)
+	}
+	var asked []string
+	fetch := func(name string) ([]byte, error) {
+		asked = append(asked, name)
+		return []byte("secret"), nil
+	}
+	if err := DecryptPGPKeys(s.KeyRing, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if len(asked) != 1 {
+		t.Errorf("expected the passphrase to be asked once, got %v", asked)
+	}
+	decrypted, err := Decrypt(encrypted, &Keys{PGPKeyRing: s.KeyRing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decrypted) != testValues {
+		t.Errorf("expected\n%s\ngot\n%s", testValues, decrypted)
+	}
+}
+
+func TestDecryptFieldsKeepsStrings(t *testing.T) {
+	keys := ageKeys(t)
+	values := `enabled: "yes"
+password: "on"
+port: "123"
+empty: "null"
+debug: "true"
+list: ["yes", 123]
+`
+	fields := []string{"enabled", "password", "port", "empty", "debug", "list"}
+	encrypted, err := EncryptFields([]byte(values), fields, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := Decrypt(encrypted, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Helm reads values as YAML 1.1, where yes and on are booleans.
+	var expect, got map[string]interface{}
+	if err := yaml.Unmarshal([]byte(values), &expect); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(decrypted, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("expected %v, got %v from\n%s", expect, got, decrypted)
+	}
+}
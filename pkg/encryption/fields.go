@@ -0,0 +1,243 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// encryptedField matches the encrypted value of a field.
+var encryptedField = regexp.MustCompile(`ENC\[(age|pgp):([A-Za-z0-9+/]+=*)\]`)
+
+// EncryptFields encrypts the values of fields, given by their dotted paths like
+// database.password. A field whose value is a map or a list is encrypted as a
+// whole. Fields that are encrypted already are left as they are.
+func EncryptFields(data []byte, fields []string, keys *Keys) ([]byte, error) {
+	scheme, err := keys.scheme()
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		n := lookup(doc.Content[0], strings.Split(field, "."))
+		if n == nil {
+			return nil, errors.Errorf("no field %s in the values", field)
+		}
+		if isEncryptedValue(n) {
+			continue
+		}
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, errors.Wrapf(err, "failed to read field %s", field)
+		}
+		plaintext, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode field %s", field)
+		}
+		ciphertext, err := keys.seal(scheme, plaintext)
+		if err != nil {
+			return nil, err
+		}
+		*n = yaml.Node{
+			Kind:        yaml.ScalarNode,
+			Tag:         "!!str",
+			Value:       fmt.Sprintf("ENC[%s:%s]", scheme, base64.StdEncoding.EncodeToString(ciphertext)),
+			HeadComment: n.HeadComment,
+			LineComment: n.LineComment,
+			FootComment: n.FootComment,
+		}
+	}
+
+	return encodeDocument(doc)
+}
+
+// EncryptedFields returns the dotted paths of the encrypted fields of values,
+// which is empty if they are not encrypted by fields.
+func EncryptedFields(data []byte) ([]string, error) {
+	if !encryptedField.Match(data) || armoredScheme(data) != "" {
+		return nil, nil
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return encryptedFields(doc.Content[0], ""), nil
+}
+
+func encryptedFields(n *yaml.Node, prefix string) []string {
+	var fields []string
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		path := prefix + n.Content[i].Value
+		switch v := n.Content[i+1]; {
+		case isEncryptedValue(v):
+			fields = append(fields, path)
+		case v.Kind == yaml.MappingNode:
+			fields = append(fields, encryptedFields(v, path+".")...)
+		}
+	}
+	return fields
+}
+
+// decryptFields replaces the encrypted values of fields by their decrypted
+// values. Values without encrypted fields are returned as they are.
+func decryptFields(data []byte, keys *Keys) ([]byte, error) {
+	doc := encryptedDocument(data)
+	if doc == nil {
+		return data, nil
+	}
+	if err := decryptNode(doc, keys); err != nil {
+		return nil, err
+	}
+	return encodeDocument(doc)
+}
+
+// encryptedDocument parses values that have encrypted fields, or returns nil
+// if they have none. Encrypted values in comments do not count.
+func encryptedDocument(data []byte) *yaml.Node {
+	if !encryptedField.Match(data) {
+		return nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || !hasEncryptedValue(&doc) {
+		return nil
+	}
+	return &doc
+}
+
+func hasEncryptedValue(n *yaml.Node) bool {
+	for i, c := range n.Content {
+		// The keys of a map are never encrypted.
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		if isEncryptedValue(c) || hasEncryptedValue(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// decryptNode replaces the encrypted values under a node by their decrypted
+// values, keeping their comments.
+func decryptNode(n *yaml.Node, keys *Keys) error {
+	for i, c := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		if !isEncryptedValue(c) {
+			if err := decryptNode(c, keys); err != nil {
+				return err
+			}
+			continue
+		}
+		m := encryptedField.FindStringSubmatch(c.Value)
+		ciphertext, err := base64.StdEncoding.DecodeString(m[2])
+		if err != nil {
+			return errors.Wrap(err, "invalid encrypted value")
+		}
+		plaintext, err := keys.open(m[1], ciphertext)
+		if err != nil {
+			return err
+		}
+		var v yaml.Node
+		if err := yaml.Unmarshal(plaintext, &v); err != nil || len(v.Content) == 0 {
+			return errors.New("invalid encrypted value")
+		}
+		value := v.Content[0]
+		// The value is encoded as JSON; maps and lists are written in the
+		// style of the rest of the values.
+		resetStyle(value)
+		value.Anchor = c.Anchor
+		value.HeadComment, value.LineComment, value.FootComment = c.HeadComment, c.LineComment, c.FootComment
+		*c = *value
+	}
+	return nil
+}
+
+// resetStyle drops the flow style of maps and lists. Strings keep their
+// quotes, or Helm would read strings like "yes" or "123" as other types.
+func resetStyle(n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!str" {
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		resetStyle(c)
+	}
+}
+
+// encodeDocument encodes values the way Helm writes them.
+func encodeDocument(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseDocument parses values, which must be a map.
+func parseDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "failed to parse values")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("values must be a map")
+	}
+	return &doc, nil
+}
+
+// lookup returns the value of a field of a map node, or nil if there is none.
+func lookup(n *yaml.Node, path []string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return n.Content[i+1]
+		}
+		return lookup(n.Content[i+1], path[1:])
+	}
+	return nil
+}
+
+func isEncryptedValue(n *yaml.Node) bool {
+	if n.Kind != yaml.ScalarNode {
+		return false
+	}
+	loc := encryptedField.FindStringIndex(n.Value)
+	return loc != nil && loc[0] == 0 && loc[1] == len(n.Value)
+}
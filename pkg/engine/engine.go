@@ -17,6 +17,7 @@ limitations under the License.
 package engine
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"path"
@@ -27,10 +28,14 @@ import (
 	"text/template"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/client-go/rest"
 
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
 // Engine is an implementation of the Helm rendering implementation for templates.
@@ -64,7 +69,17 @@ type Engine struct {
 // section contains a value named "bar", that value will be passed on to the
 // bar chart during render time.
 func (e Engine) Render(chrt *chart.Chart, values chartutil.Values) (map[string]string, error) {
+	return e.RenderContext(context.Background(), chrt, values)
+}
+
+// RenderContext is Render recording a tracing span for the rendering as a
+// child of the span in ctx.
+func (e Engine) RenderContext(ctx context.Context, chrt *chart.Chart, values chartutil.Values) (_ map[string]string, err error) {
+	_, span := tracer.Start(ctx, "engine.Render", trace.WithAttributes(attribute.String("chart", chrt.Name())))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	tmap := allTemplates(chrt, values)
+	span.SetAttributes(attribute.Int("templates", len(tmap)))
 	return e.render(tmap)
 }
 
@@ -74,15 +89,30 @@ func Render(chrt *chart.Chart, values chartutil.Values) (map[string]string, erro
 	return new(Engine).Render(chrt, values)
 }
 
+// RenderContext is Render recording a tracing span as a child of the span
+// in ctx.
+func RenderContext(ctx context.Context, chrt *chart.Chart, values chartutil.Values) (map[string]string, error) {
+	return new(Engine).RenderContext(ctx, chrt, values)
+}
+
 // RenderWithClient takes a chart, optional values, and value overrides, and attempts to
 // render the Go templates using the default options. This engine is client aware and so can have template
 // functions that interact with the client
 func RenderWithClient(chrt *chart.Chart, values chartutil.Values, config *rest.Config) (map[string]string, error) {
+	return RenderWithClientContext(context.Background(), chrt, values, config)
+}
+
+// RenderWithClientContext is RenderWithClient recording a tracing span as a
+// child of the span in ctx.
+func RenderWithClientContext(ctx context.Context, chrt *chart.Chart, values chartutil.Values, config *rest.Config) (map[string]string, error) {
 	return Engine{
 		config: config,
-	}.Render(chrt, values)
+	}.RenderContext(ctx, chrt, values)
 }
 
+// tracer records the spans of the template rendering.
+var tracer = otel.Tracer("helm.sh/helm/v3/pkg/engine")
+
 // renderable is an object that can be rendered.
 type renderable struct {
 	// tpl is the current template.
@@ -0,0 +1,181 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package errdefs classifies the errors returned by Helm.
+
+The action, kube and storage packages return errors wrapping an *Error,
+which carries the Type of the failure and details about it. Callers
+inspect them with TypeOf and DetailsOf rather than by matching error
+messages:
+
+	if errdefs.TypeOf(err) == errdefs.TypeTimeout {
+		// retry with a longer timeout
+	}
+
+TypeOf also recognizes the errors of the Kubernetes API and of waits that
+timed out, which are not wrapped by Helm.
+*/
+package errdefs // import "helm.sh/helm/v3/pkg/errdefs"
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Type is the class of an error.
+type Type string
+
+// The types of errors.
+const (
+	// TypeUnknown is the type of errors that are not classified.
+	TypeUnknown Type = "unknown"
+	// TypeReleaseNotFound is the type of errors about a release, or a
+	// revision of it, that does not exist.
+	TypeReleaseNotFound Type = "release-not-found"
+	// TypeTimeout is the type of errors about operations that did not
+	// complete in time.
+	TypeTimeout Type = "timeout"
+	// TypeResourceConflict is the type of errors about resources or
+	// releases that already exist or were modified concurrently.
+	TypeResourceConflict Type = "resource-conflict"
+	// TypeRenderError is the type of errors about charts whose templates
+	// could not be rendered.
+	TypeRenderError Type = "render-error"
+	// TypeSchemaViolation is the type of errors about values or manifests
+	// that do not conform to their schema.
+	TypeSchemaViolation Type = "schema-violation"
+	// TypeHookFailed is the type of errors about hooks that failed.
+	TypeHookFailed Type = "hook-failed"
+	// TypeAuthError is the type of errors about requests that were not
+	// authenticated or not authorized.
+	TypeAuthError Type = "auth-error"
+)
+
+// Types returns the types of errors, except TypeUnknown.
+func Types() []Type {
+	return []Type{
+		TypeReleaseNotFound,
+		TypeTimeout,
+		TypeResourceConflict,
+		TypeRenderError,
+		TypeSchemaViolation,
+		TypeHookFailed,
+		TypeAuthError,
+	}
+}
+
+// Error is an error classified with a type and details.
+type Error struct {
+	// Type is the class of the error.
+	Type Type
+	// Err is the classified error.
+	Err error
+	// Details holds information about the error, such as the name of the
+	// release or the hook.
+	Details map[string]interface{}
+}
+
+// New returns an error of the given type with the given message.
+func New(t Type, msg string) *Error {
+	return &Error{Type: t, Err: errors.New(msg)}
+}
+
+// Wrap classifies err with the given type. It returns nil if err is nil.
+// The keysAndValues alternate between a detail key and its value.
+func Wrap(t Type, err error, keysAndValues ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	e := &Error{Type: t, Err: err}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			continue
+		}
+		if e.Details == nil {
+			e.Details = map[string]interface{}{}
+		}
+		e.Details[key] = keysAndValues[i+1]
+	}
+	return e
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+// Unwrap returns the classified error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Cause returns the classified error, for github.com/pkg/errors.
+func (e *Error) Cause() error { return e.Err }
+
+// TypeOf returns the type of err: the type of the outermost *Error in its
+// chain, or the type of a Kubernetes API error or timeout it wraps. It
+// returns TypeUnknown otherwise.
+func TypeOf(err error) Type {
+	if err == nil {
+		return TypeUnknown
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Type
+	}
+	switch {
+	case errors.Is(err, wait.ErrWaitTimeout), errors.Is(err, context.DeadlineExceeded),
+		apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return TypeTimeout
+	case apierrors.IsUnauthorized(err), apierrors.IsForbidden(err):
+		return TypeAuthError
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+		return TypeResourceConflict
+	case apierrors.IsInvalid(err):
+		return TypeSchemaViolation
+	}
+	return TypeUnknown
+}
+
+// DetailsOf returns the details of all the *Error in the chain of err. The
+// details of the outer errors take precedence.
+func DetailsOf(err error) map[string]interface{} {
+	details := map[string]interface{}{}
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			for k, v := range e.Details {
+				if _, ok := details[k]; !ok {
+					details[k] = v
+				}
+			}
+		}
+		err = unwrap(err)
+	}
+	return details
+}
+
+// unwrap returns the error wrapped by err, supporting both the Unwrap
+// method of the standard library and the Cause method of
+// github.com/pkg/errors.
+func unwrap(err error) error {
+	if u := errors.Unwrap(err); u != nil {
+		return u
+	}
+	if c, ok := err.(interface{ Cause() error }); ok {
+		return c.Cause()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errdefs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestTypeOf(t *testing.T) {
+	gr := schema.GroupResource{Resource: "configmaps"}
+	tests := []struct {
+		name string
+		err  error
+		want Type
+	}{
+		{"nil", nil, TypeUnknown},
+		{"plain", errors.New("boom"), TypeUnknown},
+		{"typed", New(TypeReleaseNotFound, "not found"), TypeReleaseNotFound},
+		{"wrapped", errors.Wrap(New(TypeRenderError, "bad template"), "install"), TypeRenderError},
+		{"fmt wrapped", fmt.Errorf("install: %w", New(TypeHookFailed, "hook")), TypeHookFailed},
+		{"outermost wins", Wrap(TypeHookFailed, Wrap(TypeTimeout, wait.ErrWaitTimeout)), TypeHookFailed},
+		{"wait timeout", errors.Wrap(wait.ErrWaitTimeout, "waiting"), TypeTimeout},
+		{"deadline", context.DeadlineExceeded, TypeTimeout},
+		{"server timeout", apierrors.NewServerTimeout(gr, "get", 1), TypeTimeout},
+		{"unauthorized", apierrors.NewUnauthorized("no"), TypeAuthError},
+		{"forbidden", apierrors.NewForbidden(gr, "foo", errors.New("no")), TypeAuthError},
+		{"conflict", apierrors.NewConflict(gr, "foo", errors.New("modified")), TypeResourceConflict},
+		{"exists", errors.Wrap(apierrors.NewAlreadyExists(gr, "foo"), "create"), TypeResourceConflict},
+		{"invalid", apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "foo", nil), TypeSchemaViolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TypeOf(tt.err); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if err := Wrap(TypeTimeout, nil); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	cause := errors.New("boom")
+	err := Wrap(TypeHookFailed, cause, "hook", "test", "weight")
+	if err.Error() != "boom" {
+		t.Errorf("expected the message of the wrapped error, got %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected the wrapped error in the chain")
+	}
+	if errors.Cause(err) != cause {
+		t.Error("expected the wrapped error as the cause")
+	}
+	want := map[string]interface{}{"hook": "test"}
+	if got := DetailsOf(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected details %v, got %v", want, got)
+	}
+}
+
+func TestDetailsOf(t *testing.T) {
+	inner := Wrap(TypeTimeout, wait.ErrWaitTimeout, "timeout", "5m0s", "hook", "inner")
+	err := errors.Wrap(Wrap(TypeHookFailed, errors.Wrap(inner, "watch"), "hook", "outer"), "install")
+
+	want := map[string]interface{}{"timeout": "5m0s", "hook": "outer"}
+	if got := DetailsOf(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected details %v, got %v", want, got)
+	}
+	if got := DetailsOf(errors.New("boom")); len(got) != 0 {
+		t.Errorf("expected no details, got %v", got)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package junit writes test results in the JUnit XML format understood by most
+CI systems.
+
+	suite := junit.TestSuite{Name: "my-release"}
+	suite.Add(junit.TestCase{Name: "test-connection", Time: junit.Seconds(2 * time.Second)})
+	err := junit.Write(out, suite)
+*/
+package junit // import "helm.sh/helm/v3/pkg/junit"
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TestSuites is the root element of a report.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     float64     `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite is a group of test cases.
+type TestSuite struct {
+	Name     string     `xml:"name,attr"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Skipped  int        `xml:"skipped,attr"`
+	Time     float64    `xml:"time,attr"`
+	Cases    []TestCase `xml:"testcase"`
+}
+
+// TestCase is the result of a test.
+type TestCase struct {
+	Name      string  `xml:"name,attr"`
+	ClassName string  `xml:"classname,attr,omitempty"`
+	Time      float64 `xml:"time,attr"`
+	// Failure is set if the test failed.
+	Failure *Failure `xml:"failure,omitempty"`
+	// Skipped is set if the test was not run.
+	Skipped *Skipped `xml:"skipped,omitempty"`
+	// SystemOut is the output of the test.
+	SystemOut string `xml:"system-out,omitempty"`
+}
+
+// Failure describes why a test failed.
+type Failure struct {
+	Message string `xml:"message,attr"`
+	// Body is the detail of the failure, like a log excerpt.
+	Body string `xml:",chardata"`
+}
+
+// Skipped describes why a test was not run.
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// Seconds returns a duration in the unit of the Time attributes.
+func Seconds(d time.Duration) float64 {
+	return d.Round(time.Millisecond).Seconds()
+}
+
+// Add adds a test case to the suite and counts it.
+func (s *TestSuite) Add(c TestCase) {
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	s.Time += c.Time
+	switch {
+	case c.Failure != nil:
+		s.Failures++
+	case c.Skipped != nil:
+		s.Skipped++
+	}
+}
+
+// Write writes the suites to out as a JUnit XML report.
+func Write(out io.Writer, suites ...TestSuite) error {
+	report := TestSuites{Suites: suites}
+	for _, s := range suites {
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		report.Skipped += s.Skipped
+		report.Time += s.Time
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.Wrap(err, "unable to encode the JUnit report")
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+// WriteFile writes the suites to the named file as a JUnit XML report.
+func WriteFile(filename string, suites ...TestSuite) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrap(err, "unable to create the JUnit report")
+	}
+	if err := Write(f, suites...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,43 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package junit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/internal/test"
+)
+
+func TestWrite(t *testing.T) {
+	suite := TestSuite{Name: "my-release"}
+	suite.Add(TestCase{Name: "test-connection", ClassName: "my-release", Time: Seconds(1500 * time.Millisecond), SystemOut: "connected\n"})
+	suite.Add(TestCase{
+		Name:      "test-auth",
+		ClassName: "my-release",
+		Time:      Seconds(2 * time.Second),
+		Failure:   &Failure{Message: "pod test-auth failed", Body: "401 <Unauthorized> & retrying\n"},
+	})
+	suite.Add(TestCase{Name: "test-slow", ClassName: "my-release", Skipped: &Skipped{Message: "filtered out"}})
+
+	var out bytes.Buffer
+	if err := Write(&out, suite); err != nil {
+		t.Fatal(err)
+	}
+	test.AssertGoldenString(t, out.String(), "report.xml")
+}
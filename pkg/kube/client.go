@@ -29,6 +29,8 @@ import (
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -50,6 +52,9 @@ import (
 	cachetools "k8s.io/client-go/tools/cache"
 	watchtools "k8s.io/client-go/tools/watch"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	"helm.sh/helm/v3/pkg/logging"
+	"helm.sh/helm/v3/pkg/tracing"
 )
 
 // ErrNoObjectsVisited indicates that during a visit operation, no matching objects were found.
@@ -64,7 +69,13 @@ var ManagedFieldsManager string
 // Client represents a client capable of communicating with the Kubernetes API.
 type Client struct {
 	Factory Factory
-	Log     func(string, ...interface{})
+	// Logger receives the diagnostic output of the client. If nil, Log is
+	// used.
+	Logger logging.Logger
+	// Log is the printf-style logger used before Logger was introduced.
+	//
+	// Deprecated: set Logger instead. Log is only used if Logger is nil.
+	Log func(string, ...interface{})
 	// Namespace allows to bypass the kubeconfig file for the choice of the namespace
 	Namespace string
 
@@ -96,6 +107,15 @@ func New(getter genericclioptions.RESTClientGetter) *Client {
 
 var nopLogger = func(_ string, _ ...interface{}) {}
 
+// logger returns the logger of the client, wrapping the legacy Log
+// function if Logger is not set.
+func (c *Client) logger() logging.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return logging.FromFunc(c.Log)
+}
+
 // getKubeClient get or create a new KubernetesClientSet
 func (c *Client) getKubeClient() (*kubernetes.Clientset, error) {
 	var err error
@@ -125,8 +145,17 @@ func (c *Client) IsReachable() error {
 
 // Create creates Kubernetes resources specified in the resource list.
 func (c *Client) Create(resources ResourceList) (*Result, error) {
-	c.Log("creating %d resource(s)", len(resources))
-	if err := perform(resources, createResource); err != nil {
+	return c.CreateContext(context.Background(), resources)
+}
+
+// CreateContext creates Kubernetes resources specified in the resource list,
+// recording a span for each batch of resources of the same kind.
+func (c *Client) CreateContext(ctx context.Context, resources ResourceList) (_ *Result, err error) {
+	ctx, span := tracer.Start(ctx, "kube.Create", trace.WithAttributes(attribute.Int("resources", len(resources))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	c.logger().Debug("creating resources", "count", len(resources))
+	if err := performContext(ctx, "create", resources, createResource); err != nil {
 		return nil, err
 	}
 	return &Result{Created: resources}, nil
@@ -134,29 +163,33 @@ func (c *Client) Create(resources ResourceList) (*Result, error) {
 
 // Wait waits up to the given timeout for the specified resources to be ready.
 func (c *Client) Wait(resources ResourceList, timeout time.Duration) error {
-	cs, err := c.getKubeClient()
-	if err != nil {
-		return err
-	}
-	checker := NewReadyChecker(cs, c.Log, PausedAsReady(true))
-	w := waiter{
-		c:       checker,
-		log:     c.Log,
-		timeout: timeout,
-	}
-	return w.waitForResources(resources)
+	return c.WaitContext(context.Background(), resources, timeout, false)
 }
 
 // WaitWithJobs wait up to the given timeout for the specified resources to be ready, including jobs.
 func (c *Client) WaitWithJobs(resources ResourceList, timeout time.Duration) error {
+	return c.WaitContext(context.Background(), resources, timeout, true)
+}
+
+// WaitContext waits up to the given timeout for the specified resources to
+// be ready, including jobs if withJobs is true.
+func (c *Client) WaitContext(ctx context.Context, resources ResourceList, timeout time.Duration, withJobs bool) (err error) {
+	_, span := tracer.Start(ctx, "kube.Wait", trace.WithAttributes(
+		attribute.Int("resources", len(resources)),
+		attribute.String("timeout", timeout.String()),
+		attribute.Bool("jobs", withJobs),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	cs, err := c.getKubeClient()
 	if err != nil {
 		return err
 	}
-	checker := NewReadyChecker(cs, c.Log, PausedAsReady(true), CheckJobs(true))
+	log := logging.Printf(c.logger())
+	checker := NewReadyChecker(cs, log, PausedAsReady(true), CheckJobs(withJobs))
 	w := waiter{
 		c:       checker,
-		log:     c.Log,
+		log:     log,
 		timeout: timeout,
 	}
 	return w.waitForResources(resources)
@@ -165,7 +198,7 @@ func (c *Client) WaitWithJobs(resources ResourceList, timeout time.Duration) err
 // WaitForDelete wait up to the given timeout for the specified resources to be deleted.
 func (c *Client) WaitForDelete(resources ResourceList, timeout time.Duration) error {
 	w := waiter{
-		log:     c.Log,
+		log:     logging.Printf(c.logger()),
 		timeout: timeout,
 	}
 	return w.waitForDeletedResources(resources)
@@ -212,11 +245,21 @@ func (c *Client) Build(reader io.Reader, validate bool) (ResourceList, error) {
 // resource updates, creations, and deletions that were attempted. These can be
 // used for cleanup or other logging purposes.
 func (c *Client) Update(original, target ResourceList, force bool) (*Result, error) {
+	return c.UpdateContext(context.Background(), original, target, force)
+}
+
+// UpdateContext is Update recording spans for the resources created or
+// updated and for the resources deleted.
+func (c *Client) UpdateContext(ctx context.Context, original, target ResourceList, force bool) (_ *Result, err error) {
+	ctx, span := tracer.Start(ctx, "kube.Update", trace.WithAttributes(attribute.Int("resources", len(target))))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	updateErrors := []string{}
 	res := &Result{}
 
-	c.Log("checking %d resources for changes", len(target))
-	err := target.Visit(func(info *resource.Info, err error) error {
+	_, applySpan := tracer.Start(ctx, "kube.Update apply")
+	c.logger().Debug("checking resources for changes", "count", len(target))
+	err = target.Visit(func(info *resource.Info, err error) error {
 		if err != nil {
 			return err
 		}
@@ -236,7 +279,7 @@ func (c *Client) Update(original, target ResourceList, force bool) (*Result, err
 			}
 
 			kind := info.Mapping.GroupVersionKind.Kind
-			c.Log("Created a new %s called %q in %s\n", kind, info.Name, info.Namespace)
+			c.logger().Debug("created a new resource", "kind", kind, "name", info.Name, "namespace", info.Namespace)
 			return nil
 		}
 
@@ -247,7 +290,7 @@ func (c *Client) Update(original, target ResourceList, force bool) (*Result, err
 		}
 
 		if err := updateResource(c, info, originalInfo.Object, force); err != nil {
-			c.Log("error updating the resource %q:\n\t %v", info.Name, err)
+			c.logger().Warn("failed to update the resource", "name", info.Name, "error", err)
 			updateErrors = append(updateErrors, err.Error())
 		}
 		// Because we check for errors later, append the info regardless
@@ -256,30 +299,37 @@ func (c *Client) Update(original, target ResourceList, force bool) (*Result, err
 		return nil
 	})
 
-	switch {
-	case err != nil:
+	if err == nil && len(updateErrors) != 0 {
+		err = errors.Errorf(strings.Join(updateErrors, " && "))
+	}
+	applySpan.SetAttributes(attribute.Int("created", len(res.Created)), attribute.Int("updated", len(res.Updated)))
+	tracing.EndSpan(applySpan, err)
+	if err != nil {
 		return res, err
-	case len(updateErrors) != 0:
-		return res, errors.Errorf(strings.Join(updateErrors, " && "))
 	}
 
+	_, deleteSpan := tracer.Start(ctx, "kube.Update delete")
+	defer func() {
+		deleteSpan.SetAttributes(attribute.Int("deleted", len(res.Deleted)))
+		deleteSpan.End()
+	}()
 	for _, info := range original.Difference(target) {
-		c.Log("Deleting %s %q in namespace %s...", info.Mapping.GroupVersionKind.Kind, info.Name, info.Namespace)
+		c.logger().Debug("deleting resource", "kind", info.Mapping.GroupVersionKind.Kind, "name", info.Name, "namespace", info.Namespace)
 
 		if err := info.Get(); err != nil {
-			c.Log("Unable to get obj %q, err: %s", info.Name, err)
+			c.logger().Warn("unable to get the object", "name", info.Name, "error", err)
 			continue
 		}
 		annotations, err := metadataAccessor.Annotations(info.Object)
 		if err != nil {
-			c.Log("Unable to get annotations on %q, err: %s", info.Name, err)
+			c.logger().Warn("unable to get the annotations", "name", info.Name, "error", err)
 		}
 		if annotations != nil && annotations[ResourcePolicyAnno] == KeepPolicy {
-			c.Log("Skipping delete of %q due to annotation [%s=%s]", info.Name, ResourcePolicyAnno, KeepPolicy)
+			c.logger().Info("skipping delete due to annotation", "name", info.Name, "annotation", ResourcePolicyAnno+"="+KeepPolicy)
 			continue
 		}
 		if err := deleteResource(info); err != nil {
-			c.Log("Failed to delete %q, err: %s", info.ObjectName(), err)
+			c.logger().Warn("failed to delete the resource", "name", info.ObjectName(), "error", err)
 			continue
 		}
 		res.Deleted = append(res.Deleted, info)
@@ -296,7 +346,7 @@ func (c *Client) Delete(resources ResourceList) (*Result, []error) {
 	res := &Result{}
 	mtx := sync.Mutex{}
 	err := perform(resources, func(info *resource.Info) error {
-		c.Log("Starting delete for %q %s", info.Name, info.Mapping.GroupVersionKind.Kind)
+		c.logger().Debug("starting delete", "kind", info.Mapping.GroupVersionKind.Kind, "name", info.Name)
 		if err := c.skipIfNotFound(deleteResource(info)); err != nil {
 			mtx.Lock()
 			defer mtx.Unlock()
@@ -325,15 +375,15 @@ func (c *Client) Delete(resources ResourceList) (*Result, []error) {
 
 func (c *Client) skipIfNotFound(err error) error {
 	if apierrors.IsNotFound(err) {
-		c.Log("%v", err)
+		c.logger().Warn("failed to delete the resource", "error", err)
 		return nil
 	}
 	return err
 }
 
-func (c *Client) watchTimeout(t time.Duration) func(*resource.Info) error {
+func (c *Client) watchTimeout(ctx context.Context, t time.Duration) func(*resource.Info) error {
 	return func(info *resource.Info) error {
-		return c.watchUntilReady(t, info)
+		return c.watchUntilReady(ctx, t, info)
 	}
 }
 
@@ -352,18 +402,36 @@ func (c *Client) watchTimeout(t time.Duration) func(*resource.Info) error {
 //
 // Handling for other kinds will be added as necessary.
 func (c *Client) WatchUntilReady(resources ResourceList, timeout time.Duration) error {
+	return c.WatchUntilReadyContext(context.Background(), resources, timeout)
+}
+
+// WatchUntilReadyContext is WatchUntilReady recording a span for the watch.
+func (c *Client) WatchUntilReadyContext(ctx context.Context, resources ResourceList, timeout time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "kube.WatchUntilReady", trace.WithAttributes(
+		attribute.Int("resources", len(resources)),
+		attribute.String("timeout", timeout.String()),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// For jobs, there's also the option to do poll c.Jobs(namespace).Get():
 	// https://github.com/adamreese/kubernetes/blob/master/test/e2e/job.go#L291-L300
-	return perform(resources, c.watchTimeout(timeout))
+	return performContext(ctx, "watch", resources, c.watchTimeout(ctx, timeout))
 }
 
 func perform(infos ResourceList, fn func(*resource.Info) error) error {
+	return performContext(context.Background(), "", infos, fn)
+}
+
+// performContext calls fn for each of infos, concurrently for resources of
+// the same kind. If op is not empty, a span named after op is recorded for
+// each batch.
+func performContext(ctx context.Context, op string, infos ResourceList, fn func(*resource.Info) error) error {
 	if len(infos) == 0 {
 		return ErrNoObjectsVisited
 	}
 
 	errs := make(chan error)
-	go batchPerform(infos, fn, errs)
+	go batchPerform(ctx, op, infos, fn, errs)
 
 	for range infos {
 		err := <-errs
@@ -394,21 +462,36 @@ func getManagedFieldsManager() string {
 	return filepath.Base(os.Args[0])
 }
 
-func batchPerform(infos ResourceList, fn func(*resource.Info) error, errs chan<- error) {
+func batchPerform(ctx context.Context, op string, infos ResourceList, fn func(*resource.Info) error, errs chan<- error) {
 	var kind string
 	var wg sync.WaitGroup
-	for _, info := range infos {
+	var span trace.Span
+	endBatch := func() {
+		wg.Wait()
+		if span != nil {
+			span.End()
+		}
+	}
+	for n, info := range infos {
 		currentKind := info.Object.GetObjectKind().GroupVersionKind().Kind
-		if kind != currentKind {
-			wg.Wait()
+		if n == 0 || kind != currentKind {
+			endBatch()
 			kind = currentKind
+			if op != "" {
+				_, span = tracer.Start(ctx, "kube."+op+" batch", trace.WithAttributes(attribute.String("kind", kind)))
+			}
 		}
 		wg.Add(1)
-		go func(i *resource.Info) {
-			errs <- fn(i)
+		go func(i *resource.Info, span trace.Span) {
+			err := fn(i)
+			if err != nil && span != nil {
+				span.RecordError(err)
+			}
+			errs <- err
 			wg.Done()
-		}(info)
+		}(info, span)
 	}
+	endBatch()
 }
 
 func createResource(info *resource.Info) error {
@@ -490,7 +573,7 @@ func updateResource(c *Client, target *resource.Info, currentObj runtime.Object,
 		if err != nil {
 			return errors.Wrap(err, "failed to replace object")
 		}
-		c.Log("Replaced %q with kind %s for kind %s", target.Name, currentObj.GetObjectKind().GroupVersionKind().Kind, kind)
+		c.logger().Debug("replaced resource", "kind", kind, "name", target.Name, "currentKind", currentObj.GetObjectKind().GroupVersionKind().Kind)
 	} else {
 		patch, patchType, err := createPatch(target, currentObj)
 		if err != nil {
@@ -498,7 +581,7 @@ func updateResource(c *Client, target *resource.Info, currentObj runtime.Object,
 		}
 
 		if patch == nil || string(patch) == "{}" {
-			c.Log("Looks like there are no changes for %s %q", kind, target.Name)
+			c.logger().Debug("no changes", "kind", kind, "name", target.Name)
 			// This needs to happen to make sure that Helm has the latest info from the API
 			// Otherwise there will be no labels and other functions that use labels will panic
 			if err := target.Get(); err != nil {
@@ -507,7 +590,7 @@ func updateResource(c *Client, target *resource.Info, currentObj runtime.Object,
 			return nil
 		}
 		// send patch to server
-		c.Log("Patch %s %q in namespace %s", kind, target.Name, target.Namespace)
+		c.logger().Debug("patching resource", "kind", kind, "name", target.Name, "namespace", target.Namespace)
 		obj, err = helper.Patch(target.Namespace, target.Name, patchType, patch, nil)
 		if err != nil {
 			return errors.Wrapf(err, "cannot patch %q with kind %s", target.Name, kind)
@@ -518,7 +601,7 @@ func updateResource(c *Client, target *resource.Info, currentObj runtime.Object,
 	return nil
 }
 
-func (c *Client) watchUntilReady(timeout time.Duration, info *resource.Info) error {
+func (c *Client) watchUntilReady(parent context.Context, timeout time.Duration, info *resource.Info) error {
 	kind := info.Mapping.GroupVersionKind.Kind
 	switch kind {
 	case "Job", "Pod":
@@ -526,7 +609,7 @@ func (c *Client) watchUntilReady(timeout time.Duration, info *resource.Info) err
 		return nil
 	}
 
-	c.Log("Watching for changes to %s %s with timeout of %v", kind, info.Name, timeout)
+	c.logger().Debug("watching for changes", "kind", kind, "name", info.Name, "timeout", timeout)
 
 	// Use a selector on the name of the resource. This should be unique for the
 	// given version and kind
@@ -542,7 +625,7 @@ func (c *Client) watchUntilReady(timeout time.Duration, info *resource.Info) err
 	// In the future, we might want to add some special logic for types
 	// like Ingress, Volume, etc.
 
-	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), timeout)
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(parent, timeout)
 	defer cancel()
 	_, err = watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, nil, func(e watch.Event) (bool, error) {
 		// Make sure the incoming object is versioned as we use unstructured
@@ -554,7 +637,7 @@ func (c *Client) watchUntilReady(timeout time.Duration, info *resource.Info) err
 			// we get. We care mostly about jobs, where what we want to see is
 			// the status go into a good state. For other types, like ReplicaSet
 			// we don't really do anything to support these as hooks.
-			c.Log("Add/Modify event for %s: %v", info.Name, e.Type)
+			c.logger().Debug("add/modify event", "name", info.Name, "type", e.Type)
 			switch kind {
 			case "Job":
 				return c.waitForJob(obj, info.Name)
@@ -563,17 +646,20 @@ func (c *Client) watchUntilReady(timeout time.Duration, info *resource.Info) err
 			}
 			return true, nil
 		case watch.Deleted:
-			c.Log("Deleted event for %s", info.Name)
+			c.logger().Debug("deleted event", "name", info.Name)
 			return true, nil
 		case watch.Error:
 			// Handle error and return with an error.
-			c.Log("Error event for %s", info.Name)
+			c.logger().Debug("error event", "name", info.Name)
 			return true, errors.Errorf("failed to deploy %s", info.Name)
 		default:
 			return false, nil
 		}
 	})
-	return err
+	if err != nil && parent.Err() == context.Canceled {
+		return errors.Wrapf(parent.Err(), "stopped watching %s", info.Name)
+	}
+	return timeoutError(err, timeout)
 }
 
 // waitForJob is a helper that waits for a job to complete.
@@ -593,7 +679,7 @@ func (c *Client) waitForJob(obj runtime.Object, name string) (bool, error) {
 		}
 	}
 
-	c.Log("%s: Jobs active: %d, jobs failed: %d, jobs succeeded: %d", name, o.Status.Active, o.Status.Failed, o.Status.Succeeded)
+	c.logger().Debug("job status", "name", name, "active", o.Status.Active, "failed", o.Status.Failed, "succeeded", o.Status.Succeeded)
 	return false, nil
 }
 
@@ -608,14 +694,14 @@ func (c *Client) waitForPodSuccess(obj runtime.Object, name string) (bool, error
 
 	switch o.Status.Phase {
 	case v1.PodSucceeded:
-		c.Log("Pod %s succeeded", o.Name)
+		c.logger().Debug("pod succeeded", "name", o.Name)
 		return true, nil
 	case v1.PodFailed:
 		return true, errors.Errorf("pod %s failed", o.Name)
 	case v1.PodPending:
-		c.Log("Pod %s pending", o.Name)
+		c.logger().Debug("pod pending", "name", o.Name)
 	case v1.PodRunning:
-		c.Log("Pod %s running", o.Name)
+		c.logger().Debug("pod running", "name", o.Name)
 	}
 
 	return false, nil
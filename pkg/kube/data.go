@@ -0,0 +1,70 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SecretData returns the value of a key of a Secret.
+func (c *Client) SecretData(namespace, name, key string) ([]byte, error) {
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return secretData(context.Background(), cs, namespace, name, key)
+}
+
+// ConfigMapData returns the value of a key of a ConfigMap, which may be in
+// its data or its binary data.
+func (c *Client) ConfigMapData(namespace, name, key string) ([]byte, error) {
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return configMapData(context.Background(), cs, namespace, name, key)
+}
+
+func secretData(ctx context.Context, cs kubernetes.Interface, namespace, name, key string) ([]byte, error) {
+	secret, err := cs.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get secret %s/%s", namespace, name)
+	}
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, errors.Errorf("secret %s/%s has no key %q", namespace, name, key)
+	}
+	return data, nil
+}
+
+func configMapData(ctx context.Context, cs kubernetes.Interface, namespace, name, key string) ([]byte, error) {
+	cm, err := cs.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get configmap %s/%s", namespace, name)
+	}
+	if data, ok := cm.Data[key]; ok {
+		return []byte(data), nil
+	}
+	if data, ok := cm.BinaryData[key]; ok {
+		return data, nil
+	}
+	return nil, errors.Errorf("configmap %s/%s has no key %q", namespace, name, key)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretAndConfigMapData(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web"},
+			Data:       map[string][]byte{"values.yaml": []byte("password: s3cr3t\n")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web"},
+			Data:       map[string]string{"values.yaml": "replicas: 3\n"},
+			BinaryData: map[string][]byte{"binary.yaml": []byte("image: nginx\n")},
+		},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		read   func(namespace, name, key string) ([]byte, error)
+		key    string
+		expect string
+		err    string
+	}{{
+		name:   "secret",
+		read:   func(ns, n, k string) ([]byte, error) { return secretData(ctx, cs, ns, n, k) },
+		key:    "values.yaml",
+		expect: "password: s3cr3t\n",
+	}, {
+		name: "secret without the key",
+		read: func(ns, n, k string) ([]byte, error) { return secretData(ctx, cs, ns, n, k) },
+		key:  "other.yaml",
+		err:  `secret prod/web has no key "other.yaml"`,
+	}, {
+		name:   "configmap",
+		read:   func(ns, n, k string) ([]byte, error) { return configMapData(ctx, cs, ns, n, k) },
+		key:    "values.yaml",
+		expect: "replicas: 3\n",
+	}, {
+		name:   "configmap binary data",
+		read:   func(ns, n, k string) ([]byte, error) { return configMapData(ctx, cs, ns, n, k) },
+		key:    "binary.yaml",
+		expect: "image: nginx\n",
+	}, {
+		name: "configmap without the key",
+		read: func(ns, n, k string) ([]byte, error) { return configMapData(ctx, cs, ns, n, k) },
+		key:  "other.yaml",
+		err:  `configmap prod/web has no key "other.yaml"`,
+	}}
+	for _, tt := range tests {
+		data, err := tt.read("prod", "web", tt.key)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: expected error %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+		} else if string(data) != tt.expect {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expect, data)
+		}
+	}
+
+	if _, err := secretData(ctx, cs, "dev", "web", "values.yaml"); err == nil {
+		t.Error("expected an error reading a missing secret")
+	}
+}
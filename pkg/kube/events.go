@@ -0,0 +1,160 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"helm.sh/helm/v3/pkg/logging"
+)
+
+// Event is a Kubernetes event about a resource.
+type Event struct {
+	// Kind is the kind of the resource the event is about.
+	Kind string `json:"kind"`
+	// Name is the name of the resource the event is about.
+	Name string `json:"name"`
+	// Namespace is the namespace of the resource the event is about.
+	Namespace string `json:"namespace,omitempty"`
+	// Type is the type of the event, Normal or Warning.
+	Type string `json:"type"`
+	// Reason is a short, machine understandable reason for the event.
+	Reason string `json:"reason"`
+	// Message is a human-readable description of the event.
+	Message string `json:"message"`
+	// Count is the number of times the event occurred.
+	Count int32 `json:"count,omitempty"`
+	// LastSeen is the last time the event occurred.
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// String returns the event in the form
+// "Warning FailedScheduling Pod/web-0: 0/3 nodes are available (x4)".
+func (e Event) String() string {
+	s := fmt.Sprintf("%s %s %s/%s: %s", e.Type, e.Reason, e.Kind, e.Name, e.Message)
+	if e.Count > 1 {
+		s += fmt.Sprintf(" (x%d)", e.Count)
+	}
+	return s
+}
+
+// UnreadyEvents returns the most recent events, at most limit, about the
+// resources that are not ready and about their pods, oldest first. Events
+// last seen before since are left out, unless since is zero.
+func (c *Client) UnreadyEvents(resources ResourceList, since time.Time, limit int) ([]Event, error) {
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	checker := NewReadyChecker(cs, logging.Printf(c.logger()), PausedAsReady(true), CheckJobs(true))
+	return unreadyEvents(context.Background(), cs, checker, resources, since, limit)
+}
+
+func unreadyEvents(ctx context.Context, cs kubernetes.Interface, checker ReadyChecker, resources ResourceList, since time.Time, limit int) ([]Event, error) {
+	// Event timestamps only have a precision of a second.
+	since = since.Truncate(time.Second)
+
+	var events []Event
+	for _, info := range resources {
+		if ready, err := checker.IsReady(ctx, info); err == nil && ready {
+			continue
+		}
+		kind := info.Mapping.GroupVersionKind.Kind
+		evs, err := eventsFor(ctx, cs, info.Namespace, kind, info.Name)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, evs...)
+
+		pods, err := selectedPods(ctx, cs, info.Namespace, AsVersioned(info))
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods {
+			evs, err := eventsFor(ctx, cs, pod.Namespace, "Pod", pod.Name)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, evs...)
+		}
+	}
+
+	recent := events[:0]
+	for _, e := range events {
+		if !e.LastSeen.Before(since) {
+			recent = append(recent, e)
+		}
+	}
+	events = recent
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastSeen.Before(events[j].LastSeen)
+	})
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events, nil
+}
+
+// eventsFor returns the events about the named resource.
+func eventsFor(ctx context.Context, cs kubernetes.Interface, namespace, kind, name string) ([]Event, error) {
+	list, err := cs.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": kind, "involvedObject.name": name}.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, e := range list.Items {
+		// Not every client filters by field.
+		if e.InvolvedObject.Kind != kind || e.InvolvedObject.Name != name {
+			continue
+		}
+		events = append(events, Event{
+			Kind:      kind,
+			Name:      name,
+			Namespace: namespace,
+			Type:      e.Type,
+			Reason:    e.Reason,
+			Message:   e.Message,
+			Count:     e.Count,
+			LastSeen:  lastSeen(&e),
+		})
+	}
+	return events, nil
+}
+
+// lastSeen returns the last time the event occurred.
+func lastSeen(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
@@ -0,0 +1,111 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newEvent(name, kind, object, reason string, lastSeen time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: defaultNamespace},
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object, Namespace: defaultNamespace},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		Message:        reason + " happened",
+		Count:          1,
+		LastTimestamp:  metav1.NewTime(lastSeen),
+	}
+}
+
+func newInfo(obj runtime.Object, kind string, name string) *resource.Info {
+	return &resource.Info{
+		Name:      name,
+		Namespace: defaultNamespace,
+		Object:    obj,
+		Mapping:   &meta.RESTMapping{GroupVersionKind: batchv1.SchemeGroupVersion.WithKind(kind)},
+	}
+}
+
+func TestUnreadyEvents(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	job := newJob("migrate", 0, nil, 0, 1)
+	pod := newPodWithCondition("migrate-x7k2", corev1.ConditionFalse)
+	pod.Labels = map[string]string{"job-name": "migrate"}
+	ready := newJob("done", 0, nil, 1, 0)
+
+	cs := fake.NewSimpleClientset(job, pod, ready,
+		newEvent("e1", "Job", "migrate", "BackoffLimitExceeded", now.Add(2*time.Second)),
+		newEvent("e2", "Pod", "migrate-x7k2", "BackOff", now.Add(time.Second)),
+		newEvent("e3", "Pod", "migrate-x7k2", "Pulled", now),
+		newEvent("e4", "Job", "done", "Completed", now.Add(3*time.Second)),
+		newEvent("e5", "Pod", "other", "BackOff", now),
+	)
+	checker := NewReadyChecker(cs, nil, CheckJobs(true))
+	resources := ResourceList{newInfo(job, "Job", "migrate"), newInfo(ready, "Job", "done")}
+
+	for _, tt := range []struct {
+		since time.Time
+		limit int
+		want  []string
+	}{
+		{time.Time{}, 2, []string{
+			"Warning BackOff Pod/migrate-x7k2: BackOff happened",
+			"Warning BackoffLimitExceeded Job/migrate: BackoffLimitExceeded happened",
+		}},
+		// Event timestamps are truncated to the second.
+		{now.Add(1500 * time.Millisecond), 0, []string{
+			"Warning BackOff Pod/migrate-x7k2: BackOff happened",
+			"Warning BackoffLimitExceeded Job/migrate: BackoffLimitExceeded happened",
+		}},
+		{now.Add(2 * time.Second), 0, []string{
+			"Warning BackoffLimitExceeded Job/migrate: BackoffLimitExceeded happened",
+		}},
+		{now.Add(time.Minute), 0, nil},
+	} {
+		events, err := unreadyEvents(context.Background(), cs, checker, resources, tt.since, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range events {
+			got = append(got, e.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("since %s: expected events %q, got %q", tt.since, tt.want, got)
+		}
+	}
+}
+
+func TestEventString(t *testing.T) {
+	e := Event{Kind: "Pod", Name: "web-0", Type: "Warning", Reason: "FailedScheduling", Message: "0/3 nodes are available", Count: 4}
+	if got, want := e.String(), "Warning FailedScheduling Pod/web-0: 0/3 nodes are available (x4)"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
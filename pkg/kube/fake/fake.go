@@ -41,6 +41,10 @@ type FailingKubeClient struct {
 	BuildUnstructuredError           error
 	WaitAndGetCompletedPodPhaseError error
 	WaitDuration                     time.Duration
+	// Events are the events returned by UnreadyEvents.
+	Events []kube.Event
+	// EventsSince is the time UnreadyEvents was last asked for events since.
+	EventsSince time.Time
 }
 
 // Create returns the configured error if set or prints
@@ -115,3 +119,13 @@ func (f *FailingKubeClient) WaitAndGetCompletedPodPhase(s string, d time.Duratio
 	}
 	return f.PrintingKubeClient.WaitAndGetCompletedPodPhase(s, d)
 }
+
+// UnreadyEvents returns the configured events, at most limit, and records
+// since in EventsSince.
+func (f *FailingKubeClient) UnreadyEvents(_ kube.ResourceList, since time.Time, limit int) ([]kube.Event, error) {
+	f.EventsSince = since
+	if limit > 0 && len(f.Events) > limit {
+		return f.Events[len(f.Events)-limit:], nil
+	}
+	return f.Events, nil
+}
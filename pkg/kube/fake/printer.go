@@ -21,6 +21,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/cli-runtime/pkg/resource"
 
@@ -101,6 +102,36 @@ func (p *PrintingKubeClient) WaitAndGetCompletedPodPhase(_ string, _ time.Durati
 	return v1.PodSucceeded, nil
 }
 
+// Statuses implements kube.StatusInterface. It reports every resource as
+// ready.
+func (p *PrintingKubeClient) Statuses(resources kube.ResourceList) ([]kube.ResourceStatus, error) {
+	statuses := make([]kube.ResourceStatus, 0, len(resources))
+	for _, info := range resources {
+		statuses = append(statuses, kube.ResourceStatus{Name: info.Name, Namespace: info.Namespace, Ready: true})
+	}
+	return statuses, nil
+}
+
+// UnreadyEvents implements kube.EventsInterface. It returns no events.
+func (p *PrintingKubeClient) UnreadyEvents(_ kube.ResourceList, _ time.Time, _ int) ([]kube.Event, error) {
+	return nil, nil
+}
+
+// ContainerLogs implements kube.LogsInterface. It returns no logs.
+func (p *PrintingKubeClient) ContainerLogs(_ kube.ResourceList, _, _ int64) ([]kube.ContainerLog, error) {
+	return nil, nil
+}
+
+// SecretData implements kube.DataInterface. There are no Secrets.
+func (p *PrintingKubeClient) SecretData(namespace, name, _ string) ([]byte, error) {
+	return nil, errors.Errorf("secret %s/%s not found", namespace, name)
+}
+
+// ConfigMapData implements kube.DataInterface. There are no ConfigMaps.
+func (p *PrintingKubeClient) ConfigMapData(namespace, name, _ string) ([]byte, error) {
+	return nil, errors.Errorf("configmap %s/%s not found", namespace, name)
+}
+
 func bufferize(resources kube.ResourceList) io.Reader {
 	var builder strings.Builder
 	for _, info := range resources {
@@ -17,6 +17,7 @@ limitations under the License.
 package kube
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -78,5 +79,74 @@ type InterfaceExt interface {
 	WaitForDelete(resources ResourceList, timeout time.Duration) error
 }
 
+// ContextInterface is implemented by clients that record the operations of
+// Interface as tracing spans. The context carries the parent span; it only
+// cancels WatchUntilReadyContext.
+//
+// TODO Helm 4: Integrate its methods into the Interface.
+type ContextInterface interface {
+	// CreateContext creates one or more resources.
+	CreateContext(ctx context.Context, resources ResourceList) (*Result, error)
+
+	// UpdateContext updates one or more resources or creates the resource
+	// if it doesn't exist.
+	UpdateContext(ctx context.Context, original, target ResourceList, force bool) (*Result, error)
+
+	// WaitContext waits up to the given timeout for the specified resources
+	// to be ready, including jobs if withJobs is true.
+	WaitContext(ctx context.Context, resources ResourceList, timeout time.Duration, withJobs bool) error
+
+	// WatchUntilReadyContext watches the resources given and waits until
+	// it is ready, or until ctx is cancelled.
+	WatchUntilReadyContext(ctx context.Context, resources ResourceList, timeout time.Duration) error
+}
+
+// EventsInterface is implemented by clients that report the Kubernetes
+// events explaining why resources are not ready.
+//
+// TODO Helm 4: Integrate its methods into the Interface.
+type EventsInterface interface {
+	// UnreadyEvents returns the most recent events, at most limit, about the
+	// resources that are not ready and about their pods, oldest first. Events
+	// last seen before since are left out, unless since is zero.
+	UnreadyEvents(resources ResourceList, since time.Time, limit int) ([]Event, error)
+}
+
+// StatusInterface is implemented by clients that report the live state of
+// resources.
+//
+// TODO Helm 4: Integrate its methods into the Interface.
+type StatusInterface interface {
+	// Statuses returns the live state of the resources, in the same order.
+	Statuses(resources ResourceList) ([]ResourceStatus, error)
+}
+
+// LogsInterface is implemented by clients that fetch the logs of the pods of
+// resources.
+//
+// TODO Helm 4: Integrate its methods into the Interface.
+type LogsInterface interface {
+	// ContainerLogs returns the tail of the logs of the containers of the
+	// pods of the resources, at most tailLines lines and limitBytes bytes per
+	// container, and how each container terminated.
+	ContainerLogs(resources ResourceList, tailLines, limitBytes int64) ([]ContainerLog, error)
+}
+
+// DataInterface is implemented by clients that read the data of Secrets and
+// ConfigMaps.
+//
+// TODO Helm 4: Integrate its methods into the Interface.
+type DataInterface interface {
+	// SecretData returns the value of a key of a Secret.
+	SecretData(namespace, name, key string) ([]byte, error)
+	// ConfigMapData returns the value of a key of a ConfigMap.
+	ConfigMapData(namespace, name, key string) ([]byte, error)
+}
+
 var _ Interface = (*Client)(nil)
 var _ InterfaceExt = (*Client)(nil)
+var _ ContextInterface = (*Client)(nil)
+var _ EventsInterface = (*Client)(nil)
+var _ StatusInterface = (*Client)(nil)
+var _ LogsInterface = (*Client)(nil)
+var _ DataInterface = (*Client)(nil)
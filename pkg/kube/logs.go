@@ -0,0 +1,90 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ContainerLog is the tail of the log of a container, and how it terminated.
+type ContainerLog struct {
+	// Namespace is the namespace of the pod.
+	Namespace string `json:"namespace,omitempty"`
+	// Pod is the name of the pod.
+	Pod string `json:"pod"`
+	// Container is the name of the container.
+	Container string `json:"container"`
+	// Reason is why the container terminated, like Completed, Error or
+	// OOMKilled. It is empty if the container has not terminated.
+	Reason string `json:"reason,omitempty"`
+	// ExitCode is the exit code of the terminated container.
+	ExitCode int32 `json:"exitCode,omitempty"`
+	// Message is the termination message of the container.
+	Message string `json:"message,omitempty"`
+	// Log is the tail of the log of the container.
+	Log string `json:"log,omitempty"`
+}
+
+// ContainerLogs returns the tail of the logs of the containers of the pods of
+// the resources, at most tailLines lines and limitBytes bytes per container,
+// and how each container terminated. Containers that never ran are skipped.
+func (c *Client) ContainerLogs(resources ResourceList, tailLines, limitBytes int64) ([]ContainerLog, error) {
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return containerLogs(context.Background(), cs, resources, tailLines, limitBytes)
+}
+
+func containerLogs(ctx context.Context, cs kubernetes.Interface, resources ResourceList, tailLines, limitBytes int64) ([]ContainerLog, error) {
+	var logs []ContainerLog
+	for _, info := range resources {
+		pods, err := PodsOf(ctx, cs, info.Namespace, AsVersioned(info))
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods {
+			statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+			for _, st := range statuses {
+				terminated := st.State.Terminated
+				if terminated == nil {
+					terminated = st.LastTerminationState.Terminated
+				}
+				if st.State.Running == nil && terminated == nil {
+					continue
+				}
+				l := ContainerLog{Namespace: pod.Namespace, Pod: pod.Name, Container: st.Name}
+				if terminated != nil {
+					l.Reason = terminated.Reason
+					l.ExitCode = terminated.ExitCode
+					l.Message = terminated.Message
+				}
+				opts := &corev1.PodLogOptions{Container: st.Name, TailLines: &tailLines, LimitBytes: &limitBytes}
+				// The log may be gone with the node; the termination reason
+				// is still worth reporting.
+				if raw, err := cs.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx); err == nil {
+					l.Log = string(raw)
+				}
+				logs = append(logs, l)
+			}
+		}
+	}
+	return logs, nil
+}
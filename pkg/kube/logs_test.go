@@ -0,0 +1,69 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestContainerLogs(t *testing.T) {
+	job := newJob("migrate", 0, nil, 0, 1)
+	failed := newPodWithCondition("migrate-x7k2", corev1.ConditionFalse)
+	failed.Labels = map[string]string{"job-name": "migrate"}
+	failed.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "wait",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}},
+	}}
+	failed.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "migrate",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1, Message: "no such table"}},
+	}}
+	pending := newPodWithCondition("migrate-a1b2", corev1.ConditionFalse)
+	pending.Labels = map[string]string{"job-name": "migrate"}
+	pending.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "migrate",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	}}
+	bare := newPodWithCondition("check", corev1.ConditionTrue)
+	bare.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "check",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}
+
+	gone := newPodWithCondition("gone", corev1.ConditionFalse)
+
+	cs := fake.NewSimpleClientset(job, failed, pending, bare)
+	resources := ResourceList{newInfo(job, "Job", "migrate"), newInfo(bare, "Pod", "check"), newInfo(gone, "Pod", "gone")}
+
+	logs, err := containerLogs(context.Background(), cs, resources, 20, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []ContainerLog{
+		{Namespace: defaultNamespace, Pod: "migrate-x7k2", Container: "wait", Reason: "Completed", Log: "fake logs"},
+		{Namespace: defaultNamespace, Pod: "migrate-x7k2", Container: "migrate", Reason: "Error", ExitCode: 1, Message: "no such table", Log: "fake logs"},
+		{Namespace: defaultNamespace, Pod: "check", Container: "check", Log: "fake logs"},
+	}
+	if !reflect.DeepEqual(expect, logs) {
+		t.Errorf("expected logs %+v, got %+v", expect, logs)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodsOf returns the pods of an object in the given namespace, oldest first:
+// the object itself if it is a pod that still exists, or else the pods its
+// selector matches. Objects without a pod selector have no pods.
+func PodsOf(ctx context.Context, cs kubernetes.Interface, namespace string, obj runtime.Object) ([]corev1.Pod, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, nil
+	}
+	if _, ok := obj.(*corev1.Pod); !ok {
+		pods, err := selectedPods(ctx, cs, namespace, obj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list the pods of %s", accessor.GetName())
+		}
+		return pods, nil
+	}
+	pod, err := cs.CoreV1().Pods(namespace).Get(ctx, accessor.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get pod %s", accessor.GetName())
+	}
+	return []corev1.Pod{*pod}, nil
+}
+
+// selectedPods returns the pods the selector of a workload matches in the
+// given namespace, oldest first.
+func selectedPods(ctx context.Context, cs kubernetes.Interface, namespace string, obj runtime.Object) ([]corev1.Pod, error) {
+	selector, err := PodSelectorForObject(obj)
+	if err != nil || selector.Empty() {
+		return nil, nil
+	}
+	list, err := cs.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	pods := list.Items
+	sort.SliceStable(pods, func(i, j int) bool {
+		ti, tj := pods[i].CreationTimestamp, pods[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
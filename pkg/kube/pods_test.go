@@ -0,0 +1,77 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodsOf(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	job := newJob("migrate", 0, nil, 0, 1)
+	var pods []*corev1.Pod
+	for _, p := range []struct {
+		name    string
+		created time.Time
+	}{
+		{"migrate-a1b2", now.Add(2 * time.Minute)},
+		{"migrate-x7k2", now},
+		{"migrate-m3n4", now.Add(time.Minute)},
+		{"migrate-c5d6", now.Add(time.Minute)},
+	} {
+		pod := newPodWithCondition(p.name, corev1.ConditionFalse)
+		pod.Labels = map[string]string{"job-name": "migrate"}
+		pod.CreationTimestamp = metav1.NewTime(p.created)
+		pods = append(pods, pod)
+	}
+	bare := newPodWithCondition("check", corev1.ConditionTrue)
+
+	cs := fake.NewSimpleClientset(job, pods[0], pods[1], pods[2], pods[3], bare)
+	for _, tt := range []struct {
+		name   string
+		obj    runtime.Object
+		expect []string
+	}{
+		// Oldest first, so the logs of the newest pods are the ones kept.
+		{"workload", job, []string{"migrate-x7k2", "migrate-c5d6", "migrate-m3n4", "migrate-a1b2"}},
+		{"pod", bare, []string{"check"}},
+		{"missing pod", newPodWithCondition("gone", corev1.ConditionFalse), nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PodsOf(context.Background(), cs, defaultNamespace, tt.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, pod := range got {
+				names = append(names, pod.Name)
+			}
+			if !reflect.DeepEqual(tt.expect, names) {
+				t.Errorf("expected pods %v, got %v", tt.expect, names)
+			}
+		})
+	}
+}
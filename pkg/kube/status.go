@@ -0,0 +1,117 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+
+	"helm.sh/helm/v3/pkg/logging"
+)
+
+// ResourceStatus is the live state of a resource.
+type ResourceStatus struct {
+	// APIVersion is the group and version of the resource.
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the resource.
+	Kind string `json:"kind"`
+	// Name is the name of the resource.
+	Name string `json:"name"`
+	// Namespace is the namespace of the resource, if it is namespaced.
+	Namespace string `json:"namespace,omitempty"`
+	// Missing is true if the resource does not exist.
+	Missing bool `json:"missing,omitempty"`
+	// Ready is true if the resource is ready, as reported by ReadyChecker.
+	Ready bool `json:"ready"`
+	// Pods counts the pods of a workload. It is nil for other resources.
+	Pods *PodCount `json:"pods,omitempty"`
+	// CreatedAt is the time the resource was created.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// PodCount counts the pods of a workload.
+type PodCount struct {
+	// Ready is the number of pods that are ready.
+	Ready int `json:"ready"`
+	// Total is the number of pods.
+	Total int `json:"total"`
+}
+
+// Statuses returns the live state of the resources, in the same order.
+func (c *Client) Statuses(resources ResourceList) ([]ResourceStatus, error) {
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	checker := NewReadyChecker(cs, logging.Printf(c.logger()), PausedAsReady(true), CheckJobs(true))
+	get := func(info *resource.Info) (runtime.Object, error) {
+		return resource.NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name)
+	}
+	return resourceStatuses(context.Background(), cs, checker, get, resources)
+}
+
+func resourceStatuses(ctx context.Context, cs kubernetes.Interface, checker ReadyChecker, get func(*resource.Info) (runtime.Object, error), resources ResourceList) ([]ResourceStatus, error) {
+	statuses := make([]ResourceStatus, 0, len(resources))
+	for _, info := range resources {
+		gvk := info.Mapping.GroupVersionKind
+		st := ResourceStatus{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Name:       info.Name,
+			Namespace:  info.Namespace,
+		}
+
+		obj, err := get(info)
+		if apierrors.IsNotFound(err) {
+			st.Missing = true
+			statuses = append(statuses, st)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to get %s %s", st.Kind, st.Name)
+		}
+		if accessor, err := meta.Accessor(obj); err == nil {
+			created := accessor.GetCreationTimestamp().Time
+			st.CreatedAt = &created
+		}
+
+		// Resources that cannot be checked are not ready.
+		st.Ready, _ = checker.IsReady(ctx, info)
+
+		if selector, err := PodSelectorForObject(AsVersioned(info)); err == nil && !selector.Empty() {
+			pods, err := selectedPods(ctx, cs, info.Namespace, AsVersioned(info))
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to list the pods of %s %s", st.Kind, st.Name)
+			}
+			st.Pods = &PodCount{Total: len(pods)}
+			for i := range pods {
+				if checker.isPodReady(&pods[i]) {
+					st.Pods.Ready++
+				}
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v3/pkg/kube"
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResourceStatuses(t *testing.T) {
+	created := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	dep := newDeployment("web", 2, 1, 0)
+	dep.CreationTimestamp = metav1.NewTime(created)
+	ready := newPodWithCondition("web-1", corev1.ConditionTrue)
+	ready.Labels = map[string]string{"name": "web"}
+	unready := newPodWithCondition("web-2", corev1.ConditionFalse)
+	unready.Labels = map[string]string{"name": "web"}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: defaultNamespace}}
+
+	cs := fake.NewSimpleClientset(dep, ready, unready)
+	live := map[string]runtime.Object{"web": dep}
+	get := func(info *resource.Info) (runtime.Object, error) {
+		if obj, ok := live[info.Name]; ok {
+			return obj, nil
+		}
+		return nil, apierrors.NewNotFound(corev1.Resource("configmaps"), info.Name)
+	}
+	resources := ResourceList{
+		{Name: "web", Namespace: defaultNamespace, Object: dep, Mapping: &meta.RESTMapping{GroupVersionKind: appsv1.SchemeGroupVersion.WithKind("Deployment")}},
+		{Name: "web-config", Namespace: defaultNamespace, Object: cm, Mapping: &meta.RESTMapping{GroupVersionKind: corev1.SchemeGroupVersion.WithKind("ConfigMap")}},
+	}
+
+	statuses, err := resourceStatuses(context.Background(), cs, NewReadyChecker(cs, nil), get, resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 statuses, got %d", len(statuses))
+	}
+
+	web := statuses[0]
+	if web.APIVersion != "apps/v1" || web.Kind != "Deployment" || web.Missing {
+		t.Errorf("unexpected status of the deployment: %+v", web)
+	}
+	// The deployment has no ReplicaSet yet.
+	if web.Ready {
+		t.Error("expected the deployment not to be ready")
+	}
+	if web.Pods == nil || web.Pods.Ready != 1 || web.Pods.Total != 2 {
+		t.Errorf("expected 1/2 pods, got %+v", web.Pods)
+	}
+	if web.CreatedAt == nil || !web.CreatedAt.Equal(created) {
+		t.Errorf("expected creation time %s, got %v", created, web.CreatedAt)
+	}
+
+	config := statuses[1]
+	if !config.Missing || config.Pods != nil || config.CreatedAt != nil {
+		t.Errorf("expected the config map to be missing, got %+v", config)
+	}
+}
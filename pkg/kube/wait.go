@@ -34,6 +34,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"k8s.io/apimachinery/pkg/util/wait"
+
+	"helm.sh/helm/v3/pkg/errdefs"
 )
 
 type waiter struct {
@@ -50,7 +52,7 @@ func (w *waiter) waitForResources(created ResourceList) error {
 	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
 	defer cancel()
 
-	return wait.PollImmediateUntil(2*time.Second, func() (bool, error) {
+	err := wait.PollImmediateUntil(2*time.Second, func() (bool, error) {
 		for _, v := range created {
 			ready, err := w.c.IsReady(ctx, v)
 			if !ready || err != nil {
@@ -59,6 +61,7 @@ func (w *waiter) waitForResources(created ResourceList) error {
 		}
 		return true, nil
 	}, ctx.Done())
+	return timeoutError(err, w.timeout)
 }
 
 // waitForDeletedResources polls to check if all the resources are deleted or a timeout is reached
@@ -68,7 +71,7 @@ func (w *waiter) waitForDeletedResources(deleted ResourceList) error {
 	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
 	defer cancel()
 
-	return wait.PollImmediateUntil(2*time.Second, func() (bool, error) {
+	err := wait.PollImmediateUntil(2*time.Second, func() (bool, error) {
 		for _, v := range deleted {
 			err := v.Get()
 			if err == nil || !apierrors.IsNotFound(err) {
@@ -77,6 +80,16 @@ func (w *waiter) waitForDeletedResources(deleted ResourceList) error {
 		}
 		return true, nil
 	}, ctx.Done())
+	return timeoutError(err, w.timeout)
+}
+
+// timeoutError classifies err as a timeout if it is the error of a wait that
+// timed out.
+func timeoutError(err error, timeout time.Duration) error {
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return errdefs.Wrap(errdefs.TypeTimeout, err, "timeout", timeout.String())
+	}
+	return err
 }
 
 // SelectorsForObject returns the pod label selector for a given object
@@ -126,3 +139,13 @@ func SelectorsForObject(object runtime.Object) (selector labels.Selector, err er
 
 	return selector, errors.Wrap(err, "invalid label selector")
 }
+
+// PodSelectorForObject returns the label selector of the pods of a workload.
+// Unlike SelectorsForObject, it also selects the pods of a Job read from a
+// manifest, whose selector has not been generated by the server yet.
+func PodSelectorForObject(object runtime.Object) (labels.Selector, error) {
+	if job, ok := object.(*batchv1.Job); ok && job.Spec.Selector == nil {
+		return labels.SelectorFromSet(labels.Set{"job-name": job.Name}), nil
+	}
+	return SelectorsForObject(object)
+}
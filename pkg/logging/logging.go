@@ -0,0 +1,168 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package logging provides the leveled, structured logger used for the
+diagnostic output of Helm.
+
+A log entry is a message and a list of alternating keys and values:
+
+	logger.Debug("creating resources", "count", len(resources))
+
+Entries are written as text for people, or as one JSON object per line for
+tools parsing the output.
+*/
+package logging // import "helm.sh/helm/v3/pkg/logging"
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+// The levels of log entries, from the least to the most severe.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// String returns the lower case name of the level.
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the given name. "warning" is accepted
+// for LevelWarn.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(s)
+	if name == "warning" {
+		return LevelWarn, nil
+	}
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	return LevelDebug, errors.Errorf("unknown log level %q: must be one of %s", s, strings.Join(levelNames, ", "))
+}
+
+// Format is the encoding of the log entries written by a Logger.
+type Format string
+
+const (
+	// FormatText writes an entry as the level, the message and the
+	// key=value pairs on one line.
+	FormatText Format = "text"
+	// FormatJSON writes an entry as a JSON object on one line, with the
+	// time, level and msg keys followed by the keys of the entry.
+	FormatJSON Format = "json"
+)
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatText, FormatJSON:
+		return f, nil
+	}
+	return FormatText, errors.Errorf("unknown log format %q: must be one of %s, %s", s, FormatText, FormatJSON)
+}
+
+// Logger writes leveled log entries. The keysAndValues of an entry
+// alternate between a string key and its value.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+
+	// With returns a logger adding keysAndValues to every entry.
+	With(keysAndValues ...interface{}) Logger
+}
+
+// Nop returns a logger discarding every entry.
+func Nop() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+func (l nopLogger) With(...interface{}) Logger { return l }
+
+// FromFunc returns a logger writing every entry, as text, to a printf-style
+// log function such as the former Configuration.Log. Warnings and errors
+// are prefixed with "warning: " and "error: ".
+func FromFunc(log func(format string, v ...interface{})) Logger {
+	if log == nil {
+		return Nop()
+	}
+	return &funcLogger{log: log}
+}
+
+type funcLogger struct {
+	log    func(string, ...interface{})
+	fields []interface{}
+}
+
+func (l *funcLogger) Debug(msg string, kv ...interface{}) { l.write("", msg, kv) }
+func (l *funcLogger) Info(msg string, kv ...interface{})  { l.write("", msg, kv) }
+func (l *funcLogger) Warn(msg string, kv ...interface{})  { l.write("warning: ", msg, kv) }
+func (l *funcLogger) Error(msg string, kv ...interface{}) { l.write("error: ", msg, kv) }
+
+func (l *funcLogger) With(kv ...interface{}) Logger {
+	return &funcLogger{log: l.log, fields: appendFields(l.fields, kv)}
+}
+
+func (l *funcLogger) write(prefix, msg string, kv []interface{}) {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString(msg)
+	writeTextFields(&b, appendFields(l.fields, kv))
+	l.log("%s", b.String())
+}
+
+// Printf returns a printf-style function logging its message at the debug
+// level, for the APIs that take a log function.
+func Printf(l Logger) func(format string, v ...interface{}) {
+	return func(format string, v ...interface{}) {
+		l.Debug(fmt.Sprintf(format, v...))
+	}
+}
+
+// appendFields returns the fields followed by kv without modifying fields.
+func appendFields(fields, kv []interface{}) []interface{} {
+	if len(kv) == 0 {
+		return fields
+	}
+	all := make([]interface{}, 0, len(fields)+len(kv))
+	all = append(all, fields...)
+	return append(all, kv...)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{
+		"debug":   LevelDebug,
+		"INFO":    LevelInfo,
+		"warn":    LevelWarn,
+		"warning": LevelWarn,
+		"error":   LevelError,
+	}
+	for name, want := range tests {
+		got, err := ParseLevel(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("ParseLevel(%q) = %s, want %s", name, got, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestTextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelInfo, FormatText).With("release", "foo")
+	l.Debug("hidden")
+	l.Info("creating resources", "count", 3, "kind", "Config Map")
+	l.Warn("upgrade failed", "error", errors.New("boom"), "orphan")
+
+	expect := `[info] creating resources release=foo count=3 kind="Config Map"
+[warn] upgrade failed release=foo error=boom !BADKEY=orphan
+`
+	if buf.String() != expect {
+		t.Errorf("expected\n%s\ngot\n%s", expect, buf.String())
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelDebug, FormatJSON)
+	l.(*logger).out.now = func() time.Time { return time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC) }
+	l.With("release", "foo").Error("hook failed", "hook", "pre-install", "error", errors.New("timed out"), "retries", 2)
+
+	expect := `{"time":"2021-01-02T03:04:05Z","level":"error","msg":"hook failed","release":"foo","hook":"pre-install","error":"timed out","retries":2}` + "\n"
+	if buf.String() != expect {
+		t.Errorf("expected\n%s\ngot\n%s", expect, buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromFunc(t *testing.T) {
+	var lines []string
+	l := FromFunc(func(format string, v ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, v...))
+	}).With("driver", "Secret")
+	l.Debug("getting release", "key", "sh.helm.release.v1.foo.v1")
+	l.Warn("failed to prune", "error", "boom")
+	Printf(l)("Pruned %d record(s)", 2)
+
+	expect := []string{
+		"getting release driver=Secret key=sh.helm.release.v1.foo.v1",
+		"warning: failed to prune driver=Secret error=boom",
+		"Pruned 2 record(s) driver=Secret",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expect, "\n"), strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// badKey is the key of a value without a key in an entry.
+const badKey = "!BADKEY"
+
+// New returns a logger writing the entries of the given level and above to
+// w in the given format.
+func New(w io.Writer, level Level, format Format) Logger {
+	return &logger{out: &output{w: w, level: level, format: format, now: time.Now}}
+}
+
+// output is shared by a logger and the loggers derived from it with With.
+type output struct {
+	mu     sync.Mutex
+	w      io.Writer
+	level  Level
+	format Format
+	now    func() time.Time
+}
+
+type logger struct {
+	out    *output
+	fields []interface{}
+}
+
+func (l *logger) Debug(msg string, kv ...interface{}) { l.write(LevelDebug, msg, kv) }
+func (l *logger) Info(msg string, kv ...interface{})  { l.write(LevelInfo, msg, kv) }
+func (l *logger) Warn(msg string, kv ...interface{})  { l.write(LevelWarn, msg, kv) }
+func (l *logger) Error(msg string, kv ...interface{}) { l.write(LevelError, msg, kv) }
+
+func (l *logger) With(kv ...interface{}) Logger {
+	return &logger{out: l.out, fields: appendFields(l.fields, kv)}
+}
+
+func (l *logger) write(level Level, msg string, kv []interface{}) {
+	if level < l.out.level {
+		return
+	}
+	fields := appendFields(l.fields, kv)
+
+	var buf bytes.Buffer
+	if l.out.format == FormatJSON {
+		writeJSON(&buf, l.out.now(), level, msg, fields)
+	} else {
+		buf.WriteString("[" + level.String() + "] ")
+		buf.WriteString(msg)
+		writeTextFields(&buf, fields)
+	}
+	buf.WriteByte('\n')
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.w.Write(buf.Bytes())
+}
+
+// eachField calls fn with every key and value of kv.
+func eachField(kv []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fn(badKey, kv[i])
+			return
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fn(key, kv[i+1])
+	}
+}
+
+func writeTextFields(w interface{ WriteString(string) (int, error) }, kv []interface{}) {
+	eachField(kv, func(key string, value interface{}) {
+		w.WriteString(" " + key + "=" + textValue(value))
+	})
+}
+
+// textValue formats v, quoting it if it is empty or contains spaces,
+// quotes or equal signs.
+func textValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func writeJSON(buf *bytes.Buffer, t time.Time, level Level, msg string, kv []interface{}) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, t.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, level.String())
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, msg)
+	eachField(kv, func(key string, value interface{}) {
+		buf.WriteByte(',')
+		writeJSONValue(buf, key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, value)
+	})
+	buf.WriteByte('}')
+}
+
+// writeJSONValue writes v as JSON. Errors are written as their message and
+// values that cannot be encoded as their fmt representation.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%+v", v))
+	}
+	buf.Write(b)
+}
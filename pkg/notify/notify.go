@@ -0,0 +1,313 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package notify posts notifications about release events to HTTP endpoints.
+
+Endpoints are configured in a YAML file:
+
+	endpoints:
+	- name: chat
+	  url: https://hooks.example.com/services/T000/B000
+	  events: [succeeded, failed, rolled-back]
+	  secretEnv: CHAT_WEBHOOK_SECRET
+	  template: |
+	    {"text": "{{ .Operation }} of {{ .Release }} {{ .Type }}"}
+
+The payload of a notification is the JSON encoded Event, unless the endpoint
+sets a template. Templates are Go templates with the Sprig functions, are
+executed with the Event and must produce JSON. If the endpoint has a secret,
+the HMAC-SHA256 of the payload is sent in the X-Helm-Signature header.
+*/
+package notify // import "helm.sh/helm/v3/pkg/notify"
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// SignatureHeader is the header holding the HMAC-SHA256 signature of the
+// payload, in the form "sha256=<hex digest>".
+const SignatureHeader = "X-Helm-Signature"
+
+// EventType is the type of a release event.
+type EventType string
+
+// The release events notified.
+const (
+	EventStarted    EventType = "started"
+	EventSucceeded  EventType = "succeeded"
+	EventFailed     EventType = "failed"
+	EventRolledBack EventType = "rolled-back"
+)
+
+// Event is a release event.
+type Event struct {
+	Type EventType `json:"type"`
+	// Operation is the operation the event belongs to: install, upgrade,
+	// rollback or uninstall.
+	Operation string    `json:"operation"`
+	Release   string    `json:"release"`
+	Namespace string    `json:"namespace"`
+	Revision  int       `json:"revision,omitempty"`
+	Chart     string    `json:"chart,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	Time      time.Time `json:"time"`
+	// Error is the error of a failed operation.
+	Error string `json:"error,omitempty"`
+}
+
+// Endpoint is an HTTP endpoint notified of release events.
+type Endpoint struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Events are the event types sent to the endpoint. All events are sent
+	// if it is empty.
+	Events []EventType `json:"events,omitempty"`
+	// Headers are additional headers sent with each notification.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Template is the Go template of the JSON payload.
+	Template string `json:"template,omitempty"`
+	// Secret is the key the payload is signed with.
+	Secret string `json:"secret,omitempty"`
+	// SecretEnv is the name of the environment variable holding the key
+	// the payload is signed with. It is used if Secret is empty.
+	SecretEnv string `json:"secretEnv,omitempty"`
+	// Retries is the number of times a failed notification is retried.
+	// DefaultRetries is used if it is 0; a negative value disables retries.
+	Retries int `json:"retries,omitempty"`
+	// Timeout is the timeout of a single request, such as "10s".
+	Timeout string `json:"timeout,omitempty"`
+
+	tmpl    *template.Template
+	timeout time.Duration
+}
+
+// Config is the notification configuration file.
+type Config struct {
+	Endpoints []*Endpoint `json:"endpoints"`
+}
+
+// DefaultRetries is the number of retries of endpoints that do not set them.
+const DefaultRetries = 3
+
+// defaultTimeout is the request timeout of endpoints that do not set one.
+const defaultTimeout = 10 * time.Second
+
+// LoadConfig reads the notification configuration from path. It returns
+// nil without error if the file does not exist.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var c Config
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return &c, nil
+}
+
+// Notifier sends release events to the configured endpoints.
+type Notifier struct {
+	endpoints []*Endpoint
+
+	// Client is the HTTP client used to post notifications.
+	Client *http.Client
+	// Backoff is the delay before the first retry. It doubles for each
+	// further retry.
+	Backoff time.Duration
+	// Log receives the failures of notifications.
+	Log func(string, ...interface{})
+}
+
+// New returns a Notifier for the endpoints of c.
+func New(c *Config) (*Notifier, error) {
+	n := &Notifier{
+		Client:  &http.Client{},
+		Backoff: time.Second,
+		Log:     func(_ string, _ ...interface{}) {},
+	}
+	for i, ep := range c.Endpoints {
+		if ep.Name == "" {
+			return nil, errors.Errorf("notification endpoint %d has no name", i)
+		}
+		if ep.URL == "" {
+			return nil, errors.Errorf("notification endpoint %q has no url", ep.Name)
+		}
+		for _, t := range ep.Events {
+			switch t {
+			case EventStarted, EventSucceeded, EventFailed, EventRolledBack:
+			default:
+				return nil, errors.Errorf("notification endpoint %q: unknown event %q", ep.Name, t)
+			}
+		}
+		if ep.Template != "" {
+			tmpl, err := template.New(ep.Name).Funcs(sprig.TxtFuncMap()).Option("missingkey=error").Parse(ep.Template)
+			if err != nil {
+				return nil, errors.Wrapf(err, "notification endpoint %q: invalid template", ep.Name)
+			}
+			ep.tmpl = tmpl
+		}
+		ep.timeout = defaultTimeout
+		if ep.Timeout != "" {
+			d, err := time.ParseDuration(ep.Timeout)
+			if err != nil {
+				return nil, errors.Wrapf(err, "notification endpoint %q: invalid timeout", ep.Name)
+			}
+			ep.timeout = d
+		}
+		if ep.Retries == 0 {
+			ep.Retries = DefaultRetries
+		}
+		n.endpoints = append(n.endpoints, ep)
+	}
+	return n, nil
+}
+
+// Notify sends ev to all endpoints subscribed to its type. Failures are
+// logged; an error is returned if any endpoint could not be notified.
+func (n *Notifier) Notify(ev *Event) error {
+	var failed error
+	for _, ep := range n.endpoints {
+		if !ep.subscribed(ev.Type) {
+			continue
+		}
+		if err := n.send(ep, ev); err != nil {
+			n.Log("notify: failed to notify %s of %s event of release %s: %s", ep.Name, ev.Type, ev.Release, err)
+			if failed == nil {
+				failed = errors.Wrapf(err, "failed to notify %s", ep.Name)
+			}
+		}
+	}
+	return failed
+}
+
+func (ep *Endpoint) subscribed(t EventType) bool {
+	if len(ep.Events) == 0 {
+		return true
+	}
+	for _, e := range ep.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// payload renders the payload of ev for the endpoint.
+func (ep *Endpoint) payload(ev *Event) ([]byte, error) {
+	if ep.tmpl == nil {
+		return json.Marshal(ev)
+	}
+	var buf bytes.Buffer
+	if err := ep.tmpl.Execute(&buf, ev); err != nil {
+		return nil, errors.Wrap(err, "failed to render payload")
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.Errorf("payload is not valid JSON: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+func (ep *Endpoint) secret() string {
+	if ep.Secret != "" {
+		return ep.Secret
+	}
+	if ep.SecretEnv != "" {
+		return os.Getenv(ep.SecretEnv)
+	}
+	return ""
+}
+
+// Sign returns the value of the SignatureHeader of payload signed with
+// secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send posts ev to ep, retrying failed requests with exponential backoff.
+// Responses with a 4xx status other than 429 are not retried.
+func (n *Notifier) send(ep *Endpoint, ev *Event) error {
+	body, err := ep.payload(ev)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(ep, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= ep.Retries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post makes a single request. It reports whether a failed request should
+// be retried.
+func (n *Notifier) post(ep *Endpoint, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := ep.secret(); secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	client := *n.Client
+	client.Timeout = ep.timeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, errors.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return false, errors.Errorf("endpoint responded with %s", resp.Status)
+}
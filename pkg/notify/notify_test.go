@@ -0,0 +1,257 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type request struct {
+	header http.Header
+	body   string
+}
+
+// endpointServer records the requests it receives and responds with the
+// next status of statuses, or 200 once they are exhausted.
+type endpointServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []request
+	statuses []int
+}
+
+func newEndpointServer(t *testing.T, statuses ...int) *endpointServer {
+	s := &endpointServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, request{header: r.Header, body: string(b)})
+		if len(s.statuses) > 0 {
+			w.WriteHeader(s.statuses[0])
+			s.statuses = s.statuses[1:]
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func testEvent() *Event {
+	return &Event{
+		Type:      EventSucceeded,
+		Operation: "upgrade",
+		Release:   "hello",
+		Namespace: "prod",
+		Revision:  2,
+		Chart:     "hello",
+		Version:   "0.1.0",
+		Time:      time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC),
+	}
+}
+
+func newTestNotifier(t *testing.T, endpoints ...*Endpoint) *Notifier {
+	t.Helper()
+	n, err := New(&Config{Endpoints: endpoints})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Backoff = time.Millisecond
+	return n
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := LoadConfig(filepath.Join(dir, "missing.yaml"))
+	if err != nil || c != nil {
+		t.Errorf("expected no config for a missing file, got %v, %v", c, err)
+	}
+
+	path := filepath.Join(dir, "notifications.yaml")
+	data := `endpoints:
+- name: chat
+  url: https://hooks.example.com/chat
+  events: [succeeded, failed]
+  secretEnv: CHAT_SECRET
+  retries: 5
+  timeout: 3s
+`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Endpoints) != 1 {
+		t.Fatalf("expected 1 endpoint, got %d", len(c.Endpoints))
+	}
+	ep := c.Endpoints[0]
+	if ep.Name != "chat" || ep.SecretEnv != "CHAT_SECRET" || ep.Retries != 5 || len(ep.Events) != 2 {
+		t.Errorf("unexpected endpoint %+v", ep)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("endpoints:\n- name: chat\n  uri: typo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint *Endpoint
+		expect   string
+	}{
+		{"no name", &Endpoint{URL: "http://localhost"}, "has no name"},
+		{"no url", &Endpoint{Name: "chat"}, "has no url"},
+		{"unknown event", &Endpoint{Name: "chat", URL: "http://localhost", Events: []EventType{"deployed"}}, "unknown event"},
+		{"invalid template", &Endpoint{Name: "chat", URL: "http://localhost", Template: "{{ .Release"}, "invalid template"},
+		{"invalid timeout", &Endpoint{Name: "chat", URL: "http://localhost", Timeout: "soon"}, "invalid timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&Config{Endpoints: []*Endpoint{tt.endpoint}})
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Errorf("expected error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	srv := newEndpointServer(t)
+	n := newTestNotifier(t, &Endpoint{
+		Name:    "default",
+		URL:     srv.URL,
+		Secret:  "s3cr3t",
+		Headers: map[string]string{"X-Team": "platform"},
+	})
+
+	if err := n.Notify(testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(srv.requests))
+	}
+	req := srv.requests[0]
+
+	var got Event
+	if err := json.Unmarshal([]byte(req.body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != *testEvent() {
+		t.Errorf("expected %+v, got %+v", testEvent(), got)
+	}
+	if sig := req.header.Get(SignatureHeader); sig != Sign("s3cr3t", []byte(req.body)) {
+		t.Errorf("unexpected signature %q", sig)
+	}
+	if !strings.HasPrefix(req.header.Get(SignatureHeader), "sha256=") {
+		t.Errorf("expected a sha256 signature, got %q", req.header.Get(SignatureHeader))
+	}
+	if req.header.Get("X-Team") != "platform" || req.header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", req.header)
+	}
+}
+
+func TestNotifyTemplate(t *testing.T) {
+	os.Setenv("HELM_TEST_NOTIFY_SECRET", "from-env")
+	defer os.Unsetenv("HELM_TEST_NOTIFY_SECRET")
+
+	srv := newEndpointServer(t)
+	n := newTestNotifier(t, &Endpoint{
+		Name:      "chat",
+		URL:       srv.URL,
+		SecretEnv: "HELM_TEST_NOTIFY_SECRET",
+		Template:  `{"text": {{ printf "%s of %s %s (revision %d)" .Operation .Release .Type .Revision | toJson }}}`,
+	})
+
+	if err := n.Notify(testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	req := srv.requests[0]
+	if expect := `{"text": "upgrade of hello succeeded (revision 2)"}`; req.body != expect {
+		t.Errorf("expected %s, got %s", expect, req.body)
+	}
+	if sig := req.header.Get(SignatureHeader); sig != Sign("from-env", []byte(req.body)) {
+		t.Errorf("unexpected signature %q", sig)
+	}
+
+	bad := newTestNotifier(t, &Endpoint{Name: "chat", URL: srv.URL, Template: `text: {{ .Release }}`})
+	if err := bad.Notify(testEvent()); err == nil {
+		t.Error("expected an error for a payload that is not JSON")
+	}
+}
+
+func TestNotifyEvents(t *testing.T) {
+	srv := newEndpointServer(t)
+	n := newTestNotifier(t, &Endpoint{Name: "failures", URL: srv.URL, Events: []EventType{EventFailed}})
+
+	for _, typ := range []EventType{EventStarted, EventSucceeded, EventFailed, EventRolledBack} {
+		ev := testEvent()
+		ev.Type = typ
+		if err := n.Notify(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(srv.requests) != 1 || !strings.Contains(srv.requests[0].body, `"type":"failed"`) {
+		t.Errorf("expected only the failed event, got %v", srv.requests)
+	}
+}
+
+func TestNotifyRetries(t *testing.T) {
+	srv := newEndpointServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	n := newTestNotifier(t, &Endpoint{Name: "flaky", URL: srv.URL})
+	if err := n.Notify(testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.requests) != 3 {
+		t.Errorf("expected 3 attempts, got %d", len(srv.requests))
+	}
+
+	srv = newEndpointServer(t, 500, 500, 500)
+	n = newTestNotifier(t, &Endpoint{Name: "down", URL: srv.URL, Retries: 2})
+	if err := n.Notify(testEvent()); err == nil {
+		t.Error("expected an error once the retries are exhausted")
+	}
+	if len(srv.requests) != 3 {
+		t.Errorf("expected 3 attempts, got %d", len(srv.requests))
+	}
+
+	srv = newEndpointServer(t, http.StatusBadRequest)
+	n = newTestNotifier(t, &Endpoint{Name: "rejecting", URL: srv.URL})
+	if err := n.Notify(testEvent()); err == nil {
+		t.Error("expected an error for a rejected notification")
+	}
+	if len(srv.requests) != 1 {
+		t.Errorf("expected a rejected notification not to be retried, got %d attempts", len(srv.requests))
+	}
+}
@@ -43,6 +43,15 @@ type Downloaders struct {
 	Command string `json:"command"`
 }
 
+// StorageDriver represents the plugins capability if it can store
+// release records for the HELM_DRIVER=plugin:<name> storage driver
+type StorageDriver struct {
+	// Command is the executable path with which the plugin serves storage
+	// requests. It is invoked once per request, reading a JSON request on
+	// stdin and writing a JSON response to stdout.
+	Command string `json:"command"`
+}
+
 // PlatformCommand represents a command for a particular operating system and architecture
 type PlatformCommand struct {
 	OperatingSystem string `json:"os"`
@@ -98,6 +107,10 @@ type Metadata struct {
 	// for special protocols.
 	Downloaders []Downloaders `json:"downloaders"`
 
+	// StorageDriver field is used if the plugin supply a storage driver
+	// for release records.
+	StorageDriver *StorageDriver `json:"storageDriver,omitempty"`
+
 	// UseTunnelDeprecated indicates that this command needs a tunnel.
 	// Setting this will cause a number of side effects, such as the
 	// automatic setting of HELM_HOST.
@@ -269,6 +282,25 @@ func FindPlugins(plugdirs string) ([]*Plugin, error) {
 	return found, nil
 }
 
+// FindStorageDriver returns the plugin with the given name that supplies a
+// storage driver.
+func FindStorageDriver(plugdirs, name string) (*Plugin, error) {
+	plugins, err := FindPlugins(plugdirs)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plugins {
+		if p.Metadata.Name != name {
+			continue
+		}
+		if p.Metadata.StorageDriver == nil || p.Metadata.StorageDriver.Command == "" {
+			return nil, errors.Errorf("plugin %q does not supply a storage driver", name)
+		}
+		return p, nil
+	}
+	return nil, errors.Errorf("storage driver plugin %q not found", name)
+}
+
 // SetupPluginEnv prepares os.Env for plugins. It operates on os.Env because
 // the plugin subsystem itself needs access to the environment variables
 // created here.
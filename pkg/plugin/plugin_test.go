@@ -21,6 +21,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 
 	"helm.sh/helm/v3/pkg/cli"
@@ -376,3 +377,23 @@ func mockPlugin(name string) *Plugin {
 		Dir: "no-such-dir",
 	}
 }
+
+func TestFindStorageDriver(t *testing.T) {
+	plugdirs := strings.Join([]string{"testdata/plugdir/good", "testdata/plugdir/storage"}, string(os.PathListSeparator))
+
+	p, err := FindStorageDriver(plugdirs, "kvstore")
+	if err != nil {
+		t.Fatalf("error finding storage driver plugin: %s", err)
+	}
+	expect := &StorageDriver{Command: "$HELM_PLUGIN_DIR/kvstore serve"}
+	if !reflect.DeepEqual(expect, p.Metadata.StorageDriver) {
+		t.Errorf("Expected storage driver %v, got %v", expect, p.Metadata.StorageDriver)
+	}
+
+	if _, err := FindStorageDriver(plugdirs, "echo"); err == nil {
+		t.Error("expected error for plugin without a storage driver")
+	}
+	if _, err := FindStorageDriver(plugdirs, "missing"); err == nil {
+		t.Error("expected error for missing plugin")
+	}
+}
@@ -41,6 +41,7 @@ import (
 	"helm.sh/helm/v3/internal/version"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/logging"
 )
 
 // See https://github.com/helm/helm/issues/10166
@@ -53,7 +54,8 @@ a plus (+) when pulling from a registry.`
 type (
 	// Client works with OCI-compliant registries
 	Client struct {
-		debug bool
+		debug  bool
+		logger logging.Logger
 		// path to repository config file e.g. ~/.docker/config.json
 		credentialsFile    string
 		out                io.Writer
@@ -70,7 +72,8 @@ type (
 // NewClient returns a new registry client with config
 func NewClient(options ...ClientOption) (*Client, error) {
 	client := &Client{
-		out: ioutil.Discard,
+		out:    ioutil.Discard,
+		logger: logging.Nop(),
 	}
 	for _, option := range options {
 		option(client)
@@ -138,6 +141,14 @@ func ClientOptDebug(debug bool) ClientOption {
 	}
 }
 
+// ClientOptLogger returns a function that sets the logger receiving the
+// diagnostic output of the client
+func ClientOptLogger(logger logging.Logger) ClientOption {
+	return func(client *Client) {
+		client.logger = logger
+	}
+}
+
 // ClientOptWriter returns a function that sets the writer setting on client options set
 func ClientOptWriter(out io.Writer) ClientOption {
 	return func(client *Client) {
@@ -169,6 +180,7 @@ func (c *Client) Login(host string, options ...LoginOption) error {
 	for _, option := range options {
 		option(operation)
 	}
+	c.logger.Debug("logging in to registry", "host", host, "username", operation.username, "insecure", operation.insecure)
 	authorizerLoginOpts := []auth.LoginOption{
 		auth.WithLoginContext(ctx(c.out, c.debug)),
 		auth.WithLoginHostname(host),
@@ -214,6 +226,7 @@ func (c *Client) Logout(host string, opts ...LogoutOption) error {
 	for _, opt := range opts {
 		opt(operation)
 	}
+	c.logger.Debug("logging out of registry", "host", host)
 	if err := c.authorizer.Logout(ctx(c.out, c.debug), host); err != nil {
 		return err
 	}
@@ -287,6 +300,7 @@ func (c *Client) Pull(ref string, options ...PullOption) (*PullResult, error) {
 
 	var descriptors, layers []ocispec.Descriptor
 	registryStore := content.Registry{Resolver: c.resolver}
+	c.logger.Debug("pulling chart", "ref", parsedRef.String(), "chart", operation.withChart, "prov", operation.withProv)
 
 	manifest, err := oras.Copy(ctx(c.out, c.debug), registryStore, parsedRef.String(), memoryStore, "",
 		oras.WithPullEmptyNameAllowed(),
@@ -527,6 +541,7 @@ func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResu
 	}
 
 	registryStore := content.Registry{Resolver: c.resolver}
+	c.logger.Debug("pushing chart", "ref", parsedRef.String(), "digest", manifest.Digest.String())
 	_, err = oras.Copy(ctx(c.out, c.debug), memoryStore, parsedRef.String(), registryStore, "",
 		oras.WithNameValidation(nil))
 	if err != nil {
@@ -593,6 +608,7 @@ func (c *Client) Tags(ref string) ([]string, error) {
 	}
 
 	var registryTags []string
+	c.logger.Debug("listing tags", "repository", parsedReference.String())
 
 	for {
 		registryTags, err = registry.Tags(ctx(c.out, c.debug), &repository)
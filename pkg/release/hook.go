@@ -17,6 +17,8 @@ limitations under the License.
 package release
 
 import (
+	stdtime "time"
+
 	"helm.sh/helm/v3/pkg/time"
 )
 
@@ -34,6 +36,15 @@ const (
 	HookPreRollback  HookEvent = "pre-rollback"
 	HookPostRollback HookEvent = "post-rollback"
 	HookTest         HookEvent = "test"
+	HookPreTest      HookEvent = "pre-test"
+	HookPostTest     HookEvent = "post-test"
+	// HookInstallFailed and HookUpgradeFailed fire when an install or an
+	// upgrade fails, before an atomic operation is undone.
+	HookInstallFailed HookEvent = "install-failed"
+	HookUpgradeFailed HookEvent = "upgrade-failed"
+	// HookPostAtomicRollback fires once a failed atomic install has been
+	// uninstalled, or a failed atomic upgrade rolled back.
+	HookPostAtomicRollback HookEvent = "post-rollback-on-atomic"
 )
 
 func (x HookEvent) String() string { return string(x) }
@@ -59,6 +70,14 @@ const HookWeightAnnotation = "helm.sh/hook-weight"
 // HookDeleteAnnotation is the label name for the delete policy for a hook
 const HookDeleteAnnotation = "helm.sh/hook-delete-policy"
 
+// HookTimeoutAnnotation is the label name for the timeout of each attempt of
+// a hook, either a duration like "90s" or a number of seconds
+const HookTimeoutAnnotation = "helm.sh/hook-timeout"
+
+// HookRetriesAnnotation is the label name for the number of times a failed
+// hook is retried
+const HookRetriesAnnotation = "helm.sh/hook-retries"
+
 // Hook defines a hook object.
 type Hook struct {
 	Name string `json:"name,omitempty"`
@@ -76,6 +95,11 @@ type Hook struct {
 	Weight int `json:"weight,omitempty"`
 	// DeletePolicies are the policies that indicate when to delete the hook
 	DeletePolicies []HookDeletePolicy `json:"delete_policies,omitempty"`
+	// Timeout is how long each attempt of the hook may take. The timeout of
+	// the operation is used if it is zero.
+	Timeout stdtime.Duration `json:"timeout,omitempty"`
+	// Retries is the number of times the hook is run again after it fails
+	Retries int `json:"retries,omitempty"`
 }
 
 // A HookExecution records the result for the last execution of a hook for a given release.
@@ -86,6 +110,41 @@ type HookExecution struct {
 	CompletedAt time.Time `json:"completed_at,omitempty"`
 	// Phase indicates whether the hook completed successfully
 	Phase HookPhase `json:"phase"`
+	// Attempts records each attempt of the execution, oldest first.
+	Attempts []HookAttempt `json:"attempts,omitempty"`
+	// Logs are the tails of the logs of the containers of the hook, as
+	// they were when its last attempt completed.
+	Logs []HookLog `json:"logs,omitempty"`
+}
+
+// A HookLog is the tail of the log of a container of a hook, and how the
+// container terminated.
+type HookLog struct {
+	// Pod is the name of the pod.
+	Pod string `json:"pod"`
+	// Container is the name of the container.
+	Container string `json:"container"`
+	// Reason is why the container terminated, like Completed, Error or
+	// OOMKilled. It is empty if the container has not terminated.
+	Reason string `json:"reason,omitempty"`
+	// ExitCode is the exit code of the terminated container.
+	ExitCode int32 `json:"exit_code,omitempty"`
+	// Message is the termination message of the container.
+	Message string `json:"message,omitempty"`
+	// Log is the tail of the log of the container.
+	Log string `json:"log,omitempty"`
+}
+
+// A HookAttempt records the result of one attempt of a hook execution.
+type HookAttempt struct {
+	// StartedAt indicates the date/time this attempt was started
+	StartedAt time.Time `json:"started_at,omitempty"`
+	// CompletedAt indicates the date/time this attempt was completed.
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	// Phase indicates whether the attempt completed successfully
+	Phase HookPhase `json:"phase"`
+	// Error is the reason the attempt failed, if it did.
+	Error string `json:"error,omitempty"`
 }
 
 // A HookPhase indicates the state of a hook execution
@@ -33,4 +33,6 @@ type Info struct {
 	Status Status `json:"status,omitempty"`
 	// Contains the rendered templates/NOTES.txt if available
 	Notes string `json:"notes,omitempty"`
+	// Pinned protects this revision from being pruned from the release history.
+	Pinned bool `json:"pinned,omitempty"`
 }
@@ -22,6 +22,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"sigs.k8s.io/yaml"
@@ -53,15 +54,20 @@ type result struct {
 // TODO: Refactor this out. It's here because naming conventions were not followed through.
 // So fix the Test hook names and then remove this.
 var events = map[string]release.HookEvent{
-	release.HookPreInstall.String():   release.HookPreInstall,
-	release.HookPostInstall.String():  release.HookPostInstall,
-	release.HookPreDelete.String():    release.HookPreDelete,
-	release.HookPostDelete.String():   release.HookPostDelete,
-	release.HookPreUpgrade.String():   release.HookPreUpgrade,
-	release.HookPostUpgrade.String():  release.HookPostUpgrade,
-	release.HookPreRollback.String():  release.HookPreRollback,
-	release.HookPostRollback.String(): release.HookPostRollback,
-	release.HookTest.String():         release.HookTest,
+	release.HookPreInstall.String():         release.HookPreInstall,
+	release.HookPostInstall.String():        release.HookPostInstall,
+	release.HookPreDelete.String():          release.HookPreDelete,
+	release.HookPostDelete.String():         release.HookPostDelete,
+	release.HookPreUpgrade.String():         release.HookPreUpgrade,
+	release.HookPostUpgrade.String():        release.HookPostUpgrade,
+	release.HookPreRollback.String():        release.HookPreRollback,
+	release.HookPostRollback.String():       release.HookPostRollback,
+	release.HookTest.String():               release.HookTest,
+	release.HookPreTest.String():            release.HookPreTest,
+	release.HookPostTest.String():           release.HookPostTest,
+	release.HookInstallFailed.String():      release.HookInstallFailed,
+	release.HookUpgradeFailed.String():      release.HookUpgradeFailed,
+	release.HookPostAtomicRollback.String(): release.HookPostAtomicRollback,
 	// Support test-success for backward compatibility with Helm 2 tests
 	"test-success": release.HookTest,
 }
@@ -175,6 +181,8 @@ func (file *manifestFile) sort(result *result) error {
 			Events:         []release.HookEvent{},
 			Weight:         hw,
 			DeletePolicies: []release.HookDeletePolicy{},
+			Timeout:        calculateHookTimeout(entry),
+			Retries:        calculateHookRetries(entry),
 		}
 
 		isUnknownHook := false
@@ -222,6 +230,33 @@ func calculateHookWeight(entry SimpleHead) int {
 	return hw
 }
 
+// calculateHookTimeout finds the timeout in the hook timeout annotation,
+// either a duration or a number of seconds.
+//
+// If no valid timeout is found, the timeout is 0
+func calculateHookTimeout(entry SimpleHead) time.Duration {
+	hts := strings.TrimSpace(entry.Metadata.Annotations[release.HookTimeoutAnnotation])
+	if ht, err := time.ParseDuration(hts); err == nil && ht > 0 {
+		return ht
+	}
+	if secs, err := strconv.Atoi(hts); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
+
+// calculateHookRetries finds the number of retries in the hook retries
+// annotation.
+//
+// If no valid number is found, the hook is not retried
+func calculateHookRetries(entry SimpleHead) int {
+	hr, err := strconv.Atoi(strings.TrimSpace(entry.Metadata.Annotations[release.HookRetriesAnnotation]))
+	if err != nil || hr < 0 {
+		return 0
+	}
+	return hr
+}
+
 // operateAnnotationValues finds the given annotation and runs the operate function with the value of that annotation
 func operateAnnotationValues(entry SimpleHead, annotation string, operate func(p string)) {
 	if dps, ok := entry.Metadata.Annotations[annotation]; ok {
@@ -19,6 +19,7 @@ package releaseutil
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"sigs.k8s.io/yaml"
 
@@ -226,3 +227,57 @@ metadata:
 		}
 	}
 }
+
+func TestSortManifestsHookTimeoutAndRetries(t *testing.T) {
+	manifests := map[string]string{
+		"templates/job.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  annotations:
+    "helm.sh/hook": pre-upgrade
+    "helm.sh/hook-timeout": 90s
+    "helm.sh/hook-retries": "3"
+`,
+		"templates/pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: check
+  annotations:
+    "helm.sh/hook": pre-upgrade
+    "helm.sh/hook-timeout": "120"
+    "helm.sh/hook-retries": "-1"
+`,
+		"templates/cm.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  annotations:
+    "helm.sh/hook": pre-upgrade
+    "helm.sh/hook-timeout": soon
+`,
+	}
+
+	hs, _, err := SortManifests(manifests, chartutil.VersionSet{"v1", "batch/v1"}, InstallOrder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expect := map[string]struct {
+		timeout time.Duration
+		retries int
+	}{
+		"migrate": {90 * time.Second, 3},
+		"check":   {120 * time.Second, 0},
+		"config":  {0, 0},
+	}
+	for _, h := range hs {
+		e := expect[h.Name]
+		if h.Timeout != e.timeout {
+			t.Errorf("%s: expected timeout %s, got %s", h.Name, e.timeout, h.Timeout)
+		}
+		if h.Retries != e.retries {
+			t.Errorf("%s: expected %d retries, got %d", h.Name, e.retries, h.Retries)
+		}
+	}
+}
@@ -27,13 +27,17 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kblabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/watch"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	rspb "helm.sh/helm/v3/pkg/release"
 )
 
 var _ Driver = (*ConfigMaps)(nil)
+var _ Pager = (*ConfigMaps)(nil)
+var _ Watcher = (*ConfigMaps)(nil)
 
 // ConfigMapsDriverName is the string name of the driver.
 const ConfigMapsDriverName = "ConfigMap"
@@ -115,6 +119,53 @@ func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Releas
 	return results, nil
 }
 
+// ListPage fetches the page of releases matching opts. The selector is
+// evaluated by the API server and only the releases of the returned page
+// are decoded. The API server pages the releases too if opts.AnyOrder is set
+// and the selector is the only restriction.
+func (cfgmaps *ConfigMaps) ListPage(opts ListOptions) (*ListResult, error) {
+	return kubeListPage(opts, func(lo metav1.ListOptions) ([]*listRecord, string, error) {
+		list, err := cfgmaps.impl.List(context.Background(), lo)
+		if err != nil {
+			return nil, "", err
+		}
+		recs := make([]*listRecord, 0, len(list.Items))
+		for _, item := range list.Items {
+			recs = append(recs, &listRecord{
+				key:       item.Name,
+				namespace: item.Namespace,
+				labels:    item.Labels,
+				body:      item.Data["release"],
+			})
+		}
+		return recs, list.Continue, nil
+	}, cfgmaps.Log)
+}
+
+// Watch calls handle for every release created, updated or deleted after
+// Watch was called, until ctx is done or handle returns an error.
+func (cfgmaps *ConfigMaps) Watch(ctx context.Context, handle func(Event) error) error {
+	lsel := kblabels.Set{"owner": "helm"}.AsSelector()
+	list := func() (string, error) {
+		res, err := cfgmaps.impl.List(ctx, metav1.ListOptions{LabelSelector: lsel.String()})
+		if err != nil {
+			return "", err
+		}
+		return res.ResourceVersion, nil
+	}
+	start := func(rv string) (watch.Interface, error) {
+		return cfgmaps.impl.Watch(ctx, metav1.ListOptions{LabelSelector: lsel.String(), ResourceVersion: rv})
+	}
+	decode := func(obj runtime.Object) (*rspb.Release, error) {
+		item, ok := obj.(*v1.ConfigMap)
+		if !ok {
+			return nil, errors.Errorf("unexpected object %T", obj)
+		}
+		return decodeRelease(item.Data["release"])
+	}
+	return watchReleases(ctx, list, start, decode, cfgmaps.Log, handle)
+}
+
 // Query fetches all releases that match the provided map of labels.
 // An error is returned if the configmap fails to retrieve the releases.
 func (cfgmaps *ConfigMaps) Query(labels map[string]string) ([]*rspb.Release, error) {
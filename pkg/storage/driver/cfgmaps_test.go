@@ -14,12 +14,16 @@ limitations under the License.
 package driver
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	kblabels "k8s.io/apimachinery/pkg/labels"
 
 	rspb "helm.sh/helm/v3/pkg/release"
 )
@@ -130,6 +134,151 @@ func TestConfigMapList(t *testing.T) {
 	}
 }
 
+func TestConfigMapListPage(t *testing.T) {
+	cfgmaps := newTestFixtureCfgMaps(t, []*rspb.Release{
+		releaseStub("key-1", 1, "default", rspb.StatusSuperseded),
+		releaseStub("key-1", 2, "default", rspb.StatusDeployed),
+		releaseStub("key-2", 1, "default", rspb.StatusFailed),
+		releaseStub("key-3", 1, "default", rspb.StatusDeployed),
+	}...)
+
+	// list the superseded releases, selected by the API server
+	res, err := cfgmaps.ListPage(ListOptions{Selector: kblabels.SelectorFromSet(kblabels.Set{"status": "superseded"})})
+	if err != nil {
+		t.Fatalf("Failed to list superseded: %s", err)
+	}
+	if len(res.Releases) != 1 || res.Releases[0].Version != 1 {
+		t.Errorf("Expected key-1.v1, got %v", res.Releases)
+	}
+	if res.Releases[0].Labels["status"] != "superseded" {
+		t.Errorf("Expected release labels to be set, got %v", res.Releases[0].Labels)
+	}
+
+	// page through the latest deployed releases
+	opts := ListOptions{
+		Latest: true,
+		Filter: kblabels.SelectorFromSet(kblabels.Set{"status": "deployed"}),
+		Limit:  1,
+	}
+	res, err = cfgmaps.ListPage(opts)
+	if err != nil {
+		t.Fatalf("Failed to list first page: %s", err)
+	}
+	if len(res.Releases) != 1 || res.Releases[0].Name != "key-1" || res.Releases[0].Version != 2 {
+		t.Errorf("Expected key-1.v2 on the first page, got %v", res.Releases)
+	}
+	if res.Continue == "" {
+		t.Fatal("Expected a continue token")
+	}
+
+	opts.Continue = res.Continue
+	res, err = cfgmaps.ListPage(opts)
+	if err != nil {
+		t.Fatalf("Failed to list second page: %s", err)
+	}
+	if len(res.Releases) != 1 || res.Releases[0].Name != "key-3" {
+		t.Errorf("Expected key-3.v1 on the second page, got %v", res.Releases)
+	}
+	if res.Continue != "" {
+		t.Errorf("Expected no continue token, got %q", res.Continue)
+	}
+}
+
+func TestConfigMapListPageServerPaged(t *testing.T) {
+	cfgmaps := newTestFixtureCfgMaps(t, []*rspb.Release{
+		releaseStub("key-1", 1, "default", rspb.StatusSuperseded),
+		releaseStub("key-1", 2, "default", rspb.StatusDeployed),
+		releaseStub("key-2", 1, "default", rspb.StatusSuperseded),
+		releaseStub("key-3", 1, "default", rspb.StatusSuperseded),
+	}...)
+
+	// page through the superseded releases, paged by the API server
+	opts := ListOptions{
+		Selector: kblabels.SelectorFromSet(kblabels.Set{"status": "superseded"}),
+		Limit:    2,
+		AnyOrder: true,
+	}
+	res, err := cfgmaps.ListPage(opts)
+	if err != nil {
+		t.Fatalf("Failed to list first page: %s", err)
+	}
+	if len(res.Releases) != 2 || res.Releases[0].Name != "key-1" || res.Releases[1].Name != "key-2" {
+		t.Errorf("Expected key-1.v1 and key-2.v1 on the first page, got %v", res.Releases)
+	}
+	if res.Continue != "after:key-2.v1" {
+		t.Fatalf("Expected the continue token of the API server, got %q", res.Continue)
+	}
+
+	opts.Continue = res.Continue
+	res, err = cfgmaps.ListPage(opts)
+	if err != nil {
+		t.Fatalf("Failed to list second page: %s", err)
+	}
+	if len(res.Releases) != 1 || res.Releases[0].Name != "key-3" {
+		t.Errorf("Expected key-3.v1 on the second page, got %v", res.Releases)
+	}
+	if res.Continue != "" {
+		t.Errorf("Expected no continue token, got %q", res.Continue)
+	}
+
+	// tokens rejected by the API server are invalid
+	opts.Continue = "not a token"
+	if _, err := cfgmaps.ListPage(opts); !errors.Is(err, ErrInvalidContinue) {
+		t.Errorf("Expected ErrInvalidContinue, got %v", err)
+	}
+}
+
+func TestConfigMapWatch(t *testing.T) {
+	var mock MockConfigMapsInterface
+	mock.Init(t)
+	cfgmaps := NewConfigMaps(&mock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 3)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- cfgmaps.Watch(ctx, func(ev Event) error {
+			events <- ev
+			return nil
+		})
+	}()
+	<-mock.watching
+
+	key := testKey("smug-pigeon", 1)
+	rel := releaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed)
+	if err := cfgmaps.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create release: %s", err)
+	}
+	rel.Info.Status = rspb.StatusSuperseded
+	if err := cfgmaps.Update(key, rel); err != nil {
+		t.Fatalf("Failed to update release: %s", err)
+	}
+	if _, err := cfgmaps.Delete(key); err != nil {
+		t.Fatalf("Failed to delete release: %s", err)
+	}
+
+	for _, expect := range []EventType{EventCreated, EventUpdated, EventDeleted} {
+		select {
+		case ev := <-events:
+			if ev.Type != expect || ev.Key != key || ev.Release.Name != "smug-pigeon" {
+				t.Errorf("Expected %s event for %s, got %s event for %s", expect, key, ev.Type, ev.Key)
+			}
+			if expect != EventCreated && ev.Release.Info.Status != rspb.StatusSuperseded {
+				t.Errorf("Expected %s event for a superseded release, got %s", expect, ev.Release.Info.Status)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s event", expect)
+		}
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("Expected watch to end without error, got %s", err)
+	}
+}
+
 func TestConfigMapQuery(t *testing.T) {
 	cfgmaps := newTestFixtureCfgMaps(t, []*rspb.Release{
 		releaseStub("key-1", 1, "default", rspb.StatusUninstalled),
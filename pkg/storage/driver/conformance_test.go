@@ -0,0 +1,77 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver_test
+
+import (
+	"os"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"helm.sh/helm/v3/pkg/storage/driver/drivertest"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	drivertest.Run(t, func(t *testing.T) driver.Driver {
+		return driver.NewMemory()
+	})
+}
+
+// TestListConformance runs the suite on a driver that does not implement
+// driver.Pager, so that its releases are paged over List.
+func TestListConformance(t *testing.T) {
+	drivertest.Run(t, func(t *testing.T) driver.Driver {
+		return struct{ driver.Driver }{driver.NewMemory()}
+	})
+}
+
+func TestFileConformance(t *testing.T) {
+	drivertest.Run(t, func(t *testing.T) driver.Driver {
+		d, err := driver.NewFile(t.TempDir(), nil, drivertest.Namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	})
+}
+
+func TestPluginConformance(t *testing.T) {
+	drivertest.Run(t, func(t *testing.T) driver.Driver {
+		// The test binary itself acts as the plugin, see TestStoragePluginProcess.
+		env := append(os.Environ(),
+			"HELM_TEST_STORAGE_PLUGIN=1",
+			"HELM_TEST_STORAGE_PLUGIN_DIR="+t.TempDir(),
+		)
+		return driver.NewPlugin(os.Args[0], []string{"-test.run=^TestStoragePluginProcess$"}, env, drivertest.Namespace)
+	})
+}
+
+// TestStoragePluginProcess is not a real test. It serves a single storage
+// plugin request backed by a file driver when invoked by TestPluginConformance.
+func TestStoragePluginProcess(t *testing.T) {
+	if os.Getenv("HELM_TEST_STORAGE_PLUGIN") != "1" {
+		return
+	}
+	dir := os.Getenv("HELM_TEST_STORAGE_PLUGIN_DIR")
+	err := driver.ServePlugin(func(namespace string) (driver.Driver, error) {
+		return driver.NewFile(dir, nil, namespace)
+	}, os.Stdin, os.Stdout)
+	if err != nil {
+		os.Stderr.WriteString(err.Error())
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -18,21 +18,26 @@ package driver // import "helm.sh/helm/v3/pkg/storage/driver"
 
 import (
 	"fmt"
+	"regexp"
 
 	"github.com/pkg/errors"
+	kblabels "k8s.io/apimachinery/pkg/labels"
 
+	"helm.sh/helm/v3/pkg/errdefs"
 	rspb "helm.sh/helm/v3/pkg/release"
 )
 
 var (
 	// ErrReleaseNotFound indicates that a release is not found.
-	ErrReleaseNotFound = errors.New("release: not found")
+	ErrReleaseNotFound error = errdefs.New(errdefs.TypeReleaseNotFound, "release: not found")
 	// ErrReleaseExists indicates that a release already exists.
-	ErrReleaseExists = errors.New("release: already exists")
+	ErrReleaseExists error = errdefs.New(errdefs.TypeResourceConflict, "release: already exists")
 	// ErrInvalidKey indicates that a release key could not be parsed.
 	ErrInvalidKey = errors.New("release: invalid key")
 	// ErrNoDeployedReleases indicates that there are no releases with the given key in the deployed state
-	ErrNoDeployedReleases = errors.New("has no deployed releases")
+	ErrNoDeployedReleases error = errdefs.New(errdefs.TypeReleaseNotFound, "has no deployed releases")
+	// ErrInvalidContinue indicates that a continue token could not be parsed or has expired.
+	ErrInvalidContinue = errors.New("release: invalid continue token")
 )
 
 // StorageDriverError records an error and the release name that caused it
@@ -48,10 +53,10 @@ func (e *StorageDriverError) Error() string {
 func (e *StorageDriverError) Unwrap() error { return e.Err }
 
 func NewErrNoDeployedReleases(releaseName string) error {
-	return &StorageDriverError{
+	return errdefs.Wrap(errdefs.TypeReleaseNotFound, &StorageDriverError{
 		ReleaseName: releaseName,
 		Err:         ErrNoDeployedReleases,
-	}
+	}, "release", releaseName)
 }
 
 // Creator is the interface that wraps the Create method.
@@ -78,6 +83,46 @@ type Deletor interface {
 	Delete(key string) (*rspb.Release, error)
 }
 
+// ListOptions restricts and paginates the releases returned by ListPage.
+//
+// Selector and Name are evaluated first. Latest then keeps the most recent
+// revision of each remaining release, and Filter is applied last. All of
+// them are evaluated on the record labels ("name", "owner", "status",
+// "version" and any driver specific labels), so releases that are filtered
+// out are never decoded.
+type ListOptions struct {
+	// Selector restricts the records to those whose labels match. Drivers
+	// evaluate it server-side where possible.
+	Selector kblabels.Selector
+	// Name restricts the records to releases whose name matches.
+	Name *regexp.Regexp
+	// Latest keeps only the most recent revision of each release.
+	Latest bool
+	// Filter restricts the records remaining after Latest to those whose
+	// labels match.
+	Filter kblabels.Selector
+	// Limit is the maximum number of releases to return. Zero means no limit.
+	Limit int
+	// Continue is the token returned by a previous call to ListPage. The
+	// listing resumes after the last release returned by that call.
+	Continue string
+	// AnyOrder lets drivers return the releases in the order they store
+	// them. The Secrets and ConfigMaps drivers then let the API server page
+	// the releases if Selector is the only restriction, instead of listing
+	// all of them on every call.
+	AnyOrder bool
+}
+
+// ListResult is a page of releases returned by ListPage.
+type ListResult struct {
+	// Releases are ordered by name, namespace and version, only within
+	// the page if ListOptions.AnyOrder is set.
+	Releases []*rspb.Release
+	// Continue is set if more releases are available. It is passed in
+	// ListOptions.Continue to fetch the next page.
+	Continue string
+}
+
 // Queryor is the interface that wraps the Get and List methods.
 //
 // Get returns the release named by key or returns ErrReleaseNotFound
@@ -92,6 +137,27 @@ type Queryor interface {
 	Query(labels map[string]string) ([]*rspb.Release, error)
 }
 
+// Pager is the interface that wraps the ListPage method.
+//
+// ListPage returns a page of the releases that match the list options, or
+// ErrInvalidContinue if the continue token cannot be parsed or has expired.
+//
+// Drivers implement it when they can select and page releases without
+// decoding all of them. The releases of other drivers are paged by ListPage.
+type Pager interface {
+	ListPage(opts ListOptions) (*ListResult, error)
+}
+
+// NamespaceSetter is the interface implemented by drivers that store the
+// releases of every namespace in one backend.
+//
+// SetNamespace selects the namespace releases are read from and deleted in.
+// An empty namespace selects all namespaces when listing. Releases are
+// stored in their own namespace, which also becomes the selected one.
+type NamespaceSetter interface {
+	SetNamespace(namespace string)
+}
+
 // Driver is the interface composed of Creator, Updator, Deletor, and Queryor
 // interfaces. It defines the behavior for storing, updating, deleted,
 // and retrieving Helm releases from some underlying storage mechanism,
@@ -0,0 +1,341 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*Package drivertest provides a conformance test suite for storage drivers.
+
+Implementations of driver.Driver, including storage driver plugins served
+through driver.ServePlugin, can run the suite from their own tests:
+
+	func TestConformance(t *testing.T) {
+		drivertest.Run(t, func(t *testing.T) driver.Driver {
+			return NewMyDriver()
+		})
+	}
+*/
+package drivertest // import "helm.sh/helm/v3/pkg/storage/driver/drivertest"
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+	kblabels "k8s.io/apimachinery/pkg/labels"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// Namespace is the namespace the releases used by the suite are stored in.
+const Namespace = "default"
+
+// Run runs the conformance test suite. newDriver must return an empty
+// driver for each sub-test.
+func Run(t *testing.T, newDriver func(t *testing.T) driver.Driver) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, driver.Driver)
+	}{
+		{"Name", testName},
+		{"CreateAndGet", testCreateAndGet},
+		{"CreateExisting", testCreateExisting},
+		{"GetMissing", testGetMissing},
+		{"Update", testUpdate},
+		{"UpdateMissing", testUpdateMissing},
+		{"Delete", testDelete},
+		{"DeleteMissing", testDeleteMissing},
+		{"List", testList},
+		{"ListPage", testListPage},
+		{"ListPagination", testListPagination},
+		{"ListPageInvalidContinue", testListPageInvalidContinue},
+		{"Query", testQuery},
+		{"QueryMissing", testQueryMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newDriver(t))
+		})
+	}
+}
+
+func releaseStub(name string, version int, status rspb.Status) *rspb.Release {
+	return &rspb.Release{
+		Name:      name,
+		Version:   version,
+		Namespace: Namespace,
+		Info:      &rspb.Info{Status: status},
+	}
+}
+
+func key(name string, version int) string {
+	return fmt.Sprintf("sh.helm.release.v1.%s.v%d", name, version)
+}
+
+func create(t *testing.T, d driver.Driver, releases ...*rspb.Release) {
+	t.Helper()
+	for _, rls := range releases {
+		if err := d.Create(key(rls.Name, rls.Version), rls); err != nil {
+			t.Fatalf("failed to create %s: %s", key(rls.Name, rls.Version), err)
+		}
+	}
+}
+
+// ordered returns the versions of releases in the order they were returned.
+func ordered(releases []*rspb.Release) []string {
+	var vs []string
+	for _, rls := range releases {
+		vs = append(vs, fmt.Sprintf("%s.v%d", rls.Name, rls.Version))
+	}
+	return vs
+}
+
+func selector(t *testing.T, s string) kblabels.Selector {
+	t.Helper()
+	sel, err := kblabels.Parse(s)
+	if err != nil {
+		t.Fatalf("failed to parse selector %q: %s", s, err)
+	}
+	return sel
+}
+
+func versions(releases []*rspb.Release) []string {
+	var vs []string
+	for _, rls := range releases {
+		vs = append(vs, fmt.Sprintf("%s.v%d", rls.Name, rls.Version))
+	}
+	sort.Strings(vs)
+	return vs
+}
+
+func testName(t *testing.T, d driver.Driver) {
+	if d.Name() == "" {
+		t.Error("expected driver to have a name")
+	}
+}
+
+func testCreateAndGet(t *testing.T, d driver.Driver) {
+	rls := releaseStub("smug-pigeon", 1, rspb.StatusDeployed)
+	create(t, d, rls)
+
+	got, err := d.Get(key(rls.Name, rls.Version))
+	if err != nil {
+		t.Fatalf("failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(rls, got) {
+		t.Errorf("expected %v, got %v", rls, got)
+	}
+}
+
+func testCreateExisting(t *testing.T, d driver.Driver) {
+	rls := releaseStub("smug-pigeon", 1, rspb.StatusDeployed)
+	create(t, d, rls)
+
+	if err := d.Create(key(rls.Name, rls.Version), rls); !errors.Is(err, driver.ErrReleaseExists) {
+		t.Errorf("expected %q, got %v", driver.ErrReleaseExists, err)
+	}
+}
+
+func testGetMissing(t *testing.T, d driver.Driver) {
+	if _, err := d.Get(key("smug-pigeon", 1)); !errors.Is(err, driver.ErrReleaseNotFound) {
+		t.Errorf("expected %q, got %v", driver.ErrReleaseNotFound, err)
+	}
+}
+
+func testUpdate(t *testing.T, d driver.Driver) {
+	rls := releaseStub("smug-pigeon", 1, rspb.StatusDeployed)
+	create(t, d, rls)
+
+	rls = releaseStub("smug-pigeon", 1, rspb.StatusSuperseded)
+	if err := d.Update(key(rls.Name, rls.Version), rls); err != nil {
+		t.Fatalf("failed to update release: %s", err)
+	}
+
+	got, err := d.Get(key(rls.Name, rls.Version))
+	if err != nil {
+		t.Fatalf("failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(rls, got) {
+		t.Errorf("expected %v, got %v", rls, got)
+	}
+
+	if _, err := d.Query(map[string]string{"name": rls.Name, "status": "deployed"}); !errors.Is(err, driver.ErrReleaseNotFound) {
+		t.Errorf("expected query by the old status to fail with %q, got %v", driver.ErrReleaseNotFound, err)
+	}
+}
+
+func testUpdateMissing(t *testing.T, d driver.Driver) {
+	rls := releaseStub("smug-pigeon", 1, rspb.StatusDeployed)
+	if err := d.Update(key(rls.Name, rls.Version), rls); !errors.Is(err, driver.ErrReleaseNotFound) {
+		t.Errorf("expected %q, got %v", driver.ErrReleaseNotFound, err)
+	}
+}
+
+func testDelete(t *testing.T, d driver.Driver) {
+	rls := releaseStub("smug-pigeon", 1, rspb.StatusDeployed)
+	create(t, d, rls)
+
+	got, err := d.Delete(key(rls.Name, rls.Version))
+	if err != nil {
+		t.Fatalf("failed to delete release: %s", err)
+	}
+	if !reflect.DeepEqual(rls, got) {
+		t.Errorf("expected deleted release %v, got %v", rls, got)
+	}
+	if _, err := d.Get(key(rls.Name, rls.Version)); !errors.Is(err, driver.ErrReleaseNotFound) {
+		t.Errorf("expected %q after delete, got %v", driver.ErrReleaseNotFound, err)
+	}
+}
+
+func testDeleteMissing(t *testing.T, d driver.Driver) {
+	if _, err := d.Delete(key("smug-pigeon", 1)); !errors.Is(err, driver.ErrReleaseNotFound) {
+		t.Errorf("expected %q, got %v", driver.ErrReleaseNotFound, err)
+	}
+}
+
+func testList(t *testing.T, d driver.Driver) {
+	create(t, d,
+		releaseStub("rls-a", 1, rspb.StatusSuperseded),
+		releaseStub("rls-a", 2, rspb.StatusDeployed),
+		releaseStub("rls-b", 1, rspb.StatusUninstalled),
+	)
+
+	all, err := d.List(func(_ *rspb.Release) bool { return true })
+	if err != nil {
+		t.Fatalf("failed to list releases: %s", err)
+	}
+	if got, expect := versions(all), []string{"rls-a.v1", "rls-a.v2", "rls-b.v1"}; !reflect.DeepEqual(expect, got) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+
+	deployed, err := d.List(func(rls *rspb.Release) bool { return rls.Info.Status == rspb.StatusDeployed })
+	if err != nil {
+		t.Fatalf("failed to list releases: %s", err)
+	}
+	if got, expect := versions(deployed), []string{"rls-a.v2"}; !reflect.DeepEqual(expect, got) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+}
+
+func testListPage(t *testing.T, d driver.Driver) {
+	create(t, d,
+		releaseStub("rls-a", 1, rspb.StatusSuperseded),
+		releaseStub("rls-a", 2, rspb.StatusDeployed),
+		releaseStub("rls-b", 1, rspb.StatusDeployed),
+		releaseStub("rls-b", 2, rspb.StatusFailed),
+		releaseStub("other", 1, rspb.StatusDeployed),
+	)
+
+	tests := []struct {
+		name   string
+		opts   driver.ListOptions
+		expect []string
+	}{
+		{"all", driver.ListOptions{}, []string{"other.v1", "rls-a.v1", "rls-a.v2", "rls-b.v1", "rls-b.v2"}},
+		{"selector", driver.ListOptions{Selector: selector(t, "status=deployed")}, []string{"other.v1", "rls-a.v2", "rls-b.v1"}},
+		{"name", driver.ListOptions{Name: regexp.MustCompile("^rls-")}, []string{"rls-a.v1", "rls-a.v2", "rls-b.v1", "rls-b.v2"}},
+		{"latest", driver.ListOptions{Latest: true}, []string{"other.v1", "rls-a.v2", "rls-b.v2"}},
+		{"latest with filter", driver.ListOptions{Latest: true, Filter: selector(t, "status=deployed")}, []string{"other.v1", "rls-a.v2"}},
+		{"latest of selected", driver.ListOptions{Latest: true, Selector: selector(t, "status=deployed")}, []string{"other.v1", "rls-a.v2", "rls-b.v1"}},
+	}
+	for _, tt := range tests {
+		res, err := driver.ListPage(d, tt.opts)
+		if err != nil {
+			t.Errorf("%s: failed to list releases: %s", tt.name, err)
+			continue
+		}
+		if got := ordered(res.Releases); !reflect.DeepEqual(tt.expect, got) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expect, got)
+		}
+		if res.Continue != "" {
+			t.Errorf("%s: expected no continue token, got %q", tt.name, res.Continue)
+		}
+	}
+}
+
+func testListPagination(t *testing.T, d driver.Driver) {
+	create(t, d,
+		releaseStub("rls-a", 1, rspb.StatusSuperseded),
+		releaseStub("rls-a", 2, rspb.StatusDeployed),
+		releaseStub("rls-b", 1, rspb.StatusDeployed),
+		releaseStub("rls-c", 1, rspb.StatusDeployed),
+		releaseStub("rls-d", 1, rspb.StatusDeployed),
+	)
+
+	var pages [][]string
+	opts := driver.ListOptions{Latest: true, Limit: 2}
+	for {
+		res, err := driver.ListPage(d, opts)
+		if err != nil {
+			t.Fatalf("failed to list releases: %s", err)
+		}
+		pages = append(pages, ordered(res.Releases))
+		if res.Continue == "" {
+			break
+		}
+		opts.Continue = res.Continue
+	}
+
+	expect := [][]string{{"rls-a.v2", "rls-b.v1"}, {"rls-c.v1", "rls-d.v1"}}
+	if !reflect.DeepEqual(expect, pages) {
+		t.Errorf("expected pages %v, got %v", expect, pages)
+	}
+}
+
+func testListPageInvalidContinue(t *testing.T, d driver.Driver) {
+	create(t, d, releaseStub("rls-a", 1, rspb.StatusDeployed))
+
+	if _, err := driver.ListPage(d, driver.ListOptions{Continue: "not a token"}); !errors.Is(err, driver.ErrInvalidContinue) {
+		t.Errorf("expected %q, got %v", driver.ErrInvalidContinue, err)
+	}
+}
+
+func testQuery(t *testing.T, d driver.Driver) {
+	create(t, d,
+		releaseStub("rls-a", 1, rspb.StatusSuperseded),
+		releaseStub("rls-a", 2, rspb.StatusDeployed),
+		releaseStub("rls-b", 1, rspb.StatusDeployed),
+	)
+
+	tests := []struct {
+		labels map[string]string
+		expect []string
+	}{
+		{map[string]string{"name": "rls-a", "owner": "helm"}, []string{"rls-a.v1", "rls-a.v2"}},
+		{map[string]string{"status": "deployed"}, []string{"rls-a.v2", "rls-b.v1"}},
+		{map[string]string{"name": "rls-a", "status": "deployed"}, []string{"rls-a.v2"}},
+		{map[string]string{"name": "rls-b", "version": "1"}, []string{"rls-b.v1"}},
+	}
+	for _, tt := range tests {
+		got, err := d.Query(tt.labels)
+		if err != nil {
+			t.Errorf("failed to query %v: %s", tt.labels, err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.expect, versions(got)) {
+			t.Errorf("query %v: expected %v, got %v", tt.labels, tt.expect, versions(got))
+		}
+	}
+}
+
+func testQueryMissing(t *testing.T, d driver.Driver) {
+	create(t, d, releaseStub("rls-a", 1, rspb.StatusDeployed))
+
+	if _, err := d.Query(map[string]string{"name": "rls-b", "owner": "helm"}); !errors.Is(err, driver.ErrReleaseNotFound) {
+		t.Errorf("expected %q, got %v", driver.ErrReleaseNotFound, err)
+	}
+}
@@ -0,0 +1,521 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver // import "helm.sh/helm/v3/pkg/storage/driver"
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/pkg/errors"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+)
+
+var _ Driver = (*File)(nil)
+var _ Pager = (*File)(nil)
+var _ NamespaceSetter = (*File)(nil)
+
+// FileDriverName is the string name of this driver.
+const FileDriverName = "File"
+
+const (
+	// fileLockName is the name of the lock file guarding the storage directory.
+	fileLockName = ".lock"
+	// fileRecordExt is the extension of the files holding release records.
+	fileRecordExt = ".json"
+	// fileLockTimeout is how long to wait for another helm process to release the lock.
+	fileLockTimeout = 30 * time.Second
+)
+
+// fileRecord is the on-disk representation of a single release revision.
+//
+// The labels are stored next to the encoded release so that queries can be
+// answered without decoding every release body.
+type fileRecord struct {
+	Type    string            `json:"type"`
+	Labels  map[string]string `json:"labels"`
+	Release string            `json:"release"`
+
+	// namespace and key locate the record, they are set when it is read.
+	namespace string
+	key       string
+}
+
+// File is the local filesystem storage driver implementation.
+//
+// Each release revision is stored as a file named after its key, inside a
+// directory per namespace:
+//
+//	<root>/<namespace>/<key>.json
+//
+// Access to the directory is serialized with a file lock so that several
+// helm processes can safely share the same storage directory.
+type File struct {
+	root      string
+	namespace string
+
+	// mu serializes access from within this process, lock from other processes.
+	mu   sync.Mutex
+	lock *flock.Flock
+
+	Log func(string, ...interface{})
+}
+
+// NewFile initializes a new file driver storing releases below root.
+func NewFile(root string, logger func(string, ...interface{}), namespace string) (*File, error) {
+	if root == "" {
+		return nil, errors.New("file driver: no storage directory provided")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, errors.Wrapf(err, "file driver: failed to create storage directory %q", root)
+	}
+	if logger == nil {
+		logger = func(_ string, _ ...interface{}) {}
+	}
+	return &File{
+		root:      root,
+		namespace: namespace,
+		lock:      flock.New(filepath.Join(root, fileLockName)),
+		Log:       logger,
+	}, nil
+}
+
+// SetNamespace sets a specific namespace in which releases will be accessed.
+// An empty string indicates all namespaces (for the list operation)
+func (f *File) SetNamespace(ns string) {
+	f.namespace = ns
+}
+
+// Name returns the name of the driver.
+func (f *File) Name() string {
+	return FileDriverName
+}
+
+// Get returns the release named by key or returns ErrReleaseNotFound.
+func (f *File) Get(key string) (*rspb.Release, error) {
+	path, err := f.path(f.namespace, key)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := f.rlock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	rec, err := readFileRecord(path)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil, ErrReleaseNotFound
+		}
+		return nil, errors.Wrapf(err, "get: failed to read %q", key)
+	}
+	r, err := decodeRelease(rec.Release)
+	return r, errors.Wrapf(err, "get: failed to decode data %q", key)
+}
+
+// List returns the list of all releases such that filter(release) == true
+func (f *File) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	unlock, err := f.rlock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	recs, err := f.records()
+	if err != nil {
+		return nil, errors.Wrap(err, "list: failed to list")
+	}
+
+	var results []*rspb.Release
+	for _, rec := range recs {
+		rls, err := decodeRelease(rec.Release)
+		if err != nil {
+			f.Log("list: failed to decode release: %s", err)
+			continue
+		}
+
+		rls.Labels = rec.Labels
+
+		if filter(rls) {
+			results = append(results, rls)
+		}
+	}
+	return results, nil
+}
+
+// ListPage returns the page of releases matching opts. Only the releases of
+// the returned page are decoded.
+func (f *File) ListPage(opts ListOptions) (*ListResult, error) {
+	unlock, err := f.rlock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	recs, err := f.records()
+	if err != nil {
+		return nil, errors.Wrap(err, "list: failed to list")
+	}
+
+	lrecs := make([]*listRecord, 0, len(recs))
+	for _, rec := range recs {
+		lrecs = append(lrecs, &listRecord{
+			key:       rec.key,
+			namespace: rec.namespace,
+			labels:    rec.Labels,
+			body:      rec.Release,
+		})
+	}
+	return listPage(opts, lrecs, f.Log)
+}
+
+// Query returns the set of releases that match the provided set of labels
+func (f *File) Query(keyvals map[string]string) ([]*rspb.Release, error) {
+	unlock, err := f.rlock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	recs, err := f.records()
+	if err != nil {
+		return nil, errors.Wrap(err, "query: failed to query with labels")
+	}
+
+	var lbs labels
+
+	lbs.init()
+	lbs.fromMap(keyvals)
+
+	var results []*rspb.Release
+	for _, rec := range recs {
+		if !labels(rec.Labels).match(lbs) {
+			continue
+		}
+		rls, err := decodeRelease(rec.Release)
+		if err != nil {
+			f.Log("query: failed to decode release: %s", err)
+			continue
+		}
+		rls.Labels = rec.Labels
+		results = append(results, rls)
+	}
+
+	if len(results) == 0 {
+		return nil, ErrReleaseNotFound
+	}
+	return results, nil
+}
+
+// Create creates a new release or returns ErrReleaseExists.
+func (f *File) Create(key string, rls *rspb.Release) error {
+	// For backwards compatibility, we protect against an unset namespace
+	namespace := rls.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	f.namespace = namespace
+
+	path, err := f.path(namespace, key)
+	if err != nil {
+		return err
+	}
+
+	var lbs labels
+
+	lbs.init()
+	lbs.set("createdAt", strconv.Itoa(int(time.Now().Unix())))
+
+	rec, err := newFileRecord(rls, lbs)
+	if err != nil {
+		return errors.Wrapf(err, "create: failed to encode release %q", rls.Name)
+	}
+
+	unlock, err := f.wlock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		return ErrReleaseExists
+	} else if !os.IsNotExist(err) {
+		return errors.Wrap(err, "create: failed to create")
+	}
+	return errors.Wrap(writeFileRecord(path, rec), "create: failed to create")
+}
+
+// Update updates a release or returns ErrReleaseNotFound.
+func (f *File) Update(key string, rls *rspb.Release) error {
+	// For backwards compatibility, we protect against an unset namespace
+	namespace := rls.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	f.namespace = namespace
+
+	path, err := f.path(namespace, key)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := f.wlock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	old, err := readFileRecord(path)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return ErrReleaseNotFound
+		}
+		return errors.Wrap(err, "update: failed to update")
+	}
+
+	var lbs labels
+
+	lbs.init()
+	if createdAt, ok := old.Labels["createdAt"]; ok {
+		lbs.set("createdAt", createdAt)
+	}
+	lbs.set("modifiedAt", strconv.Itoa(int(time.Now().Unix())))
+
+	rec, err := newFileRecord(rls, lbs)
+	if err != nil {
+		return errors.Wrapf(err, "update: failed to encode release %q", rls.Name)
+	}
+	return errors.Wrap(writeFileRecord(path, rec), "update: failed to update")
+}
+
+// Delete deletes a release or returns ErrReleaseNotFound.
+func (f *File) Delete(key string) (*rspb.Release, error) {
+	path, err := f.path(f.namespace, key)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := f.wlock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	rec, err := readFileRecord(path)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil, ErrReleaseNotFound
+		}
+		return nil, errors.Wrapf(err, "delete: failed to read %q", key)
+	}
+	rls, err := decodeRelease(rec.Release)
+	if err != nil {
+		return nil, errors.Wrapf(err, "delete: failed to decode data %q", key)
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, errors.Wrapf(err, "delete: failed to delete %q", key)
+	}
+	return rls, nil
+}
+
+// path returns the location of the file holding the release named by key.
+func (f *File) path(namespace, key string) (string, error) {
+	if !isPathElement(key) {
+		return "", ErrInvalidKey
+	}
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	if !isPathElement(namespace) {
+		return "", errors.Errorf("file driver: invalid namespace %q", namespace)
+	}
+	return filepath.Join(f.root, namespace, key+fileRecordExt), nil
+}
+
+// isPathElement returns whether s names a single file or directory, so that
+// keys and namespaces cannot escape the storage directory.
+func isPathElement(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
+
+// records reads every release record visible from the current namespace.
+// An empty namespace reads the records of all namespaces.
+func (f *File) records() ([]*fileRecord, error) {
+	if f.namespace != "" && !isPathElement(f.namespace) {
+		return nil, errors.Errorf("file driver: invalid namespace %q", f.namespace)
+	}
+	namespaces := []string{f.namespace}
+	if f.namespace == "" {
+		entries, err := ioutil.ReadDir(f.root)
+		if err != nil {
+			return nil, err
+		}
+		namespaces = namespaces[:0]
+		for _, e := range entries {
+			if e.IsDir() {
+				namespaces = append(namespaces, e.Name())
+			}
+		}
+	}
+
+	var recs []*fileRecord
+	for _, ns := range namespaces {
+		entries, err := ioutil.ReadDir(filepath.Join(f.root, ns))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() || filepath.Ext(e.Name()) != fileRecordExt {
+				continue
+			}
+			rec, err := readFileRecord(filepath.Join(f.root, ns, e.Name()))
+			if err != nil {
+				f.Log("failed to read release record %s: %s", e.Name(), err)
+				continue
+			}
+			rec.namespace = ns
+			rec.key = strings.TrimSuffix(e.Name(), fileRecordExt)
+			recs = append(recs, rec)
+		}
+	}
+	return recs, nil
+}
+
+// wlock acquires the storage lock for writing. The returned function
+// releases the lock.
+func (f *File) wlock() (func(), error) {
+	return f.acquire(f.lock.TryLockContext)
+}
+
+// rlock acquires the storage lock for reading. The returned function
+// releases the lock.
+func (f *File) rlock() (func(), error) {
+	return f.acquire(f.lock.TryRLockContext)
+}
+
+func (f *File) acquire(try func(context.Context, time.Duration) (bool, error)) (func(), error) {
+	f.mu.Lock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fileLockTimeout)
+	defer cancel()
+
+	locked, err := try(ctx, 100*time.Millisecond)
+	if err != nil {
+		f.mu.Unlock()
+		return nil, errors.Wrapf(err, "failed to lock %q", f.lock.Path())
+	}
+	if !locked {
+		f.mu.Unlock()
+		return nil, errors.Errorf("failed to lock %q", f.lock.Path())
+	}
+	return func() {
+		if err := f.lock.Unlock(); err != nil {
+			f.Log("failed to unlock %q: %s", f.lock.Path(), err)
+		}
+		f.mu.Unlock()
+	}, nil
+}
+
+// newFileRecord constructs the record used to store a release on disk.
+//
+// The following labels are stored with each record:
+//
+//	"modifiedAt"    - timestamp indicating when this record was last modified. (set in Update)
+//	"createdAt"     - timestamp indicating when this record was created. (set in Create)
+//	"version"        - version of the release.
+//	"status"         - status of the release (see pkg/release/status.go for variants)
+//	"owner"          - owner of the record, currently "helm".
+//	"name"           - name of the release.
+func newFileRecord(rls *rspb.Release, lbs labels) (*fileRecord, error) {
+	const owner = "helm"
+
+	s, err := encodeRelease(rls)
+	if err != nil {
+		return nil, err
+	}
+
+	if lbs == nil {
+		lbs.init()
+	}
+
+	lbs.set("name", rls.Name)
+	lbs.set("owner", owner)
+	lbs.set("status", rls.Info.Status.String())
+	lbs.set("version", strconv.Itoa(rls.Version))
+
+	return &fileRecord{
+		Type:    "helm.sh/release.v1",
+		Labels:  lbs.toMap(),
+		Release: s,
+	}, nil
+}
+
+func readFileRecord(path string) (*fileRecord, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec fileRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %q", path)
+	}
+	return &rec, nil
+}
+
+// writeFileRecord atomically replaces the file at path with rec, so that
+// readers never observe a partially written record.
+func writeFileRecord(path string, rec *fileRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
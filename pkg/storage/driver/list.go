@@ -0,0 +1,251 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver // import "helm.sh/helm/v3/pkg/storage/driver"
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kblabels "k8s.io/apimachinery/pkg/labels"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+)
+
+// listRecord is a release record considered by ListPage. Either rls holds
+// the release or body holds its encoded form, which is only decoded once the
+// record has been selected.
+type listRecord struct {
+	key       string
+	namespace string
+	labels    labels
+	rls       *rspb.Release
+	body      string
+}
+
+func (rec *listRecord) name() string { return rec.labels.get("name") }
+
+func (rec *listRecord) version() int {
+	v, _ := strconv.Atoi(rec.labels.get("version"))
+	return v
+}
+
+// less orders records by release name, namespace and version.
+func (rec *listRecord) less(other *listRecord) bool {
+	if rec.name() != other.name() {
+		return rec.name() < other.name()
+	}
+	if rec.namespace != other.namespace {
+		return rec.namespace < other.namespace
+	}
+	return rec.version() < other.version()
+}
+
+// continueToken returns the token resuming a listing after rec.
+func (rec *listRecord) continueToken() string {
+	s := fmt.Sprintf("%s/%s/%d", rec.name(), rec.namespace, rec.version())
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// parseContinueToken returns a record positioned where the listing
+// identified by token stopped.
+func parseContinueToken(token string) (*listRecord, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidContinue
+	}
+	parts := strings.SplitN(string(b), "/", 3)
+	if len(parts) != 3 {
+		return nil, ErrInvalidContinue
+	}
+	if _, err := strconv.Atoi(parts[2]); err != nil {
+		return nil, ErrInvalidContinue
+	}
+	return &listRecord{
+		namespace: parts[1],
+		labels:    labels{"name": parts[0], "version": parts[2]},
+	}, nil
+}
+
+// selectRecords applies opts to recs and returns the records of the
+// requested page, in order, along with the token for the next page.
+func selectRecords(opts ListOptions, recs []*listRecord) ([]*listRecord, string, error) {
+	var after *listRecord
+	if opts.Continue != "" {
+		var err error
+		if after, err = parseContinueToken(opts.Continue); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var matched []*listRecord
+	for _, rec := range recs {
+		if opts.Selector != nil && !opts.Selector.Matches(kblabels.Set(rec.labels)) {
+			continue
+		}
+		if opts.Name != nil && !opts.Name.MatchString(rec.name()) {
+			continue
+		}
+		matched = append(matched, rec)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].less(matched[j]) })
+
+	if opts.Latest {
+		latest := matched[:0]
+		for i, rec := range matched {
+			if i+1 < len(matched) && matched[i+1].name() == rec.name() && matched[i+1].namespace == rec.namespace {
+				continue
+			}
+			latest = append(latest, rec)
+		}
+		matched = latest
+	}
+
+	var page []*listRecord
+	for _, rec := range matched {
+		if opts.Filter != nil && !opts.Filter.Matches(kblabels.Set(rec.labels)) {
+			continue
+		}
+		if after != nil && !after.less(rec) {
+			continue
+		}
+		if opts.Limit > 0 && len(page) == opts.Limit {
+			return page, page[len(page)-1].continueToken(), nil
+		}
+		page = append(page, rec)
+	}
+	return page, "", nil
+}
+
+// decodeRecords returns the releases held by recs. Records that fail to
+// decode are logged and skipped, like List does.
+func decodeRecords(recs []*listRecord, log func(string, ...interface{})) []*rspb.Release {
+	var results []*rspb.Release
+	for _, rec := range recs {
+		if rec.rls != nil {
+			results = append(results, rec.rls)
+			continue
+		}
+		rls, err := decodeRelease(rec.body)
+		if err != nil {
+			log("list: failed to decode release %q: %s", rec.key, err)
+			continue
+		}
+		rls.Labels = rec.labels.toMap()
+		results = append(results, rls)
+	}
+	return results
+}
+
+// ownerSelector returns a selector matching the records owned by helm that
+// also match sel, for drivers that evaluate selectors server-side.
+func ownerSelector(sel kblabels.Selector) kblabels.Selector {
+	lsel := kblabels.Set{"owner": "helm"}.AsSelector()
+	if sel == nil {
+		return lsel
+	}
+	if reqs, selectable := sel.Requirements(); selectable {
+		lsel = lsel.Add(reqs...)
+	}
+	return lsel
+}
+
+// listPage returns the page of recs selected by opts.
+func listPage(opts ListOptions, recs []*listRecord, log func(string, ...interface{})) (*ListResult, error) {
+	page, next, err := selectRecords(opts, recs)
+	if err != nil {
+		return nil, err
+	}
+	return &ListResult{Releases: decodeRecords(page, log), Continue: next}, nil
+}
+
+// serverPaged reports whether the API server can page the records selected
+// by opts: their order does not matter and it evaluates all the
+// restrictions.
+func serverPaged(opts ListOptions) bool {
+	if !opts.AnyOrder || opts.Name != nil || opts.Latest || opts.Filter != nil {
+		return false
+	}
+	if opts.Selector == nil {
+		return true
+	}
+	_, selectable := opts.Selector.Requirements()
+	return selectable
+}
+
+// kubeListPage returns the page of releases matching opts for the drivers
+// storing releases in Kubernetes objects. list requests the records from the
+// API server and returns them along with its continue token. The API server
+// selects the records, and pages them too if serverPaged(opts).
+func kubeListPage(opts ListOptions, list func(metav1.ListOptions) ([]*listRecord, string, error), log func(string, ...interface{})) (*ListResult, error) {
+	paged := serverPaged(opts)
+	lo := metav1.ListOptions{LabelSelector: ownerSelector(opts.Selector).String()}
+	if paged {
+		lo.Limit = int64(opts.Limit)
+		lo.Continue = opts.Continue
+	}
+	recs, next, err := list(lo)
+	if err != nil {
+		if paged && opts.Continue != "" && (apierrors.IsBadRequest(err) || apierrors.IsResourceExpired(err)) {
+			return nil, ErrInvalidContinue
+		}
+		return nil, errors.Wrap(err, "list: failed to list")
+	}
+	if !paged {
+		return listPage(opts, recs, log)
+	}
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].less(recs[j]) })
+	return &ListResult{Releases: decodeRecords(recs, log), Continue: next}, nil
+}
+
+// ListPage returns the page of releases of d that match opts. Drivers that
+// do not implement Pager are paged over all the releases returned by List.
+func ListPage(d Driver, opts ListOptions) (*ListResult, error) {
+	if p, ok := d.(Pager); ok {
+		return p.ListPage(opts)
+	}
+	// Fail on an invalid token before listing every release.
+	if opts.Continue != "" {
+		if _, err := parseContinueToken(opts.Continue); err != nil {
+			return nil, err
+		}
+	}
+
+	releases, err := d.List(func(*rspb.Release) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]*listRecord, 0, len(releases))
+	for _, rls := range releases {
+		// Labels set on the release itself can be selected as well.
+		var lbs labels
+		lbs.init()
+		lbs.fromMap(rls.Labels)
+		lbs.fromMap(newRecord("", rls).lbs)
+		recs = append(recs, &listRecord{
+			namespace: rls.Namespace,
+			labels:    lbs,
+			rls:       rls,
+		})
+	}
+	return listPage(opts, recs, func(_ string, _ ...interface{}) {})
+}
@@ -25,6 +25,8 @@ import (
 )
 
 var _ Driver = (*Memory)(nil)
+var _ Pager = (*Memory)(nil)
+var _ NamespaceSetter = (*Memory)(nil)
 
 const (
 	// MemoryDriverName is the string name of this driver.
@@ -108,6 +110,40 @@ func (mem *Memory) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error
 	return ls, nil
 }
 
+// ListPage returns the page of releases matching opts.
+func (mem *Memory) ListPage(opts ListOptions) (*ListResult, error) {
+	defer unlock(mem.rlock())
+
+	var lrecs []*listRecord
+	for namespace := range mem.cache {
+		if mem.namespace != "" {
+			// Should only list releases of this namespace
+			namespace = mem.namespace
+		}
+		for _, recs := range mem.cache[namespace] {
+			recs.Iter(func(_ int, rec *record) bool {
+				// Labels set on the release itself can be selected as well.
+				var lbs labels
+				lbs.init()
+				lbs.fromMap(rec.rls.Labels)
+				lbs.fromMap(rec.lbs)
+				lrecs = append(lrecs, &listRecord{
+					key:       rec.key,
+					namespace: namespace,
+					labels:    lbs,
+					rls:       rec.rls,
+				})
+				return true
+			})
+		}
+		if mem.namespace != "" {
+			// Should only list releases of this namespace
+			break
+		}
+	}
+	return listPage(opts, lrecs, func(_ string, _ ...interface{}) {})
+}
+
 // Query returns the set of releases that match the provided set of labels
 func (mem *Memory) Query(keyvals map[string]string) ([]*rspb.Release, error) {
 	defer unlock(mem.rlock())
@@ -19,6 +19,8 @@ package driver // import "helm.sh/helm/v3/pkg/storage/driver"
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
@@ -29,6 +31,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kblabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	rspb "helm.sh/helm/v3/pkg/release"
@@ -47,8 +50,27 @@ func testKey(name string, vers int) string {
 	return fmt.Sprintf("%s.v%d", name, vers)
 }
 
-func tsFixtureMemory(t *testing.T) *Memory {
-	hs := []*rspb.Release{
+// mockPage returns the page of names requested by opts, in order, and the
+// continue token of the next page, like the API server pages a list.
+func mockPage(names []string, opts metav1.ListOptions) ([]string, string, error) {
+	sort.Strings(names)
+	if opts.Continue != "" {
+		if !strings.HasPrefix(opts.Continue, "after:") {
+			return nil, "", apierrors.NewBadRequest("continue key is not valid")
+		}
+		after := strings.TrimPrefix(opts.Continue, "after:")
+		names = names[sort.Search(len(names), func(i int) bool { return names[i] > after }):]
+	}
+	if opts.Limit > 0 && int64(len(names)) > opts.Limit {
+		names = names[:opts.Limit]
+		return names, "after:" + names[len(names)-1], nil
+	}
+	return names, "", nil
+}
+
+// tsFixtureReleases returns the release history shared by the driver fixtures.
+func tsFixtureReleases() []*rspb.Release {
+	return []*rspb.Release{
 		// rls-a
 		releaseStub("rls-a", 4, "default", rspb.StatusDeployed),
 		releaseStub("rls-a", 1, "default", rspb.StatusSuperseded),
@@ -65,9 +87,11 @@ func tsFixtureMemory(t *testing.T) *Memory {
 		releaseStub("rls-c", 3, "mynamespace", rspb.StatusSuperseded),
 		releaseStub("rls-c", 2, "mynamespace", rspb.StatusSuperseded),
 	}
+}
 
+func tsFixtureMemory(t *testing.T) *Memory {
 	mem := NewMemory()
-	for _, tt := range hs {
+	for _, tt := range tsFixtureReleases() {
 		err := mem.Create(testKey(tt.Name, tt.Version), tt)
 		if err != nil {
 			t.Fatalf("Test setup failed to create: %s\n", err)
@@ -76,6 +100,20 @@ func tsFixtureMemory(t *testing.T) *Memory {
 	return mem
 }
 
+func tsFixtureFile(t *testing.T) *File {
+	f, err := NewFile(t.TempDir(), nil, "default")
+	if err != nil {
+		t.Fatalf("Test setup failed to create file driver: %s\n", err)
+	}
+	for _, tt := range tsFixtureReleases() {
+		if err := f.Create(testKey(tt.Name, tt.Version), tt); err != nil {
+			t.Fatalf("Test setup failed to create: %s\n", err)
+		}
+	}
+	f.SetNamespace("default")
+	return f
+}
+
 // newTestFixture initializes a MockConfigMapsInterface.
 // ConfigMaps are created for each release provided.
 func newTestFixtureCfgMaps(t *testing.T, releases ...*rspb.Release) *ConfigMaps {
@@ -90,11 +128,16 @@ type MockConfigMapsInterface struct {
 	corev1.ConfigMapInterface
 
 	objects map[string]*v1.ConfigMap
+
+	// watcher is set once Watch was called, watching is signaled then.
+	watcher  *watch.RaceFreeFakeWatcher
+	watching chan struct{}
 }
 
 // Init initializes the MockConfigMapsInterface with the set of releases.
 func (mock *MockConfigMapsInterface) Init(t *testing.T, releases ...*rspb.Release) {
 	mock.objects = map[string]*v1.ConfigMap{}
+	mock.watching = make(chan struct{}, 1)
 
 	for _, rls := range releases {
 		objkey := testKey(rls.Name, rls.Version)
@@ -125,11 +168,19 @@ func (mock *MockConfigMapsInterface) List(_ context.Context, opts metav1.ListOpt
 		return nil, err
 	}
 
-	for _, cfgmap := range mock.objects {
+	var names []string
+	for name, cfgmap := range mock.objects {
 		if labelSelector.Matches(kblabels.Set(cfgmap.ObjectMeta.Labels)) {
-			list.Items = append(list.Items, *cfgmap)
+			names = append(names, name)
 		}
 	}
+	names, list.Continue, err = mockPage(names, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		list.Items = append(list.Items, *mock.objects[name])
+	}
 	return &list, nil
 }
 
@@ -140,6 +191,9 @@ func (mock *MockConfigMapsInterface) Create(_ context.Context, cfgmap *v1.Config
 		return object, apierrors.NewAlreadyExists(v1.Resource("tests"), name)
 	}
 	mock.objects[name] = cfgmap
+	if mock.watcher != nil {
+		mock.watcher.Add(cfgmap)
+	}
 	return cfgmap, nil
 }
 
@@ -150,18 +204,32 @@ func (mock *MockConfigMapsInterface) Update(_ context.Context, cfgmap *v1.Config
 		return nil, apierrors.NewNotFound(v1.Resource("tests"), name)
 	}
 	mock.objects[name] = cfgmap
+	if mock.watcher != nil {
+		mock.watcher.Modify(cfgmap)
+	}
 	return cfgmap, nil
 }
 
 // Delete deletes a ConfigMap by name.
 func (mock *MockConfigMapsInterface) Delete(_ context.Context, name string, _ metav1.DeleteOptions) error {
-	if _, ok := mock.objects[name]; !ok {
+	object, ok := mock.objects[name]
+	if !ok {
 		return apierrors.NewNotFound(v1.Resource("tests"), name)
 	}
 	delete(mock.objects, name)
+	if mock.watcher != nil {
+		mock.watcher.Delete(object)
+	}
 	return nil
 }
 
+// Watch watches the ConfigMaps created, updated or deleted from now on.
+func (mock *MockConfigMapsInterface) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	mock.watcher = watch.NewRaceFreeFake()
+	mock.watching <- struct{}{}
+	return mock.watcher, nil
+}
+
 // newTestFixture initializes a MockSecretsInterface.
 // Secrets are created for each release provided.
 func newTestFixtureSecrets(t *testing.T, releases ...*rspb.Release) *Secrets {
@@ -176,11 +244,16 @@ type MockSecretsInterface struct {
 	corev1.SecretInterface
 
 	objects map[string]*v1.Secret
+
+	// watcher is set once Watch was called, watching is signaled then.
+	watcher  *watch.RaceFreeFakeWatcher
+	watching chan struct{}
 }
 
 // Init initializes the MockSecretsInterface with the set of releases.
 func (mock *MockSecretsInterface) Init(t *testing.T, releases ...*rspb.Release) {
 	mock.objects = map[string]*v1.Secret{}
+	mock.watching = make(chan struct{}, 1)
 
 	for _, rls := range releases {
 		objkey := testKey(rls.Name, rls.Version)
@@ -211,11 +284,19 @@ func (mock *MockSecretsInterface) List(_ context.Context, opts metav1.ListOption
 		return nil, err
 	}
 
-	for _, secret := range mock.objects {
+	var names []string
+	for name, secret := range mock.objects {
 		if labelSelector.Matches(kblabels.Set(secret.ObjectMeta.Labels)) {
-			list.Items = append(list.Items, *secret)
+			names = append(names, name)
 		}
 	}
+	names, list.Continue, err = mockPage(names, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		list.Items = append(list.Items, *mock.objects[name])
+	}
 	return &list, nil
 }
 
@@ -226,6 +307,9 @@ func (mock *MockSecretsInterface) Create(_ context.Context, secret *v1.Secret, _
 		return object, apierrors.NewAlreadyExists(v1.Resource("tests"), name)
 	}
 	mock.objects[name] = secret
+	if mock.watcher != nil {
+		mock.watcher.Add(secret)
+	}
 	return secret, nil
 }
 
@@ -236,18 +320,32 @@ func (mock *MockSecretsInterface) Update(_ context.Context, secret *v1.Secret, _
 		return nil, apierrors.NewNotFound(v1.Resource("tests"), name)
 	}
 	mock.objects[name] = secret
+	if mock.watcher != nil {
+		mock.watcher.Modify(secret)
+	}
 	return secret, nil
 }
 
 // Delete deletes a Secret by name.
 func (mock *MockSecretsInterface) Delete(_ context.Context, name string, _ metav1.DeleteOptions) error {
-	if _, ok := mock.objects[name]; !ok {
+	object, ok := mock.objects[name]
+	if !ok {
 		return apierrors.NewNotFound(v1.Resource("tests"), name)
 	}
 	delete(mock.objects, name)
+	if mock.watcher != nil {
+		mock.watcher.Delete(object)
+	}
 	return nil
 }
 
+// Watch watches the Secrets created, updated or deleted from now on.
+func (mock *MockSecretsInterface) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	mock.watcher = watch.NewRaceFreeFake()
+	mock.watching <- struct{}{}
+	return mock.watcher, nil
+}
+
 // newTestFixtureSQL mocks the SQL database (for testing purposes)
 func newTestFixtureSQL(t *testing.T, releases ...*rspb.Release) (*SQL, sqlmock.Sqlmock) {
 	sqlDB, mock, err := sqlmock.New()
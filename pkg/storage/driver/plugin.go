@@ -0,0 +1,351 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver // import "helm.sh/helm/v3/pkg/storage/driver"
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+)
+
+var _ Driver = (*Plugin)(nil)
+var _ Pager = (*Plugin)(nil)
+var _ NamespaceSetter = (*Plugin)(nil)
+
+// PluginDriverName is the string name of this driver.
+const PluginDriverName = "Plugin"
+
+// Operations a storage driver plugin is asked to perform.
+const (
+	PluginOperationCreate = "create"
+	PluginOperationUpdate = "update"
+	PluginOperationDelete = "delete"
+	PluginOperationGet    = "get"
+	PluginOperationList   = "list"
+	PluginOperationQuery  = "query"
+)
+
+// Error codes a storage driver plugin reports in PluginError.Code. They map
+// to ErrReleaseNotFound, ErrReleaseExists and ErrInvalidKey respectively.
+const (
+	PluginErrorNotFound   = "not_found"
+	PluginErrorExists     = "exists"
+	PluginErrorInvalidKey = "invalid_key"
+)
+
+// PluginRequest is the message a storage driver plugin reads from stdin.
+//
+// The plugin is executed once per request. Depending on Operation, the
+// request carries:
+//
+//	create, update - Key, Namespace, Labels and the Release to store.
+//	delete, get    - Key and Namespace of the release record.
+//	list           - Namespace. An empty namespace means all namespaces.
+//	query          - Namespace and the Labels every returned record must match.
+//
+// Labels always hold the standard release labels "name", "owner", "status"
+// and "version" for create and update, so plugins can index records without
+// inspecting the release.
+type PluginRequest struct {
+	Operation string            `json:"operation"`
+	Namespace string            `json:"namespace"`
+	Key       string            `json:"key,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Release   *rspb.Release     `json:"release,omitempty"`
+}
+
+// PluginResponse is the message a storage driver plugin writes to stdout.
+//
+// get and delete return the release in Release, list and query return the
+// matching releases in Releases. A failed operation sets Error instead.
+type PluginResponse struct {
+	Release  *rspb.Release   `json:"release,omitempty"`
+	Releases []*rspb.Release `json:"releases,omitempty"`
+	Error    *PluginError    `json:"error,omitempty"`
+}
+
+// PluginError describes why a storage driver plugin failed to serve a request.
+type PluginError struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// Plugin is the storage driver implementation that delegates to an external
+// program, usually supplied by a helm plugin declaring a storageDriver.
+type Plugin struct {
+	command   string
+	args      []string
+	env       []string
+	namespace string
+
+	Log func(string, ...interface{})
+}
+
+// NewPlugin initializes a new plugin driver running command with args and
+// the environment env for each request.
+func NewPlugin(command string, args, env []string, namespace string) *Plugin {
+	return &Plugin{
+		command:   command,
+		args:      args,
+		env:       env,
+		namespace: namespace,
+		Log:       func(_ string, _ ...interface{}) {},
+	}
+}
+
+// SetNamespace sets a specific namespace in which releases will be accessed.
+// An empty string indicates all namespaces (for the list operation)
+func (p *Plugin) SetNamespace(ns string) {
+	p.namespace = ns
+}
+
+// Name returns the name of the driver.
+func (p *Plugin) Name() string {
+	return PluginDriverName
+}
+
+// Get returns the release named by key or returns ErrReleaseNotFound.
+func (p *Plugin) Get(key string) (*rspb.Release, error) {
+	resp, err := p.call(&PluginRequest{Operation: PluginOperationGet, Namespace: p.namespace, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Release == nil {
+		return nil, ErrReleaseNotFound
+	}
+	return resp.Release, nil
+}
+
+// List returns the list of all releases such that filter(release) == true
+func (p *Plugin) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	resp, err := p.call(&PluginRequest{Operation: PluginOperationList, Namespace: p.namespace})
+	if err != nil {
+		return nil, errors.Wrap(err, "list: failed to list")
+	}
+
+	var results []*rspb.Release
+	for _, rls := range resp.Releases {
+		if filter(rls) {
+			results = append(results, rls)
+		}
+	}
+	return results, nil
+}
+
+// ListPage returns the page of releases matching opts. Plugins return whole
+// releases, so the options are applied after the plugin listed them.
+func (p *Plugin) ListPage(opts ListOptions) (*ListResult, error) {
+	resp, err := p.call(&PluginRequest{Operation: PluginOperationList, Namespace: p.namespace})
+	if err != nil {
+		return nil, errors.Wrap(err, "list: failed to list")
+	}
+
+	recs := make([]*listRecord, 0, len(resp.Releases))
+	for _, rls := range resp.Releases {
+		recs = append(recs, &listRecord{
+			namespace: rls.Namespace,
+			labels:    pluginLabels(rls),
+			rls:       rls,
+		})
+	}
+	return listPage(opts, recs, p.Log)
+}
+
+// Query returns the set of releases that match the provided set of labels
+func (p *Plugin) Query(labels map[string]string) ([]*rspb.Release, error) {
+	resp, err := p.call(&PluginRequest{Operation: PluginOperationQuery, Namespace: p.namespace, Labels: labels})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Releases) == 0 {
+		return nil, ErrReleaseNotFound
+	}
+	return resp.Releases, nil
+}
+
+// Create creates a new release or returns ErrReleaseExists.
+func (p *Plugin) Create(key string, rls *rspb.Release) error {
+	// For backwards compatibility, we protect against an unset namespace
+	namespace := rls.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	p.namespace = namespace
+
+	_, err := p.call(&PluginRequest{
+		Operation: PluginOperationCreate,
+		Namespace: namespace,
+		Key:       key,
+		Labels:    pluginLabels(rls),
+		Release:   rls,
+	})
+	return err
+}
+
+// Update updates a release or returns ErrReleaseNotFound.
+func (p *Plugin) Update(key string, rls *rspb.Release) error {
+	// For backwards compatibility, we protect against an unset namespace
+	namespace := rls.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	p.namespace = namespace
+
+	_, err := p.call(&PluginRequest{
+		Operation: PluginOperationUpdate,
+		Namespace: namespace,
+		Key:       key,
+		Labels:    pluginLabels(rls),
+		Release:   rls,
+	})
+	return err
+}
+
+// Delete deletes a release or returns ErrReleaseNotFound.
+func (p *Plugin) Delete(key string) (*rspb.Release, error) {
+	resp, err := p.call(&PluginRequest{Operation: PluginOperationDelete, Namespace: p.namespace, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Release == nil {
+		return nil, ErrReleaseNotFound
+	}
+	return resp.Release, nil
+}
+
+// call runs the plugin command for a single request and decodes its response.
+func (p *Plugin) call(req *PluginRequest) (*PluginResponse, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to encode request", req.Operation)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(p.command, p.args...)
+	cmd.Env = p.env
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		p.Log("%s: storage plugin %q failed: %s", req.Operation, p.command, stderr.String())
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.Wrapf(err, "%s: storage plugin %q failed: %s", req.Operation, p.command, msg)
+		}
+		return nil, errors.Wrapf(err, "%s: storage plugin %q failed", req.Operation, p.command)
+	}
+
+	var resp PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode response of storage plugin %q", req.Operation, p.command)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error.err()
+	}
+	return &resp, nil
+}
+
+// err converts a plugin error into the matching driver error.
+func (e *PluginError) err() error {
+	switch e.Code {
+	case PluginErrorNotFound:
+		return ErrReleaseNotFound
+	case PluginErrorExists:
+		return ErrReleaseExists
+	case PluginErrorInvalidKey:
+		return ErrInvalidKey
+	}
+	return errors.New(e.Message)
+}
+
+// pluginLabels returns the standard labels describing rls.
+func pluginLabels(rls *rspb.Release) map[string]string {
+	var lbs labels
+
+	lbs.init()
+	lbs.set("name", rls.Name)
+	lbs.set("owner", "helm")
+	lbs.set("status", rls.Info.Status.String())
+	lbs.set("version", strconv.Itoa(rls.Version))
+	return lbs.toMap()
+}
+
+// ServePlugin serves a single storage driver plugin request read from in,
+// writing the response to out. The request is executed against the driver
+// returned by newDriver for the requested namespace.
+//
+// It allows storage driver plugins written in Go to reuse an existing Driver
+// implementation.
+func ServePlugin(newDriver func(namespace string) (Driver, error), in io.Reader, out io.Writer) error {
+	var req PluginRequest
+	if err := json.NewDecoder(in).Decode(&req); err != nil {
+		return errors.Wrap(err, "failed to decode request")
+	}
+
+	resp := servePluginRequest(newDriver, &req)
+	return json.NewEncoder(out).Encode(resp)
+}
+
+func servePluginRequest(newDriver func(namespace string) (Driver, error), req *PluginRequest) *PluginResponse {
+	d, err := newDriver(req.Namespace)
+	if err != nil {
+		return &PluginResponse{Error: newPluginError(err)}
+	}
+
+	var resp PluginResponse
+	switch req.Operation {
+	case PluginOperationCreate:
+		err = d.Create(req.Key, req.Release)
+	case PluginOperationUpdate:
+		err = d.Update(req.Key, req.Release)
+	case PluginOperationDelete:
+		resp.Release, err = d.Delete(req.Key)
+	case PluginOperationGet:
+		resp.Release, err = d.Get(req.Key)
+	case PluginOperationList:
+		resp.Releases, err = d.List(func(_ *rspb.Release) bool { return true })
+	case PluginOperationQuery:
+		resp.Releases, err = d.Query(req.Labels)
+	default:
+		err = errors.Errorf("unknown operation %q", req.Operation)
+	}
+	if err != nil {
+		return &PluginResponse{Error: newPluginError(err)}
+	}
+	return &resp
+}
+
+// newPluginError converts a driver error into a plugin error.
+func newPluginError(err error) *PluginError {
+	pe := &PluginError{Message: err.Error()}
+	switch {
+	case errors.Is(err, ErrReleaseNotFound):
+		pe.Code = PluginErrorNotFound
+	case errors.Is(err, ErrReleaseExists):
+		pe.Code = PluginErrorExists
+	case errors.Is(err, ErrInvalidKey):
+		pe.Code = PluginErrorInvalidKey
+	}
+	return pe
+}
@@ -19,10 +19,13 @@ package driver // import "helm.sh/helm/v3/pkg/storage/driver"
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	migrate "github.com/rubenv/sql-migrate"
+	kblabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 
 	sq "github.com/Masterminds/squirrel"
 
@@ -33,6 +36,8 @@ import (
 )
 
 var _ Driver = (*SQL)(nil)
+var _ Pager = (*SQL)(nil)
+var _ NamespaceSetter = (*SQL)(nil)
 
 var labelMap = map[string]struct{}{
 	"modifiedAt": {},
@@ -77,6 +82,12 @@ type SQL struct {
 	Log func(string, ...interface{})
 }
 
+// SetNamespace sets a specific namespace in which releases will be accessed.
+// An empty string indicates all namespaces (for the list operation)
+func (s *SQL) SetNamespace(ns string) {
+	s.namespace = ns
+}
+
 // Name returns the name of the driver.
 func (s *SQL) Name() string {
 	return SQLDriverName
@@ -272,6 +283,136 @@ func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
 	return releases, nil
 }
 
+// ListPage returns the page of releases matching opts. The selector is
+// translated into the WHERE clause of a query that only fetches the release
+// labels. The bodies are then fetched for the releases of the page only.
+func (s *SQL) ListPage(opts ListOptions) (*ListResult, error) {
+	sb := s.statementBuilder.
+		Select(
+			sqlReleaseTableKeyColumn,
+			sqlReleaseTableNamespaceColumn,
+			sqlReleaseTableNameColumn,
+			sqlReleaseTableVersionColumn,
+			sqlReleaseTableStatusColumn,
+			sqlReleaseTableOwnerColumn,
+			sqlReleaseTableCreatedAtColumn,
+			sqlReleaseTableModifiedAtColumn,
+		).
+		From(sqlReleaseTableName).
+		Where(sq.Eq{sqlReleaseTableOwnerColumn: sqlReleaseDefaultOwner})
+
+	// If a namespace was specified, we only list releases from that namespace
+	if s.namespace != "" {
+		sb = sb.Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
+	}
+
+	// Requirements on labels without a column are left to selectRecords.
+	if opts.Selector != nil {
+		reqs, _ := opts.Selector.Requirements()
+		for _, req := range reqs {
+			if _, ok := labelMap[req.Key()]; !ok {
+				continue
+			}
+			cond, err := sqlSelectorCondition(req)
+			if err != nil {
+				s.Log("list: %v", err)
+				return nil, err
+			}
+			sb = sb.Where(cond)
+		}
+	}
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		s.Log("failed to build query: %v", err)
+		return nil, err
+	}
+
+	var records = []SQLReleaseWrapper{}
+	if err := s.db.Select(&records, query, args...); err != nil {
+		s.Log("list: failed to list: %v", err)
+		return nil, err
+	}
+
+	recs := make([]*listRecord, 0, len(records))
+	for _, record := range records {
+		recs = append(recs, &listRecord{
+			key:       record.Key,
+			namespace: record.Namespace,
+			labels: labels{
+				"name":       record.Name,
+				"owner":      record.Owner,
+				"status":     record.Status,
+				"version":    strconv.Itoa(record.Version),
+				"createdAt":  strconv.Itoa(record.CreatedAt),
+				"modifiedAt": strconv.Itoa(record.ModifiedAt),
+			},
+		})
+	}
+
+	page, next, err := selectRecords(opts, recs)
+	if err != nil {
+		return nil, err
+	}
+	if len(page) == 0 {
+		return &ListResult{Continue: next}, nil
+	}
+
+	keys := sq.Or{}
+	for _, rec := range page {
+		keys = append(keys, sq.Eq{
+			sqlReleaseTableKeyColumn:       rec.key,
+			sqlReleaseTableNamespaceColumn: rec.namespace,
+		})
+	}
+	query, args, err = s.statementBuilder.
+		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn).
+		From(sqlReleaseTableName).
+		Where(keys).
+		ToSql()
+	if err != nil {
+		s.Log("failed to build query: %v", err)
+		return nil, err
+	}
+
+	records = []SQLReleaseWrapper{}
+	if err := s.db.Select(&records, query, args...); err != nil {
+		s.Log("list: failed to fetch releases: %v", err)
+		return nil, err
+	}
+
+	bodies := make(map[string]string, len(records))
+	for _, record := range records {
+		bodies[record.Namespace+"/"+record.Key] = record.Body
+	}
+	for _, rec := range page {
+		rec.body = bodies[rec.namespace+"/"+rec.key]
+	}
+	return &ListResult{Releases: decodeRecords(page, s.Log), Continue: next}, nil
+}
+
+// sqlSelectorCondition translates a label selector requirement into a
+// condition on the matching release table column.
+func sqlSelectorCondition(req kblabels.Requirement) (sq.Sqlizer, error) {
+	key := req.Key()
+	values := req.Values().List()
+	switch req.Operator() {
+	case selection.Equals, selection.DoubleEquals, selection.In:
+		return sq.Eq{key: values}, nil
+	case selection.NotEquals, selection.NotIn:
+		return sq.NotEq{key: values}, nil
+	case selection.Exists:
+		return sq.Expr("1 = 1"), nil
+	case selection.DoesNotExist:
+		return sq.Expr("1 = 0"), nil
+	case selection.GreaterThan:
+		return sq.Gt{key: values[0]}, nil
+	case selection.LessThan:
+		return sq.Lt{key: values[0]}, nil
+	}
+	return nil, fmt.Errorf("unsupported operator %q for label %s", req.Operator(), key)
+}
+
 // Query returns the set of releases that match the provided set of labels.
 func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 	sb := s.statementBuilder.
@@ -287,8 +428,10 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 		if _, ok := labelMap[key]; ok {
 			sb = sb.Where(sq.Eq{key: labels[key]})
 		} else {
-			s.Log("unknown label %s", key)
-			return nil, fmt.Errorf("unknown label %s", key)
+			// Releases stored in SQL have no labels besides their columns,
+			// so none of them matches.
+			s.Log("query: no release has the label %s", key)
+			return nil, ErrReleaseNotFound
 		}
 	}
 
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	kblabels "k8s.io/apimachinery/pkg/labels"
 
 	rspb "helm.sh/helm/v3/pkg/release"
 )
@@ -152,6 +153,168 @@ func TestSQLList(t *testing.T) {
 	}
 }
 
+func TestSQLListPage(t *testing.T) {
+	body1, _ := encodeRelease(releaseStub("key-1", 2, "default", rspb.StatusDeployed))
+
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	labelsQuery := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s IN ($3,$4)",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableVersionColumn,
+		sqlReleaseTableStatusColumn,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableCreatedAtColumn,
+		sqlReleaseTableModifiedAtColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableStatusColumn,
+	)
+	mock.
+		ExpectQuery(regexp.QuoteMeta(labelsQuery)).
+		WithArgs(sqlReleaseDefaultOwner, sqlDriver.namespace, "deployed", "failed").
+		WillReturnRows(
+			mock.NewRows([]string{
+				sqlReleaseTableKeyColumn,
+				sqlReleaseTableNamespaceColumn,
+				sqlReleaseTableNameColumn,
+				sqlReleaseTableVersionColumn,
+				sqlReleaseTableStatusColumn,
+				sqlReleaseTableOwnerColumn,
+				sqlReleaseTableCreatedAtColumn,
+				sqlReleaseTableModifiedAtColumn,
+			}).
+				AddRow("key-1.v1", "default", "key-1", 1, "failed", "helm", 0, 0).
+				AddRow("key-1.v2", "default", "key-1", 2, "deployed", "helm", 0, 0).
+				AddRow("key-2.v1", "default", "key-2", 1, "deployed", "helm", 0, 0),
+		).RowsWillBeClosed()
+
+	bodyQuery := fmt.Sprintf(
+		"SELECT %s, %s, %s FROM %s WHERE (%s = $1 AND %s = $2)",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+	)
+	mock.
+		ExpectQuery(regexp.QuoteMeta(bodyQuery)).
+		WithArgs("key-1.v2", "default").
+		WillReturnRows(
+			mock.NewRows([]string{
+				sqlReleaseTableKeyColumn,
+				sqlReleaseTableNamespaceColumn,
+				sqlReleaseTableBodyColumn,
+			}).AddRow("key-1.v2", "default", body1),
+		).RowsWillBeClosed()
+
+	selector, err := kblabels.Parse("status in (deployed,failed)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := sqlDriver.ListPage(ListOptions{Selector: selector, Latest: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("Failed to list: %v", err)
+	}
+	if len(res.Releases) != 1 || res.Releases[0].Name != "key-1" || res.Releases[0].Version != 2 {
+		t.Errorf("Expected key-1.v2, got %v", res.Releases)
+	}
+	if res.Continue == "" {
+		t.Error("Expected a continue token")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
+func TestSQLListPageCustomLabel(t *testing.T) {
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	labelsQuery := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableVersionColumn,
+		sqlReleaseTableStatusColumn,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableCreatedAtColumn,
+		sqlReleaseTableModifiedAtColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableNamespaceColumn,
+	)
+	for i := 0; i < 2; i++ {
+		mock.
+			ExpectQuery(regexp.QuoteMeta(labelsQuery)).
+			WithArgs(sqlReleaseDefaultOwner, sqlDriver.namespace).
+			WillReturnRows(
+				mock.NewRows([]string{
+					sqlReleaseTableKeyColumn,
+					sqlReleaseTableNamespaceColumn,
+					sqlReleaseTableNameColumn,
+					sqlReleaseTableVersionColumn,
+					sqlReleaseTableStatusColumn,
+					sqlReleaseTableOwnerColumn,
+					sqlReleaseTableCreatedAtColumn,
+					sqlReleaseTableModifiedAtColumn,
+				}).AddRow("key-1.v1", "default", "key-1", 1, "deployed", "helm", 0, 0),
+			).RowsWillBeClosed()
+	}
+	body, _ := encodeRelease(releaseStub("key-1", 1, "default", rspb.StatusDeployed))
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(
+			"SELECT %s, %s, %s FROM %s WHERE (%s = $1 AND %s = $2)",
+			sqlReleaseTableKeyColumn,
+			sqlReleaseTableNamespaceColumn,
+			sqlReleaseTableBodyColumn,
+			sqlReleaseTableName,
+			sqlReleaseTableKeyColumn,
+			sqlReleaseTableNamespaceColumn,
+		))).
+		WithArgs("key-1.v1", "default").
+		WillReturnRows(
+			mock.NewRows([]string{
+				sqlReleaseTableKeyColumn,
+				sqlReleaseTableNamespaceColumn,
+				sqlReleaseTableBodyColumn,
+			}).AddRow("key-1.v1", "default", body),
+		).RowsWillBeClosed()
+
+	// Releases stored in SQL have no custom labels.
+	for _, tt := range []struct {
+		selector string
+		want     int
+	}{
+		{"app=nginx", 0},
+		{"app!=nginx", 1},
+	} {
+		selector, err := kblabels.Parse(tt.selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := sqlDriver.ListPage(ListOptions{Selector: selector})
+		if err != nil {
+			t.Fatalf("%s: failed to list: %v", tt.selector, err)
+		}
+		if len(res.Releases) != tt.want {
+			t.Errorf("%s: expected %d releases, got %d", tt.selector, tt.want, len(res.Releases))
+		}
+	}
+	if _, err := sqlDriver.Query(map[string]string{"name": "key-1", "app": "nginx"}); err != ErrReleaseNotFound {
+		t.Errorf("Expected ErrReleaseNotFound querying a custom label, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
 func TestSqlCreate(t *testing.T) {
 	vers := 1
 	name := "smug-pigeon"
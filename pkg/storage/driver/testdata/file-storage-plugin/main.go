@@ -0,0 +1,50 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command file-storage is a reference storage driver plugin.
+//
+// Build it into the plugin directory and install the plugin:
+//
+//	go build -o file-storage .
+//	helm plugin install .
+//
+// Helm runs the command once per storage request, writing a
+// driver.PluginRequest as JSON to its stdin and reading a
+// driver.PluginResponse as JSON from its stdout.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+func main() {
+	dir := os.Getenv("HELM_FILE_STORAGE_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HELM_PLUGIN_DIR"), "releases")
+	}
+
+	err := driver.ServePlugin(func(namespace string) (driver.Driver, error) {
+		return driver.NewFile(dir, nil, namespace)
+	}, os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
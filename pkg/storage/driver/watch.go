@@ -0,0 +1,151 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver // import "helm.sh/helm/v3/pkg/storage/driver"
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+)
+
+// EventType describes how a release record changed.
+type EventType string
+
+// The changes reported by Watch.
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// Event is a change of a release record.
+type Event struct {
+	Type EventType
+	// Key is the key of the release record.
+	Key string
+	// Release is the release stored in the record. For EventDeleted, it is
+	// the last known state of the release.
+	Release *rspb.Release
+}
+
+// Watcher is the interface implemented by drivers that are notified of
+// changes to their release records.
+//
+// Watch calls handle for every release record created, updated or deleted
+// after Watch was called, until ctx is done or handle returns an error.
+// It returns nil once ctx is done.
+type Watcher interface {
+	Watch(ctx context.Context, handle func(Event) error) error
+}
+
+var watchEventTypes = map[watch.EventType]EventType{
+	watch.Added:    EventCreated,
+	watch.Modified: EventUpdated,
+	watch.Deleted:  EventDeleted,
+}
+
+// watchReleases consumes the watches returned by start, starting at the
+// resource version returned by list, and calls handle for each release record
+// changed. Watches closed by the API server are resumed from the last resource
+// version seen. Once that resource version is too old to resume from, the
+// watch restarts from a fresh list.
+func watchReleases(
+	ctx context.Context,
+	list func() (string, error),
+	start func(rv string) (watch.Interface, error),
+	decode func(obj runtime.Object) (*rspb.Release, error),
+	log func(string, ...interface{}),
+	handle func(Event) error,
+) error {
+	rv, err := list()
+	if err != nil {
+		return errors.Wrap(err, "watch: failed to list")
+	}
+	for {
+		w, err := start(rv)
+		if isExpired(err) {
+			log("watch: resource version %s expired, listing again", rv)
+			if rv, err = list(); err != nil {
+				return errors.Wrap(err, "watch: failed to list")
+			}
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "watch: failed to watch")
+		}
+
+		done, err := func() (bool, error) {
+			defer w.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return true, nil
+				case ev, ok := <-w.ResultChan():
+					if !ok {
+						return false, nil
+					}
+					if ev.Type == watch.Error {
+						err := apierrors.FromObject(ev.Object)
+						if !isExpired(err) {
+							return true, errors.Wrap(err, "watch: failed to watch")
+						}
+						log("watch: resource version %s expired, listing again", rv)
+						if rv, err = list(); err != nil {
+							return true, errors.Wrap(err, "watch: failed to list")
+						}
+						return false, nil
+					}
+					typ, ok := watchEventTypes[ev.Type]
+					if !ok {
+						continue
+					}
+					obj, err := meta.Accessor(ev.Object)
+					if err != nil {
+						log("watch: unexpected object: %s", err)
+						continue
+					}
+					rv = obj.GetResourceVersion()
+
+					rls, err := decode(ev.Object)
+					if err != nil {
+						log("watch: failed to decode release %q: %s", obj.GetName(), err)
+						continue
+					}
+					rls.Labels = obj.GetLabels()
+					if err := handle(Event{Type: typ, Key: obj.GetName(), Release: rls}); err != nil {
+						return true, err
+					}
+				}
+			}
+		}()
+		if done {
+			return err
+		}
+	}
+}
+
+// isExpired returns whether err reports that a watch cannot resume from its
+// resource version anymore.
+func isExpired(err error) bool {
+	return apierrors.IsGone(err) || apierrors.IsResourceExpired(err)
+}
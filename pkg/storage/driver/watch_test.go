@@ -0,0 +1,109 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+)
+
+func TestWatchReleasesExpired(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lists, starts []string
+	list := func() (string, error) {
+		rv := []string{"1", "5", "9"}[len(lists)]
+		lists = append(lists, rv)
+		return rv, nil
+	}
+	watchers := make(chan *watch.RaceFreeFakeWatcher, 3)
+	start := func(rv string) (watch.Interface, error) {
+		starts = append(starts, rv)
+		if len(starts) == 2 {
+			// The resource version was compacted before the watch started.
+			return nil, apierrors.NewResourceExpired("too old resource version")
+		}
+		w := watch.NewRaceFreeFake()
+		watchers <- w
+		return w, nil
+	}
+	decode := func(obj runtime.Object) (*rspb.Release, error) {
+		return decodeRelease(obj.(*v1.ConfigMap).Data["release"])
+	}
+
+	events := make(chan Event, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- watchReleases(ctx, list, start, decode, t.Logf, func(ev Event) error {
+			events <- ev
+			return nil
+		})
+	}()
+
+	// The resource version expires while watching.
+	w := <-watchers
+	w.Error(&apierrors.NewGone("too old resource version").ErrStatus)
+
+	w = <-watchers
+	key := testKey("smug-pigeon", 1)
+	cfgmap, err := newConfigMapsObject(key, releaseStub("smug-pigeon", 1, "default", rspb.StatusDeployed), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Add(cfgmap)
+	select {
+	case ev := <-events:
+		if ev.Type != EventCreated || ev.Key != key {
+			t.Errorf("Expected created event for %s, got %s event for %s", key, ev.Type, ev.Key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the created event")
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("Expected watch to end without error, got %s", err)
+	}
+	if expect := []string{"1", "5", "9"}; len(starts) != 3 || starts[0] != expect[0] || starts[1] != expect[1] || starts[2] != expect[2] {
+		t.Errorf("Expected watches to start from resource versions %v, got %v", expect, starts)
+	}
+}
+
+func TestWatchReleasesError(t *testing.T) {
+	list := func() (string, error) { return "1", nil }
+	start := func(rv string) (watch.Interface, error) {
+		w := watch.NewRaceFreeFake()
+		w.Error(&apierrors.NewForbidden(v1.Resource("configmaps"), "", nil).ErrStatus)
+		return w, nil
+	}
+	decode := func(obj runtime.Object) (*rspb.Release, error) { return nil, nil }
+
+	err := watchReleases(context.Background(), list, start, decode, t.Logf, func(Event) error { return nil })
+	if !apierrors.IsForbidden(errors.Cause(err)) {
+		t.Errorf("Expected a forbidden error, got %v", err)
+	}
+}
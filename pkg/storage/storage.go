@@ -19,9 +19,12 @@ package storage // import "helm.sh/helm/v3/pkg/storage"
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/pkg/errdefs"
+	"helm.sh/helm/v3/pkg/logging"
 	rspb "helm.sh/helm/v3/pkg/release"
 	relutil "helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/storage/driver"
@@ -43,14 +46,45 @@ type Storage struct {
 	// ignored (meaning no limits are imposed).
 	MaxHistory int
 
+	// MaxHistoryAge specifies how long historical releases are retained after
+	// they were last deployed. Older releases are pruned on every write, as long
+	// as at least MinHistory releases remain. Values of 0 or less are ignored
+	// (meaning releases never expire).
+	MaxHistoryAge time.Duration
+
+	// MinHistory specifies the minimum number of releases, including the most
+	// recent release, that are retained when pruning releases by age.
+	MinHistory int
+
+	// PollInterval is how often Watch lists the releases of drivers that
+	// cannot watch their release records. Values of 0 or less mean
+	// DefaultPollInterval.
+	PollInterval time.Duration
+
+	// Logger receives the diagnostic output of the storage. If nil, Log is
+	// used.
+	Logger logging.Logger
+
+	// Log is the printf-style logger used before Logger was introduced.
+	//
+	// Deprecated: set Logger instead. Log is only used if Logger is nil.
 	Log func(string, ...interface{})
 }
 
+// logger returns the logger of the storage, wrapping the legacy Log
+// function if Logger is not set.
+func (s *Storage) logger() logging.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return logging.FromFunc(s.Log)
+}
+
 // Get retrieves the release from storage. An error is returned
 // if the storage driver failed to fetch the release, or the
 // release identified by the key, version pair does not exist.
 func (s *Storage) Get(name string, version int) (*rspb.Release, error) {
-	s.Log("getting release %q", makeKey(name, version))
+	s.logger().Debug("getting release", "key", makeKey(name, version))
 	return s.Driver.Get(makeKey(name, version))
 }
 
@@ -58,7 +92,7 @@ func (s *Storage) Get(name string, version int) (*rspb.Release, error) {
 // error is returned if the storage driver fails to store the
 // release, or a release with an identical key already exists.
 func (s *Storage) Create(rls *rspb.Release) error {
-	s.Log("creating release %q", makeKey(rls.Name, rls.Version))
+	s.logger().Debug("creating release", "key", makeKey(rls.Name, rls.Version))
 	if s.MaxHistory > 0 {
 		// Want to make space for one more release.
 		if err := s.removeLeastRecent(rls.Name, s.MaxHistory-1); err != nil &&
@@ -66,6 +100,11 @@ func (s *Storage) Create(rls *rspb.Release) error {
 			return err
 		}
 	}
+	if s.MaxHistoryAge > 0 {
+		if err := s.removeExpired(rls); err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+			return err
+		}
+	}
 	return s.Driver.Create(makeKey(rls.Name, rls.Version), rls)
 }
 
@@ -73,7 +112,12 @@ func (s *Storage) Create(rls *rspb.Release) error {
 // storage backend fails to update the release or if the release
 // does not exist.
 func (s *Storage) Update(rls *rspb.Release) error {
-	s.Log("updating release %q", makeKey(rls.Name, rls.Version))
+	s.logger().Debug("updating release", "key", makeKey(rls.Name, rls.Version))
+	if s.MaxHistoryAge > 0 {
+		if err := s.removeExpired(rls); err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+			return err
+		}
+	}
 	return s.Driver.Update(makeKey(rls.Name, rls.Version), rls)
 }
 
@@ -81,21 +125,28 @@ func (s *Storage) Update(rls *rspb.Release) error {
 // the storage backend fails to delete the release or if the release
 // does not exist.
 func (s *Storage) Delete(name string, version int) (*rspb.Release, error) {
-	s.Log("deleting release %q", makeKey(name, version))
+	s.logger().Debug("deleting release", "key", makeKey(name, version))
 	return s.Driver.Delete(makeKey(name, version))
 }
 
 // ListReleases returns all releases from storage. An error is returned if the
 // storage backend fails to retrieve the releases.
 func (s *Storage) ListReleases() ([]*rspb.Release, error) {
-	s.Log("listing all releases in storage")
+	s.logger().Debug("listing all releases in storage")
 	return s.Driver.List(func(_ *rspb.Release) bool { return true })
 }
 
+// ListPage returns the page of releases matching opts. Drivers that cannot
+// page releases themselves are paged over all their releases.
+func (s *Storage) ListPage(opts driver.ListOptions) (*driver.ListResult, error) {
+	s.logger().Debug("listing a page of releases in storage")
+	return driver.ListPage(s.Driver, opts)
+}
+
 // ListUninstalled returns all releases with Status == UNINSTALLED. An error is returned
 // if the storage backend fails to retrieve the releases.
 func (s *Storage) ListUninstalled() ([]*rspb.Release, error) {
-	s.Log("listing uninstalled releases in storage")
+	s.logger().Debug("listing uninstalled releases in storage")
 	return s.Driver.List(func(rls *rspb.Release) bool {
 		return relutil.StatusFilter(rspb.StatusUninstalled).Check(rls)
 	})
@@ -104,7 +155,7 @@ func (s *Storage) ListUninstalled() ([]*rspb.Release, error) {
 // ListDeployed returns all releases with Status == DEPLOYED. An error is returned
 // if the storage backend fails to retrieve the releases.
 func (s *Storage) ListDeployed() ([]*rspb.Release, error) {
-	s.Log("listing all deployed releases in storage")
+	s.logger().Debug("listing all deployed releases in storage")
 	return s.Driver.List(func(rls *rspb.Release) bool {
 		return relutil.StatusFilter(rspb.StatusDeployed).Check(rls)
 	})
@@ -132,7 +183,7 @@ func (s *Storage) Deployed(name string) (*rspb.Release, error) {
 // DeployedAll returns all deployed releases with the provided name, or
 // returns ErrReleaseNotFound if not found.
 func (s *Storage) DeployedAll(name string) ([]*rspb.Release, error) {
-	s.Log("getting deployed releases from %q history", name)
+	s.logger().Debug("getting deployed releases from history", "release", name)
 
 	ls, err := s.Driver.Query(map[string]string{
 		"name":   name,
@@ -151,7 +202,7 @@ func (s *Storage) DeployedAll(name string) ([]*rspb.Release, error) {
 // History returns the revision history for the release with the provided name, or
 // returns ErrReleaseNotFound if no such release name exists.
 func (s *Storage) History(name string) ([]*rspb.Release, error) {
-	s.Log("getting release history for %q", name)
+	s.logger().Debug("getting release history", "release", name)
 
 	return s.Driver.Query(map[string]string{"name": name, "owner": "helm"})
 }
@@ -187,18 +238,76 @@ func (s *Storage) removeLeastRecent(name string, max int) error {
 		if len(h)-len(toDelete) == max {
 			break
 		}
-		if lastDeployed != nil {
-			if rel.Version != lastDeployed.Version {
-				toDelete = append(toDelete, rel)
-			}
-		} else {
+		if !isProtected(rel, lastDeployed) {
 			toDelete = append(toDelete, rel)
 		}
 	}
 
+	return s.deleteReleaseVersions(name, toDelete)
+}
+
+// removeExpired removes the releases of rls.Name that were last deployed
+// longer than MaxHistoryAge ago, keeping at least MinHistory releases
+// including rls itself. The last deployed release and pinned releases are
+// never removed.
+func (s *Storage) removeExpired(rls *rspb.Release) error {
+	h, err := s.History(rls.Name)
+	if err != nil {
+		return err
+	}
+
+	// We want oldest to newest
+	relutil.SortByRevision(h)
+
+	lastDeployed, err := s.Deployed(rls.Name)
+	if err != nil && !errors.Is(err, driver.ErrNoDeployedReleases) {
+		return err
+	}
+
+	// rls is retained whether or not it is already stored.
+	remaining := 1
+	for _, rel := range h {
+		if rel.Version != rls.Version {
+			remaining++
+		}
+	}
+
+	cutoff := time.Now().Add(-s.MaxHistoryAge)
+	var toDelete []*rspb.Release
+	for _, rel := range h {
+		if remaining <= s.MinHistory {
+			break
+		}
+		if rel.Version == rls.Version || isProtected(rel, lastDeployed) {
+			continue
+		}
+		if rel.Info == nil || rel.Info.LastDeployed.IsZero() || !rel.Info.LastDeployed.Time.Before(cutoff) {
+			continue
+		}
+		toDelete = append(toDelete, rel)
+		remaining--
+	}
+	if len(toDelete) == 0 {
+		return nil
+	}
+
+	return s.deleteReleaseVersions(rls.Name, toDelete)
+}
+
+// isProtected reports whether rel must be kept when pruning the release history.
+func isProtected(rel, lastDeployed *rspb.Release) bool {
+	if lastDeployed != nil && rel.Version == lastDeployed.Version {
+		return true
+	}
+	return rel.Info != nil && rel.Info.Pinned
+}
+
+// deleteReleaseVersions removes the given revisions of the named release.
+func (s *Storage) deleteReleaseVersions(name string, toDelete []*rspb.Release) error {
 	// Delete as many as possible. In the case of API throughput limitations,
 	// multiple invocations of this function will eventually delete them all.
 	errs := []error{}
+	var err error
 	for _, rel := range toDelete {
 		err = s.deleteReleaseVersion(name, rel.Version)
 		if err != nil {
@@ -206,7 +315,7 @@ func (s *Storage) removeLeastRecent(name string, max int) error {
 		}
 	}
 
-	s.Log("Pruned %d record(s) from %s with %d error(s)", len(toDelete), name, len(errs))
+	s.logger().Debug("pruned release history", "release", name, "records", len(toDelete), "errors", len(errs))
 	switch c := len(errs); c {
 	case 0:
 		return nil
@@ -221,7 +330,7 @@ func (s *Storage) deleteReleaseVersion(name string, version int) error {
 	key := makeKey(name, version)
 	_, err := s.Delete(name, version)
 	if err != nil {
-		s.Log("error pruning %s from release history: %s", key, err)
+		s.logger().Warn("failed to prune release history", "key", key, "error", err)
 		return err
 	}
 	return nil
@@ -229,13 +338,13 @@ func (s *Storage) deleteReleaseVersion(name string, version int) error {
 
 // Last fetches the last revision of the named release.
 func (s *Storage) Last(name string) (*rspb.Release, error) {
-	s.Log("getting last revision of %q", name)
+	s.logger().Debug("getting last revision", "release", name)
 	h, err := s.History(name)
 	if err != nil {
 		return nil, err
 	}
 	if len(h) == 0 {
-		return nil, errors.Errorf("no revision for release %q", name)
+		return nil, errdefs.Wrap(errdefs.TypeReleaseNotFound, errors.Errorf("no revision for release %q", name), "release", name)
 	}
 
 	relutil.Reverse(h, relutil.SortByRevision)
@@ -20,11 +20,13 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 
 	rspb "helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
 )
 
 func TestStorageCreate(t *testing.T) {
@@ -412,6 +414,156 @@ func TestStorageDoNotDeleteDeployed(t *testing.T) {
 	}
 }
 
+func TestStorageDoNotDeletePinned(t *testing.T) {
+	storage := Init(driver.NewMemory())
+	storage.Log = t.Logf
+	storage.MaxHistory = 3
+
+	const name = "angry-bird"
+
+	// setup storage with test releases
+	setup := func() {
+		// release records
+		rls0 := ReleaseTestData{Name: name, Version: 1, Status: rspb.StatusSuperseded}.ToRelease()
+		rls0.Info.Pinned = true
+		rls1 := ReleaseTestData{Name: name, Version: 2, Status: rspb.StatusSuperseded}.ToRelease()
+		rls2 := ReleaseTestData{Name: name, Version: 3, Status: rspb.StatusDeployed}.ToRelease()
+
+		// create the release records in the storage
+		assertErrNil(t.Fatal, storage.Create(rls0), "Storing release 'angry-bird' (v1)")
+		assertErrNil(t.Fatal, storage.Create(rls1), "Storing release 'angry-bird' (v2)")
+		assertErrNil(t.Fatal, storage.Create(rls2), "Storing release 'angry-bird' (v3)")
+	}
+	setup()
+
+	rls4 := ReleaseTestData{Name: name, Version: 4, Status: rspb.StatusDeployed}.ToRelease()
+	assertErrNil(t.Fatal, storage.Create(rls4), "Storing release 'angry-bird' (v4)")
+
+	// On inserting the 4th record, we expect version 2 to be pruned instead
+	// of the older, pinned version 1.
+	hist, err := storage.History(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedVersions := map[int]bool{
+		1: true,
+		3: true,
+		4: true,
+	}
+	if len(hist) != len(expectedVersions) {
+		t.Fatalf("expected %d items in history, got %d", len(expectedVersions), len(hist))
+	}
+	for _, item := range hist {
+		if !expectedVersions[item.Version] {
+			t.Errorf("Release version %d, found when not expected", item.Version)
+		}
+	}
+}
+
+func TestStorageRemoveExpired(t *testing.T) {
+	storage := Init(driver.NewMemory())
+	storage.Log = t.Logf
+	storage.MaxHistoryAge = 30 * 24 * time.Hour
+	storage.MinHistory = 4
+
+	const name = "angry-bird"
+	now := helmtime.Now()
+	daysAgo := func(days int) helmtime.Time { return now.AddDate(0, 0, -days) }
+
+	// setup storage with test releases
+	setup := func() {
+		// release records
+		rls := []*rspb.Release{
+			ReleaseTestData{Name: name, Version: 1, Status: rspb.StatusSuperseded}.ToRelease(),
+			ReleaseTestData{Name: name, Version: 2, Status: rspb.StatusSuperseded}.ToRelease(),
+			ReleaseTestData{Name: name, Version: 3, Status: rspb.StatusSuperseded}.ToRelease(),
+			ReleaseTestData{Name: name, Version: 4, Status: rspb.StatusSuperseded}.ToRelease(),
+			ReleaseTestData{Name: name, Version: 5, Status: rspb.StatusDeployed}.ToRelease(),
+		}
+		rls[0].Info.LastDeployed = daysAgo(90)
+		rls[1].Info.LastDeployed = daysAgo(60)
+		rls[1].Info.Pinned = true
+		rls[2].Info.LastDeployed = daysAgo(45)
+		rls[3].Info.LastDeployed = daysAgo(40)
+		rls[4].Info.LastDeployed = daysAgo(35)
+
+		// create the release records in the storage
+		for _, r := range rls {
+			assertErrNil(t.Fatal, storage.Driver.Create(makeKey(r.Name, r.Version), r), "Storing release")
+		}
+	}
+	setup()
+
+	rls6 := ReleaseTestData{Name: name, Version: 6, Status: rspb.StatusPendingUpgrade}.ToRelease()
+	rls6.Info.LastDeployed = now
+	assertErrNil(t.Fatal, storage.Create(rls6), "Storing release 'angry-bird' (v6)")
+
+	// Everything is older than 30 days, but version 2 is pinned, version 5 is
+	// the last deployed release and at least 4 releases must be kept, so only
+	// versions 1 and 3 are pruned.
+	hist, err := storage.History(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedVersions := map[int]bool{
+		2: true,
+		4: true,
+		5: true,
+		6: true,
+	}
+	if len(hist) != len(expectedVersions) {
+		for _, item := range hist {
+			t.Logf("%s %v", item.Name, item.Version)
+		}
+		t.Fatalf("expected %d items in history, got %d", len(expectedVersions), len(hist))
+	}
+	for _, item := range hist {
+		if !expectedVersions[item.Version] {
+			t.Errorf("Release version %d, found when not expected", item.Version)
+		}
+	}
+
+	// Updates also apply the retention policy.
+	storage.MinHistory = 0
+	rls6.Info.Status = rspb.StatusDeployed
+	assertErrNil(t.Fatal, storage.Update(rls6), "Updating release 'angry-bird' (v6)")
+
+	hist, err = storage.History(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != 3 {
+		for _, item := range hist {
+			t.Logf("%s %v", item.Name, item.Version)
+		}
+		t.Fatalf("expected 3 items in history, got %d", len(hist))
+	}
+}
+
+// queryCounter counts the queries of a driver.
+type queryCounter struct {
+	driver.Driver
+	queries int
+}
+
+func (d *queryCounter) Query(labels map[string]string) ([]*rspb.Release, error) {
+	d.queries++
+	return d.Driver.Query(labels)
+}
+
+func TestStorageNoHistoryQueryWithoutLimits(t *testing.T) {
+	d := &queryCounter{Driver: driver.NewMemory()}
+	storage := Init(d)
+	storage.Log = t.Logf
+
+	rls := ReleaseTestData{Name: "angry-bird", Version: 1, Status: rspb.StatusDeployed}.ToRelease()
+	assertErrNil(t.Fatal, storage.Create(rls), "Storing release 'angry-bird' (v1)")
+	assertErrNil(t.Fatal, storage.Update(rls), "Updating release 'angry-bird' (v1)")
+	if d.queries != 0 {
+		t.Errorf("expected no history query without --history-max or --history-max-age, got %d", d.queries)
+	}
+}
+
 func TestStorageLast(t *testing.T) {
 	storage := Init(driver.NewMemory())
 
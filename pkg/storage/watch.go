@@ -0,0 +1,136 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage // import "helm.sh/helm/v3/pkg/storage"
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// DefaultPollInterval is how often Watch lists the releases of drivers that
+// cannot watch their release records, unless Storage.PollInterval is set.
+const DefaultPollInterval = 5 * time.Second
+
+// Watch calls handle for every release created, updated or deleted after
+// Watch was called, until ctx is done or handle returns an error. It returns
+// nil once ctx is done.
+//
+// Drivers implementing driver.Watcher are notified of changes by their
+// backend. Other drivers are polled every PollInterval.
+func (s *Storage) Watch(ctx context.Context, handle func(driver.Event) error) error {
+	if w, ok := s.Driver.(driver.Watcher); ok {
+		s.logger().Debug("watching releases")
+		return w.Watch(ctx, handle)
+	}
+
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	s.logger().Debug("watching releases", "pollInterval", interval)
+
+	known, err := s.snapshot()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := s.snapshot()
+		if err != nil {
+			return err
+		}
+		for _, ev := range diffSnapshots(known, current) {
+			if err := handle(ev); err != nil {
+				return err
+			}
+		}
+		known = current
+	}
+}
+
+// snapshotEntry is a release as seen by a single poll. The release info and
+// labels are copied, as drivers may hand out releases that are later changed
+// in place.
+type snapshotEntry struct {
+	rls    *rspb.Release
+	info   rspb.Info
+	labels map[string]string
+}
+
+// snapshot returns the releases currently stored, by namespace and key.
+func (s *Storage) snapshot() (map[string]snapshotEntry, error) {
+	rls, err := s.Driver.List(func(_ *rspb.Release) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	snap := make(map[string]snapshotEntry, len(rls))
+	for _, r := range rls {
+		var info rspb.Info
+		if r.Info != nil {
+			info = *r.Info
+		}
+		labels := make(map[string]string, len(r.Labels))
+		for k, v := range r.Labels {
+			labels[k] = v
+		}
+		snap[r.Namespace+"/"+makeKey(r.Name, r.Version)] = snapshotEntry{rls: r, info: info, labels: labels}
+	}
+	return snap, nil
+}
+
+// diffSnapshots returns the events turning known into current, ordered by
+// namespace and key.
+func diffSnapshots(known, current map[string]snapshotEntry) []driver.Event {
+	ids := make([]string, 0, len(current))
+	for id := range current {
+		ids = append(ids, id)
+	}
+	for id := range known {
+		if _, ok := current[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var events []driver.Event
+	for _, id := range ids {
+		old, existed := known[id]
+		cur, exists := current[id]
+		switch {
+		case !existed:
+			events = append(events, driver.Event{Type: driver.EventCreated, Key: makeKey(cur.rls.Name, cur.rls.Version), Release: cur.rls})
+		case !exists:
+			events = append(events, driver.Event{Type: driver.EventDeleted, Key: makeKey(old.rls.Name, old.rls.Version), Release: old.rls})
+		case !reflect.DeepEqual(old.info, cur.info) || !reflect.DeepEqual(old.labels, cur.labels):
+			events = append(events, driver.Event{Type: driver.EventUpdated, Key: makeKey(cur.rls.Name, cur.rls.Version), Release: cur.rls})
+		}
+	}
+	return events
+}
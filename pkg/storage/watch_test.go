@@ -0,0 +1,128 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage // import "helm.sh/helm/v3/pkg/storage"
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	rspb "helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// listNotifier signals every time the releases are listed.
+type listNotifier struct {
+	*driver.Memory
+	listed chan struct{}
+}
+
+func (d *listNotifier) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	defer func() {
+		select {
+		case d.listed <- struct{}{}:
+		default:
+		}
+	}()
+	return d.Memory.List(filter)
+}
+
+func TestStorageWatchPolling(t *testing.T) {
+	d := &listNotifier{Memory: driver.NewMemory(), listed: make(chan struct{}, 1)}
+	storage := Init(d)
+	storage.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan driver.Event, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- storage.Watch(ctx, func(ev driver.Event) error {
+			events <- ev
+			return nil
+		})
+	}()
+	<-d.listed
+
+	expectEvent := func(typ driver.EventType, status rspb.Status) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Type != typ || ev.Key != "sh.helm.release.v1.angry-beaver.v1" || ev.Release.Info.Status != status {
+				t.Errorf("Expected %s event for %s release, got %s event for %s %s release", typ, status, ev.Type, ev.Key, ev.Release.Info.Status)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s event", typ)
+		}
+	}
+
+	rls := ReleaseTestData{Name: "angry-beaver", Version: 1, Status: rspb.StatusDeployed}.ToRelease()
+	assertErrNil(t.Fatal, storage.Create(rls), "StoreRelease")
+	expectEvent(driver.EventCreated, rspb.StatusDeployed)
+
+	rls = ReleaseTestData{Name: "angry-beaver", Version: 1, Status: rspb.StatusSuperseded}.ToRelease()
+	assertErrNil(t.Fatal, storage.Update(rls), "UpdateRelease")
+	expectEvent(driver.EventUpdated, rspb.StatusSuperseded)
+
+	// Changing only the labels of a release is reported too.
+	rls = ReleaseTestData{Name: "angry-beaver", Version: 1, Status: rspb.StatusSuperseded}.ToRelease()
+	rls.Labels = map[string]string{"team": "storage"}
+	assertErrNil(t.Fatal, storage.Update(rls), "UpdateRelease")
+	expectEvent(driver.EventUpdated, rspb.StatusSuperseded)
+
+	_, err := storage.Delete("angry-beaver", 1)
+	assertErrNil(t.Fatal, err, "DeleteRelease")
+	expectEvent(driver.EventDeleted, rspb.StatusSuperseded)
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("Expected watch to end without error, got %s", err)
+	}
+}
+
+func TestStorageWatchHandlerError(t *testing.T) {
+	storage := Init(driver.NewMemory())
+	storage.PollInterval = 10 * time.Millisecond
+
+	errStop := errors.New("stop")
+	done := make(chan error, 1)
+	go func() {
+		done <- storage.Watch(context.Background(), func(_ driver.Event) error { return errStop })
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for version := 1; ; version++ {
+		// The release may be created before the first poll, so create new
+		// revisions until the watch reports one.
+		rls := ReleaseTestData{Name: "angry-beaver", Version: version, Status: rspb.StatusDeployed}.ToRelease()
+		assertErrNil(t.Fatal, storage.Create(rls), "StoreRelease")
+
+		select {
+		case err := <-done:
+			if err != errStop {
+				t.Errorf("Expected watch to return the handler error, got %v", err)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("Timed out waiting for the watch to end")
+		}
+	}
+}
@@ -26,6 +26,15 @@ The above is equivalent to the YAML document
 	topname:
 	  subname: value
 
+Lines parsed with ParseJSON have JSON values, which may be objects or arrays:
+
+	name={"key": [1, 2]},topname.subname="value"
+
+Lines parsed with ParseLiteral have a single key, and the value is the rest of
+the line, taken as is:
+
+	topname.subname=a,b\c
+
 This package provides a parser and utilities for converting the strvals format
 to other formats.
 */
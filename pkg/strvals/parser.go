@@ -17,8 +17,10 @@ package strvals
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"strconv"
 	"strings"
 
@@ -103,6 +105,42 @@ func ParseIntoFile(s string, dest map[string]interface{}, reader RunesValueReade
 	return t.parse()
 }
 
+// ParseJSON parses a set line with JSON values and merges the result into
+// dest.
+//
+// A set line is of the form name1=jsonval1,name2=jsonval2. A JSON object is
+// merged into the map already at its key; any other JSON value replaces the
+// value at its key.
+func ParseJSON(s string, dest map[string]interface{}) error {
+	scanner := bytes.NewBufferString(s)
+	t := newJSONParser(scanner, dest)
+	return t.parse()
+}
+
+// ParseLiteral parses a set line with a literal value.
+//
+// A set line is of the form name=value. The value is everything after the
+// first '=' following the key, with no escaping, splitting on commas or type
+// inference.
+func ParseLiteral(s string) (map[string]interface{}, error) {
+	vals := map[string]interface{}{}
+	scanner := bytes.NewBufferString(s)
+	t := newLiteralParser(scanner, vals)
+	err := t.parse()
+	return vals, err
+}
+
+// ParseLiteralInto parses a set line with a literal value and merges the
+// result into dest.
+//
+// If the set line has a key that exists in dest, it overwrites the dest
+// version.
+func ParseLiteralInto(s string, dest map[string]interface{}) error {
+	scanner := bytes.NewBufferString(s)
+	t := newLiteralParser(scanner, dest)
+	return t.parse()
+}
+
 // RunesValueReader is a function that takes the given value (a slice of runes)
 // and returns the parsed value
 type RunesValueReader func([]rune) (interface{}, error)
@@ -116,6 +154,10 @@ type parser struct {
 	sc     *bytes.Buffer
 	data   map[string]interface{}
 	reader RunesValueReader
+	// isjsonval is set if the values are JSON.
+	isjsonval bool
+	// isliteral is set if the value is the rest of the line, as is.
+	isliteral bool
 }
 
 func newParser(sc *bytes.Buffer, data map[string]interface{}, stringBool bool) *parser {
@@ -129,6 +171,14 @@ func newFileParser(sc *bytes.Buffer, data map[string]interface{}, reader RunesVa
 	return &parser{sc: sc, data: data, reader: reader}
 }
 
+func newJSONParser(sc *bytes.Buffer, data map[string]interface{}) *parser {
+	return &parser{sc: sc, data: data, isjsonval: true}
+}
+
+func newLiteralParser(sc *bytes.Buffer, data map[string]interface{}) *parser {
+	return &parser{sc: sc, data: data, isliteral: true}
+}
+
 func (t *parser) parse() error {
 	for {
 		err := t.key(t.data)
@@ -184,6 +234,20 @@ func (t *parser) key(data map[string]interface{}) (reterr error) {
 			set(data, kk, list)
 			return err
 		case last == '=':
+			if t.isjsonval || t.isliteral {
+				v, err := t.rawVal(string(k))
+				if err != nil {
+					return err
+				}
+				if m, ok := v.(map[string]interface{}); ok && t.isjsonval {
+					if dm, ok := data[string(k)].(map[string]interface{}); ok {
+						v = mergeMaps(dm, m)
+					}
+				}
+				set(data, string(k), v)
+				return nil
+			}
+
 			//End of key. Consume =, Get value.
 			// FIXME: Get value list first
 			vl, e := t.valList()
@@ -280,6 +344,18 @@ func (t *parser) listItem(list []interface{}, i int) ([]interface{}, error) {
 	case err != nil:
 		return list, err
 	case last == '=':
+		if t.isjsonval || t.isliteral {
+			v, err := t.rawVal(fmt.Sprintf("[%d]", i))
+			if err != nil {
+				return list, err
+			}
+			if m, ok := v.(map[string]interface{}); ok && t.isjsonval && len(list) > i {
+				if dm, ok := list[i].(map[string]interface{}); ok {
+					v = mergeMaps(dm, m)
+				}
+			}
+			return setIndex(list, i, v)
+		}
 		vl, e := t.valList()
 		switch e {
 		case nil:
@@ -343,6 +419,75 @@ func (t *parser) listItem(list []interface{}, i int) ([]interface{}, error) {
 	}
 }
 
+// rawVal reads the value of a key of a JSON or literal set line. A literal
+// value is the rest of the line. A JSON value is decoded, and may be followed
+// by a comma and another key; an empty JSON value is null.
+func (t *parser) rawVal(key string) (interface{}, error) {
+	if t.isliteral {
+		v := t.sc.String()
+		t.sc.Reset()
+		return v, nil
+	}
+
+	if end, err := t.endOfVal(); end || err != nil {
+		return nil, err
+	}
+	// The decoder reads ahead of the value it decodes, so it decodes a copy
+	// of the rest of the line, and the characters of the value are discarded
+	// after.
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(t.sc.String()))
+	if err := dec.Decode(&v); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the JSON value of key %q", key)
+	}
+	if _, err := io.CopyN(ioutil.Discard, t.sc, dec.InputOffset()); err != nil {
+		return nil, err
+	}
+	end, err := t.endOfVal()
+	if err != nil {
+		return nil, err
+	}
+	if !end {
+		return nil, errors.Errorf("unexpected data after the JSON value of key %q", key)
+	}
+	return v, nil
+}
+
+// endOfVal skips blanks, and returns whether they are followed by the end of
+// the line or a comma, which it consumes.
+func (t *parser) endOfVal() (bool, error) {
+	for {
+		r, _, err := t.sc.ReadRune()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch r {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case ',':
+			return true, nil
+		}
+		return false, t.sc.UnreadRune()
+	}
+}
+
+// mergeMaps merges src into dst, recursing into the maps both have.
+func mergeMaps(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		if v, ok := v.(map[string]interface{}); ok {
+			if dv, ok := dst[k].(map[string]interface{}); ok {
+				dst[k] = mergeMaps(dv, v)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
 func (t *parser) val() ([]rune, error) {
 	stop := runeSet([]rune{','})
 	v, _, err := runesUntil(t.sc, stop)
@@ -633,6 +633,188 @@ func TestParseIntoFile(t *testing.T) {
 	}
 }
 
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		input  string
+		got    map[string]interface{}
+		expect map[string]interface{}
+		err    bool
+	}{
+		{ // set json scalars values, and replace one existing key
+			input: "outer.inner1=\"1\",outer.inner3=3,outer.inner4=true,outer.inner5=\"true\"",
+			got: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"inner1": "overwrite",
+					"inner2": "value2",
+				},
+			},
+			expect: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"inner1": "1",
+					"inner2": "value2",
+					"inner3": 3,
+					"inner4": true,
+					"inner5": "true",
+				},
+			},
+		},
+		{ // set json objects and arrays, and merge an object into an existing map
+			input: `outer={"inner1": {"a": 1}, "inner3": [{"b": 2}]},list[1]={"c": [3, "x,y"]}`,
+			got: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"inner1": map[string]interface{}{"z": 0},
+					"inner2": "value2",
+				},
+			},
+			expect: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"inner1": map[string]interface{}{"a": 1, "z": 0},
+					"inner2": "value2",
+					"inner3": []interface{}{map[string]interface{}{"b": 2}},
+				},
+				"list": []interface{}{nil, map[string]interface{}{"c": []interface{}{3, "x,y"}}},
+			},
+		},
+		{ // a json array replaces an existing list, an empty value is null
+			input: "list=[1,2],outer=",
+			got: map[string]interface{}{
+				"list":  []interface{}{"a", "b", "c"},
+				"outer": "value",
+			},
+			expect: map[string]interface{}{
+				"list":  []interface{}{1, 2},
+				"outer": nil,
+			},
+		},
+		{
+			input: "outer={\"inner\": ",
+			got:   map[string]interface{}{},
+			err:   true,
+		},
+		{
+			input: "outer=\"a\" b",
+			got:   map[string]interface{}{},
+			err:   true,
+		},
+		{
+			input: "outer=value",
+			got:   map[string]interface{}{},
+			err:   true,
+		},
+	}
+	for _, tt := range tests {
+		if err := ParseJSON(tt.input, tt.got); err != nil {
+			if tt.err {
+				continue
+			}
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+		if tt.err {
+			t.Fatalf("%s: Expected error. Got nil", tt.input)
+		}
+
+		y1, err := yaml.Marshal(tt.expect)
+		if err != nil {
+			t.Fatalf("Error serializing expected value: %s", err)
+		}
+		y2, err := yaml.Marshal(tt.got)
+		if err != nil {
+			t.Fatalf("Error serializing parsed value: %s", err)
+		}
+
+		if string(y1) != string(y2) {
+			t.Errorf("%s: Expected:\n%s\nGot:\n%s", tt.input, y1, y2)
+		}
+	}
+}
+
+func TestParseLiteral(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect map[string]interface{}
+		err    bool
+	}{
+		{
+			input:  "name=value",
+			expect: map[string]interface{}{"name": "value"},
+		},
+		{
+			input:  "outer.inner=1,inner2=true",
+			expect: map[string]interface{}{"outer": map[string]interface{}{"inner": "1,inner2=true"}},
+		},
+		{
+			input:  `list[1].name=a\,b{c}=d\e`,
+			expect: map[string]interface{}{"list": []interface{}{nil, map[string]interface{}{"name": `a\,b{c}=d\e`}}},
+		},
+		{
+			input:  "name=",
+			expect: map[string]interface{}{"name": ""},
+		},
+		{
+			input: "name",
+			err:   true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseLiteral(tt.input)
+		if err != nil {
+			if tt.err {
+				continue
+			}
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+		if tt.err {
+			t.Fatalf("%s: Expected error. Got nil", tt.input)
+		}
+
+		y1, err := yaml.Marshal(tt.expect)
+		if err != nil {
+			t.Fatalf("Error serializing expected value: %s", err)
+		}
+		y2, err := yaml.Marshal(got)
+		if err != nil {
+			t.Fatalf("Error serializing parsed value: %s", err)
+		}
+
+		if string(y1) != string(y2) {
+			t.Errorf("%s: Expected:\n%s\nGot:\n%s", tt.input, y1, y2)
+		}
+	}
+}
+
+func TestParseLiteralInto(t *testing.T) {
+	got := map[string]interface{}{
+		"outer": map[string]interface{}{
+			"inner1": "overwrite",
+			"inner2": "value2",
+		},
+	}
+	input := "outer.inner1=null"
+	expect := map[string]interface{}{
+		"outer": map[string]interface{}{
+			"inner1": "null",
+			"inner2": "value2",
+		},
+	}
+
+	if err := ParseLiteralInto(input, got); err != nil {
+		t.Fatal(err)
+	}
+
+	y1, err := yaml.Marshal(expect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y2, err := yaml.Marshal(got)
+	if err != nil {
+		t.Fatalf("Error serializing parsed value: %s", err)
+	}
+
+	if string(y1) != string(y2) {
+		t.Errorf("%s: Expected:\n%s\nGot:\n%s", input, y1, y2)
+	}
+}
+
 func TestToYAML(t *testing.T) {
 	// The TestParse does the hard part. We just verify that YAML formatting is
 	// happening.
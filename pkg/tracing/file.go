@@ -0,0 +1,124 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanRecord is the JSON form of a span written by FileExporter.
+type SpanRecord struct {
+	Name         string                 `json:"name"`
+	TraceID      string                 `json:"traceId"`
+	SpanID       string                 `json:"spanId"`
+	ParentSpanID string                 `json:"parentSpanId,omitempty"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	Duration     string                 `json:"duration"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Events       []EventRecord          `json:"events,omitempty"`
+	// Status is "Unset", "Ok" or "Error".
+	Status string `json:"status"`
+	// Error is the description of an Error status.
+	Error string `json:"error,omitempty"`
+}
+
+// EventRecord is the JSON form of a span event written by FileExporter.
+type EventRecord struct {
+	Name       string                 `json:"name"`
+	Time       time.Time              `json:"time"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// FileExporter is a span exporter writing each span as a line of JSON, so
+// traces can be inspected without a collector.
+type FileExporter struct {
+	mu  sync.Mutex
+	w   io.WriteCloser
+	enc *json.Encoder
+}
+
+var _ sdktrace.SpanExporter = (*FileExporter)(nil)
+
+// NewFileExporter returns an exporter writing spans to w. w is closed when
+// the exporter is shut down.
+func NewFileExporter(w io.WriteCloser) *FileExporter {
+	return &FileExporter{w: w, enc: json.NewEncoder(w)}
+}
+
+// ExportSpans writes spans to the file.
+func (e *FileExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.enc == nil {
+		return nil
+	}
+	for _, s := range spans {
+		if err := e.enc.Encode(newSpanRecord(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown closes the file. Spans exported afterwards are dropped.
+func (e *FileExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.enc == nil {
+		return nil
+	}
+	e.enc = nil
+	return e.w.Close()
+}
+
+func newSpanRecord(s sdktrace.ReadOnlySpan) *SpanRecord {
+	rec := &SpanRecord{
+		Name:       s.Name(),
+		TraceID:    s.SpanContext().TraceID().String(),
+		SpanID:     s.SpanContext().SpanID().String(),
+		Start:      s.StartTime(),
+		End:        s.EndTime(),
+		Duration:   s.EndTime().Sub(s.StartTime()).String(),
+		Attributes: map[string]interface{}{},
+		Status:     s.Status().Code.String(),
+		Error:      s.Status().Description,
+	}
+	if s.Parent().IsValid() {
+		rec.ParentSpanID = s.Parent().SpanID().String()
+	}
+	for _, kv := range s.Attributes() {
+		rec.Attributes[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	for _, ev := range s.Events() {
+		er := EventRecord{Name: ev.Name, Time: ev.Time}
+		if len(ev.Attributes) > 0 {
+			er.Attributes = map[string]interface{}{}
+			for _, kv := range ev.Attributes {
+				er.Attributes[string(kv.Key)] = kv.Value.AsInterface()
+			}
+		}
+		rec.Events = append(rec.Events, er)
+	}
+	return rec
+}
@@ -0,0 +1,134 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package tracing configures the OpenTelemetry tracing of Helm operations.
+
+The action, engine, kube and downloader packages create spans with the
+global OpenTelemetry tracer provider. Spans are discarded unless Setup
+installs an exporter, or an SDK user sets a tracer provider with
+otel.SetTracerProvider.
+*/
+package tracing // import "helm.sh/helm/v3/pkg/tracing"
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"helm.sh/helm/v3/internal/version"
+)
+
+// The exporters supported by Setup.
+const (
+	// ExporterOTLP sends spans to an OpenTelemetry collector over OTLP/HTTP.
+	ExporterOTLP = "otlp"
+	// ExporterFile writes spans to a file, one JSON object per line.
+	ExporterFile = "file"
+	// ExporterStderr writes spans to standard error, one JSON object per
+	// line. Standard output is left to the output of the command.
+	ExporterStderr = "stderr"
+)
+
+// Options configures the exporter installed by Setup.
+type Options struct {
+	// Exporter is one of ExporterOTLP, ExporterFile or ExporterStderr.
+	// Tracing is disabled if it is empty.
+	Exporter string
+	// Endpoint is the host:port of the OTLP collector. If empty, the
+	// OTEL_EXPORTER_OTLP_ENDPOINT environment variable or localhost:4318 is
+	// used.
+	Endpoint string
+	// Insecure disables TLS for the OTLP collector.
+	Insecure bool
+	// File is the path of the file written by ExporterFile. It is appended
+	// to if it exists.
+	File string
+}
+
+// Setup installs a global tracer provider exporting spans as configured by
+// opts. The returned function flushes the pending spans and must be called
+// before the process exits.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case "":
+		return noop, nil
+	case ExporterOTLP:
+		var httpOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, httpOpts...)
+		if err != nil {
+			return noop, errors.Wrap(err, "failed to create the OTLP exporter")
+		}
+		exporter = exp
+	case ExporterFile:
+		if opts.File == "" {
+			return noop, errors.New("the file exporter requires a file")
+		}
+		f, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return noop, errors.Wrap(err, "failed to open the trace file")
+		}
+		exporter = NewFileExporter(f)
+	case ExporterStderr:
+		exporter = NewFileExporter(nopCloser{os.Stderr})
+	default:
+		return noop, errors.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceNameKey.String("helm"),
+		semconv.ServiceVersionKey.String(version.GetVersion()),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// EndSpan records err, if any, as the status of span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
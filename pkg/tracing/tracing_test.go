@@ -0,0 +1,115 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSetupFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "trace.json")
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterFile, File: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := otel.Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttributes(attribute.String("chart", "hello"))
+	EndSpan(child, errors.New("boom"))
+	EndSpan(parent, nil)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	spans := map[string]SpanRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec SpanRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		spans[rec.Name] = rec
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	p, c := spans["parent"], spans["child"]
+	if c.ParentSpanID != p.SpanID || c.TraceID != p.TraceID {
+		t.Errorf("expected child of span %s in trace %s, got parent %s in trace %s", p.SpanID, p.TraceID, c.ParentSpanID, c.TraceID)
+	}
+	if c.Status != "Error" || c.Error != "boom" {
+		t.Errorf("expected the error status, got %q %q", c.Status, c.Error)
+	}
+	if c.Attributes["chart"] != "hello" {
+		t.Errorf("expected the chart attribute, got %v", c.Attributes)
+	}
+	if len(c.Events) != 1 || c.Events[0].Name != "exception" {
+		t.Errorf("expected the error to be recorded as an event, got %v", c.Events)
+	}
+	if p.Status != "Unset" {
+		t.Errorf("expected the unset status, got %q", p.Status)
+	}
+}
+
+func TestSetupErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"unknown exporter", Options{Exporter: "zipkin"}},
+		{"file without path", Options{Exporter: ExporterFile}},
+		{"unwritable file", Options{Exporter: ExporterFile, File: filepath.Join(t.TempDir(), "missing", "trace.json")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), tt.opts)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("expected a no-op shutdown, got %s", err)
+			}
+		})
+	}
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
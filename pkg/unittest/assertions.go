@@ -0,0 +1,319 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unittest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Assertion is an assertion about the documents a test renders. Exactly one
+// of its kinds must be set.
+type Assertion struct {
+	// Template restricts the assertion to the documents of a template,
+	// like templates/deployment.yaml.
+	Template string `json:"template,omitempty"`
+	// DocumentIndex restricts the assertion to one document, counted in the
+	// documents of Template if it is set.
+	DocumentIndex *int `json:"documentIndex,omitempty"`
+	// Not negates the assertion.
+	Not bool `json:"not,omitempty"`
+
+	Equals         *Equals         `json:"equals,omitempty"`
+	MatchRegex     *MatchRegex     `json:"matchRegex,omitempty"`
+	IsKind         *IsKind         `json:"isKind,omitempty"`
+	HasDocuments   *HasDocuments   `json:"hasDocuments,omitempty"`
+	NotExists      *NotExists      `json:"notExists,omitempty"`
+	FailedTemplate *FailedTemplate `json:"failedTemplate,omitempty"`
+	Snapshot       *Snapshot       `json:"snapshot,omitempty"`
+}
+
+// UnmarshalJSON decodes an assertion strictly. A snapshot assertion has no
+// options, so it may be written with a null value, as in "- snapshot:".
+func (a *Assertion) UnmarshalJSON(data []byte) error {
+	// assertion has the fields of Assertion, but not this method.
+	type assertion Assertion
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode((*assertion)(a)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if raw, ok := fields["snapshot"]; ok && a.Snapshot == nil && string(bytes.TrimSpace(raw)) == "null" {
+		a.Snapshot = &Snapshot{}
+	}
+	return nil
+}
+
+// Equals asserts that the value at a path equals a value.
+type Equals struct {
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MatchRegex asserts that the string at a path matches a regular expression.
+type MatchRegex struct {
+	Path    string `json:"path"`
+	Pattern string `json:"pattern"`
+}
+
+// IsKind asserts the kind of the documents.
+type IsKind struct {
+	Of string `json:"of"`
+}
+
+// HasDocuments asserts the number of documents rendered.
+type HasDocuments struct {
+	Count int `json:"count"`
+}
+
+// NotExists asserts that there is no value at a path.
+type NotExists struct {
+	Path string `json:"path"`
+}
+
+// FailedTemplate asserts that rendering fails, optionally with an error
+// containing a message or matching a pattern.
+type FailedTemplate struct {
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	ErrorPattern string `json:"errorPattern,omitempty"`
+}
+
+// Snapshot asserts that the documents are the same as the last time the
+// snapshot was updated.
+type Snapshot struct{}
+
+// document is a document rendered by a template.
+type document struct {
+	// template is the path of the template, relative to the chart.
+	template string
+	content  map[string]interface{}
+}
+
+// evaluation is what an assertion is evaluated against.
+type evaluation struct {
+	docs []document
+	// err is the error rendering the templates.
+	err error
+	// snapshot compares the documents with the snapshot of the assertion.
+	snapshot func(docs []document) error
+}
+
+// kind returns the name of the kind of the assertion.
+func (a *Assertion) kind() (string, error) {
+	var kinds []string
+	for name, set := range map[string]bool{
+		"equals":         a.Equals != nil,
+		"matchRegex":     a.MatchRegex != nil,
+		"isKind":         a.IsKind != nil,
+		"hasDocuments":   a.HasDocuments != nil,
+		"notExists":      a.NotExists != nil,
+		"failedTemplate": a.FailedTemplate != nil,
+		"snapshot":       a.Snapshot != nil,
+	} {
+		if set {
+			kinds = append(kinds, name)
+		}
+	}
+	sort.Strings(kinds)
+	switch len(kinds) {
+	case 0:
+		return "", errors.New("assertion has no kind")
+	case 1:
+	default:
+		return "", errors.Errorf("assertion has more than one kind: %s", strings.Join(kinds, ", "))
+	}
+	if kinds[0] == "snapshot" && a.Not {
+		return "", errors.New("snapshot cannot be negated")
+	}
+	if a.MatchRegex != nil {
+		if _, err := regexp.Compile(a.MatchRegex.Pattern); err != nil {
+			return "", errors.Wrap(err, "matchRegex")
+		}
+	}
+	if a.FailedTemplate != nil && a.FailedTemplate.ErrorPattern != "" {
+		if _, err := regexp.Compile(a.FailedTemplate.ErrorPattern); err != nil {
+			return "", errors.Wrap(err, "failedTemplate")
+		}
+	}
+	return kinds[0], nil
+}
+
+// evaluate returns why the assertion fails, or nil if it holds.
+func (a *Assertion) evaluate(e *evaluation) error {
+	kind, err := a.kind()
+	if err != nil {
+		return err
+	}
+	if a.Not {
+		kind = "not " + kind
+	}
+
+	if a.FailedTemplate != nil {
+		return a.failedTemplate(kind, e.err)
+	}
+	if e.err != nil {
+		return errors.Errorf("%s: rendering failed: %s", kind, e.err)
+	}
+
+	docs := e.docs
+	if a.Template != "" {
+		docs = nil
+		for _, d := range e.docs {
+			if d.template == a.Template {
+				docs = append(docs, d)
+			}
+		}
+	}
+	if a.HasDocuments != nil {
+		if (len(docs) == a.HasDocuments.Count) == a.Not {
+			return errors.Errorf("%s: expected %d documents, got %d", kind, a.HasDocuments.Count, len(docs))
+		}
+		return nil
+	}
+	if a.DocumentIndex != nil {
+		i := *a.DocumentIndex
+		if i < 0 || i >= len(docs) {
+			return errors.Errorf("%s: documentIndex %d is out of range, %d documents rendered", kind, i, len(docs))
+		}
+		docs = docs[i : i+1]
+	}
+	if len(docs) == 0 {
+		return errors.Errorf("%s: no documents rendered", kind)
+	}
+
+	if a.Snapshot != nil {
+		if err := e.snapshot(docs); err != nil {
+			return errors.Wrap(err, kind)
+		}
+		return nil
+	}
+	for _, d := range docs {
+		ok, detail, err := a.check(d.content)
+		if err != nil {
+			return errors.Wrapf(err, "%s: %s", kind, d.template)
+		}
+		if ok == a.Not {
+			if a.Not && a.NotExists == nil {
+				detail = strings.Replace(detail, "expected ", "expected not ", 1)
+			}
+			return errors.Errorf("%s: %s: %s", kind, d.template, detail)
+		}
+	}
+	return nil
+}
+
+// check returns whether the assertion, not negated, holds for a document,
+// and what was expected and found.
+func (a *Assertion) check(doc map[string]interface{}) (bool, string, error) {
+	switch {
+	case a.Equals != nil:
+		v, found, err := lookup(doc, a.Equals.Path)
+		if err != nil {
+			return false, "", err
+		}
+		expected := normalize(a.Equals.Value)
+		detail := fmt.Sprintf("%s: expected %s, got %s", a.Equals.Path, format(expected), formatFound(v, found))
+		return found && reflect.DeepEqual(expected, v), detail, nil
+	case a.MatchRegex != nil:
+		v, found, err := lookup(doc, a.MatchRegex.Path)
+		if err != nil {
+			return false, "", err
+		}
+		detail := fmt.Sprintf("%s: expected to match %q, got %s", a.MatchRegex.Path, a.MatchRegex.Pattern, formatFound(v, found))
+		s, ok := v.(string)
+		if !ok {
+			if found {
+				return false, "", errors.Errorf("%s: expected a string, got %s", a.MatchRegex.Path, format(v))
+			}
+			return false, detail, nil
+		}
+		return regexp.MustCompile(a.MatchRegex.Pattern).MatchString(s), detail, nil
+	case a.IsKind != nil:
+		kind, _ := doc["kind"].(string)
+		return kind == a.IsKind.Of, fmt.Sprintf("expected kind %s, got %s", a.IsKind.Of, kind), nil
+	case a.NotExists != nil:
+		v, found, err := lookup(doc, a.NotExists.Path)
+		if err != nil {
+			return false, "", err
+		}
+		if !found {
+			return true, fmt.Sprintf("%s: expected a value, got nothing", a.NotExists.Path), nil
+		}
+		return false, fmt.Sprintf("%s: expected no value, got %s", a.NotExists.Path, format(v)), nil
+	}
+	return false, "", errors.New("unknown assertion")
+}
+
+func (a *Assertion) failedTemplate(kind string, err error) error {
+	f := a.FailedTemplate
+	if a.Not {
+		if err != nil {
+			return errors.Errorf("%s: expected rendering to succeed, got %s", kind, err)
+		}
+		return nil
+	}
+	if err == nil {
+		return errors.Errorf("%s: expected rendering to fail, it succeeded", kind)
+	}
+	if f.ErrorMessage != "" && !strings.Contains(err.Error(), f.ErrorMessage) {
+		return errors.Errorf("%s: expected an error containing %q, got %s", kind, f.ErrorMessage, err)
+	}
+	if f.ErrorPattern != "" && !regexp.MustCompile(f.ErrorPattern).MatchString(err.Error()) {
+		return errors.Errorf("%s: expected an error matching %q, got %s", kind, f.ErrorPattern, err)
+	}
+	return nil
+}
+
+// normalize converts a value to the types of the values of documents, which
+// are parsed as JSON.
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n interface{}
+	if err := json.Unmarshal(data, &n); err != nil {
+		return v
+	}
+	return n
+}
+
+func format(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func formatFound(v interface{}, found bool) string {
+	if !found {
+		return "nothing"
+	}
+	return format(v)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unittest
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parsePath splits a path like spec.containers[0].image into its keys and
+// indexes. Keys with dots are quoted in brackets, like
+// metadata.annotations["helm.sh/hook"].
+func parsePath(path string) ([]interface{}, error) {
+	var parts []interface{}
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, errors.Errorf("path %q: missing ]", path)
+			}
+			inner := path[i+1 : i+end]
+			if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+				parts = append(parts, inner[1:len(inner)-1])
+			} else {
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, errors.Errorf("path %q: invalid index %q", path, inner)
+				}
+				parts = append(parts, n)
+			}
+			i += end + 1
+		default:
+			j := i
+			for j < len(path) && path[j] != '.' && path[j] != '[' {
+				j++
+			}
+			parts = append(parts, path[i:j])
+			i = j
+		}
+	}
+	return parts, nil
+}
+
+// lookup returns the value at the path in a document, and whether it exists.
+// The empty path is the whole document.
+func lookup(doc interface{}, path string) (interface{}, bool, error) {
+	parts, err := parsePath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	v := doc
+	for _, p := range parts {
+		switch p := p.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false, nil
+			}
+			if v, ok = m[p]; !ok {
+				return nil, false, nil
+			}
+		case int:
+			l, ok := v.([]interface{})
+			if !ok || p >= len(l) {
+				return nil, false, nil
+			}
+			v = l[p]
+		}
+	}
+	return v, true, nil
+}
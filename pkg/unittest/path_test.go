@@ -0,0 +1,74 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unittest
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestLookup(t *testing.T) {
+	var doc interface{}
+	err := yaml.Unmarshal([]byte(`
+metadata:
+  annotations:
+    helm.sh/hook: pre-install
+spec:
+  containers:
+    - name: app
+      ports: [80, 443]
+`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		value interface{}
+		found bool
+		err   string
+	}{
+		{path: `metadata.annotations["helm.sh/hook"]`, value: "pre-install", found: true},
+		{path: `metadata.annotations['helm.sh/hook']`, value: "pre-install", found: true},
+		{path: `spec.containers[0].name`, value: "app", found: true},
+		{path: `spec.containers[0].ports[1]`, value: float64(443), found: true},
+		{path: `spec.containers[1].name`},
+		{path: `spec.containers.name`},
+		{path: `metadata.labels`},
+		{path: ``, value: doc, found: true},
+		{path: `spec.containers[0`, err: `path "spec.containers[0": missing ]`},
+		{path: `spec.containers[x]`, err: `path "spec.containers[x]": invalid index "x"`},
+	}
+	for _, tt := range tests {
+		value, found, err := lookup(doc, tt.path)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: expected error %q, got %v", tt.path, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.path, err)
+			continue
+		}
+		if found != tt.found || !reflect.DeepEqual(value, tt.value) {
+			t.Errorf("%s: expected %v (%t), got %v (%t)", tt.path, tt.value, tt.found, value, found)
+		}
+	}
+}
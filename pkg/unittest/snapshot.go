@@ -0,0 +1,124 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unittest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/internal/diffutil"
+)
+
+// snapshotDir is the directory of the snapshots, next to the suites.
+const snapshotDir = "__snapshot__"
+
+// snapshots are the snapshots of a suite, stored in one file keyed by the
+// test and the number of the snapshot assertion in the test.
+type snapshots struct {
+	filename string
+	update   bool
+	old      map[string]string
+	current  map[string]string
+	seen     map[string]bool
+	// written is the number of snapshots added or updated.
+	written int
+}
+
+// loadSnapshots reads the snapshots of a suite, if there are any.
+func loadSnapshots(suiteFile string, update bool) (*snapshots, error) {
+	name := strings.TrimSuffix(filepath.Base(suiteFile), filepath.Ext(suiteFile)) + ".snap"
+	s := &snapshots{
+		filename: filepath.Join(filepath.Dir(suiteFile), snapshotDir, name),
+		update:   update,
+		old:      map[string]string{},
+		current:  map[string]string{},
+		seen:     map[string]bool{},
+	}
+	data, err := ioutil.ReadFile(s.filename)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &s.old); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse snapshots %s", s.filename)
+	}
+	for k, v := range s.old {
+		s.current[k] = v
+	}
+	return s, nil
+}
+
+// compare compares documents with their snapshot. A missing snapshot is
+// added, and a different one updated if the snapshots are updated.
+func (s *snapshots) compare(key string, docs []document) error {
+	var parts []string
+	for _, d := range docs {
+		data, err := yaml.Marshal(d.content)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, string(data))
+	}
+	rendered := strings.Join(parts, "---\n")
+
+	s.seen[key] = true
+	snapshot, ok := s.current[key]
+	if ok && snapshot == rendered {
+		return nil
+	}
+	if ok && !s.update {
+		diff, err := diffutil.Unified(snapshot, rendered, "snapshot", "rendered")
+		if err != nil {
+			return err
+		}
+		return errors.Errorf("documents differ from the snapshot:\n%s", diff)
+	}
+	s.current[key] = rendered
+	s.written++
+	return nil
+}
+
+// save writes the snapshots if they changed. When the snapshots are updated,
+// those of tests that no longer exist are removed.
+func (s *snapshots) save() error {
+	if s.update {
+		for k := range s.current {
+			if !s.seen[k] {
+				delete(s.current, k)
+			}
+		}
+	}
+	if reflect.DeepEqual(s.old, s.current) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.filename), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(s.current)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.filename, data, 0644)
+}
@@ -0,0 +1,234 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unittest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// Suite is a file of unit tests of the templates of a chart.
+type Suite struct {
+	// Name is the name of the suite. It defaults to the name of the file.
+	Name string `json:"suite,omitempty"`
+	// Templates are the templates the tests render, relative to the chart,
+	// like templates/deployment.yaml. All the templates are rendered if it
+	// is empty.
+	Templates []string `json:"templates,omitempty"`
+	// Values are values files, relative to the suite, merged in order over
+	// the values of the chart.
+	Values []string `json:"values,omitempty"`
+	// Set are values merged over the values files. Keys may be dotted
+	// paths, like image.tag.
+	Set map[string]interface{} `json:"set,omitempty"`
+	// Release overrides the release the templates are rendered for.
+	Release Release `json:"release,omitempty"`
+	// Capabilities override the capabilities of the cluster the templates
+	// are rendered for.
+	Capabilities Capabilities `json:"capabilities,omitempty"`
+	// Tests are the tests of the suite.
+	Tests []*Test `json:"tests"`
+
+	// path is the path of the suite file, relative to the chart.
+	path string
+	// dir is the directory of the suite file.
+	dir string
+}
+
+// Test is a unit test: templates rendered with some values, and assertions
+// about the documents they render.
+type Test struct {
+	// It describes what the test checks, like "should set the image".
+	It string `json:"it"`
+	// Templates, Values, Set, Release and Capabilities override those of
+	// the suite for the test. Values and Set are merged over those of the
+	// suite.
+	Templates    []string               `json:"templates,omitempty"`
+	Values       []string               `json:"values,omitempty"`
+	Set          map[string]interface{} `json:"set,omitempty"`
+	Release      Release                `json:"release,omitempty"`
+	Capabilities Capabilities           `json:"capabilities,omitempty"`
+	// Asserts are the assertions of the test.
+	Asserts []*Assertion `json:"asserts"`
+}
+
+// Release is the release templates are rendered for.
+type Release struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Revision  int    `json:"revision,omitempty"`
+	// Upgrade renders the templates for an upgrade rather than an install.
+	Upgrade bool `json:"upgrade,omitempty"`
+}
+
+// Capabilities are the capabilities of the cluster templates are rendered
+// for.
+type Capabilities struct {
+	// KubeVersion is the version of Kubernetes, like v1.24.0.
+	KubeVersion string `json:"kubeVersion,omitempty"`
+	// APIVersions are available in addition to the default ones.
+	APIVersions []string `json:"apiVersions,omitempty"`
+}
+
+// loadSuite reads a suite file.
+func loadSuite(chartPath, filename string) (*Suite, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s := &Suite{dir: filepath.Dir(filename)}
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, errors.Wrap(err, "unable to parse")
+	}
+	if s.path, err = filepath.Rel(chartPath, filename); err != nil {
+		s.path = filename
+	}
+	s.path = filepath.ToSlash(s.path)
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(filename), "_test.yaml")
+	}
+	for i, t := range s.Tests {
+		if t == nil || t.It == "" {
+			return nil, errors.Errorf("tests[%d]: 'it' is required", i)
+		}
+		for j, a := range t.Asserts {
+			if a == nil {
+				return nil, errors.Errorf("tests[%d].asserts[%d]: assertion is empty", i, j)
+			}
+			if _, err := a.kind(); err != nil {
+				return nil, errors.Wrapf(err, "tests[%d].asserts[%d]", i, j)
+			}
+		}
+	}
+	return s, nil
+}
+
+// values returns the values of a test: the values files and values of the
+// suite, then those of the test, merged in order.
+func (s *Suite) values(t *Test) (map[string]interface{}, error) {
+	vals := map[string]interface{}{}
+	for _, files := range [][]string{s.Values, t.Values} {
+		for _, f := range files {
+			if !filepath.IsAbs(f) {
+				f = filepath.Join(s.dir, f)
+			}
+			fileVals, err := chartutil.ReadValuesFile(f)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to read values file %s", f)
+			}
+			vals = mergeValues(vals, fileVals)
+		}
+	}
+	for _, set := range []map[string]interface{}{s.Set, t.Set} {
+		// Sorted, so that a map is set before the values in it.
+		keys := make([]string, 0, len(set))
+		for k := range set {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			setValue(vals, k, set[k])
+		}
+	}
+	return vals, nil
+}
+
+// release returns the release options of a test.
+func (s *Suite) release(t *Test) chartutil.ReleaseOptions {
+	r := chartutil.ReleaseOptions{Name: "release-name", Namespace: "default", Revision: 1}
+	for _, o := range []Release{s.Release, t.Release} {
+		if o.Name != "" {
+			r.Name = o.Name
+		}
+		if o.Namespace != "" {
+			r.Namespace = o.Namespace
+		}
+		if o.Revision != 0 {
+			r.Revision = o.Revision
+		}
+		r.IsUpgrade = r.IsUpgrade || o.Upgrade
+	}
+	r.IsInstall = !r.IsUpgrade
+	return r
+}
+
+// capabilities returns the capabilities of the cluster of a test.
+func (s *Suite) capabilities(t *Test) (*chartutil.Capabilities, error) {
+	caps := chartutil.DefaultCapabilities.Copy()
+	for _, o := range []Capabilities{s.Capabilities, t.Capabilities} {
+		if o.KubeVersion != "" {
+			kv, err := chartutil.ParseKubeVersion(o.KubeVersion)
+			if err != nil {
+				return nil, err
+			}
+			caps.KubeVersion = *kv
+		}
+		caps.APIVersions = append(caps.APIVersions, o.APIVersions...)
+	}
+	return caps, nil
+}
+
+// templates returns the templates a test renders.
+func (s *Suite) templates(t *Test) []string {
+	if len(t.Templates) > 0 {
+		return t.Templates
+	}
+	return s.Templates
+}
+
+// mergeValues merges src into dst, recursing into the maps both have.
+func mergeValues(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		if v, ok := v.(map[string]interface{}); ok {
+			if dv, ok := dst[k].(map[string]interface{}); ok {
+				dst[k] = mergeValues(dv, v)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// setValue sets the value at a dotted path, creating the maps on the way. A
+// map is merged into the map already there.
+func setValue(vals map[string]interface{}, key string, value interface{}) {
+	keys := strings.Split(key, ".")
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := vals[k].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			vals[k] = next
+		}
+		vals = next
+	}
+	last := keys[len(keys)-1]
+	if v, ok := value.(map[string]interface{}); ok {
+		if dv, ok := vals[last].(map[string]interface{}); ok {
+			vals[last] = mergeValues(dv, v)
+			return
+		}
+	}
+	vals[last] = value
+}
@@ -0,0 +1,334 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package unittest runs the unit tests of the templates of a chart.
+
+Suites are the files tests/*_test.yaml of a chart. Each test renders templates
+with its values, release and capabilities, and asserts about the documents
+they render:
+
+	suite: deployment
+	templates:
+	  - templates/deployment.yaml
+	tests:
+	  - it: should set the image
+	    set:
+	      image.tag: "1.2.3"
+	    asserts:
+	      - isKind:
+	          of: Deployment
+	      - equals:
+	          path: spec.template.spec.containers[0].image
+	          value: nginx:1.2.3
+
+Snapshot assertions compare the documents with those stored in
+tests/__snapshot__ the first time the assertion ran.
+*/
+package unittest // import "helm.sh/helm/v3/pkg/unittest"
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/copystructure"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// TestsDir is the directory of the suites of a chart.
+const TestsDir = "tests"
+
+// Options configure how tests are run.
+type Options struct {
+	// UpdateSnapshots replaces the snapshots that differ from the documents
+	// rendered instead of failing.
+	UpdateSnapshots bool
+}
+
+// Result is the result of the tests of a chart.
+type Result struct {
+	// Chart is the name of the chart.
+	Chart string
+	// Suites are the results of the suites, in the order of their files.
+	Suites []SuiteResult
+	// SnapshotsWritten is the number of snapshots added or updated.
+	SnapshotsWritten int
+}
+
+// SuiteResult is the result of a suite.
+type SuiteResult struct {
+	Name string
+	// Path is the path of the suite file, relative to the chart.
+	Path  string
+	Tests []TestResult
+	// Err is set if the suite could not be run.
+	Err error
+}
+
+// TestResult is the result of a test.
+type TestResult struct {
+	Name     string
+	Duration time.Duration
+	// Failures are why the assertions of the test failed.
+	Failures []string
+}
+
+// Passed returns whether all the assertions of the test held.
+func (r TestResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Passed returns whether the suite ran and all its tests passed.
+func (r SuiteResult) Passed() bool {
+	if r.Err != nil {
+		return false
+	}
+	for _, t := range r.Tests {
+		if !t.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Passed returns whether all the suites passed.
+func (r *Result) Passed() bool {
+	for _, s := range r.Suites {
+		if !s.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Run runs the suites of the chart in the directory chartPath.
+func Run(chartPath string, opts Options) (*Result, error) {
+	ch, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(chartPath, TestsDir, "*_test.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	result := &Result{Chart: ch.Name()}
+	for _, f := range files {
+		s, err := loadSuite(chartPath, f)
+		if err != nil {
+			path, _ := filepath.Rel(chartPath, f)
+			result.Suites = append(result.Suites, SuiteResult{
+				Name: strings.TrimSuffix(filepath.Base(f), "_test.yaml"),
+				Path: filepath.ToSlash(path),
+				Err:  err,
+			})
+			continue
+		}
+		sr, written := runSuite(ch, s, f, opts)
+		result.Suites = append(result.Suites, sr)
+		result.SnapshotsWritten += written
+	}
+	return result, nil
+}
+
+// runSuite runs the tests of a suite, and returns its result and the number
+// of snapshots written.
+func runSuite(ch *chart.Chart, s *Suite, filename string, opts Options) (SuiteResult, int) {
+	result := SuiteResult{Name: s.Name, Path: s.path}
+	snaps, err := loadSnapshots(filename, opts.UpdateSnapshots)
+	if err != nil {
+		result.Err = err
+		return result, 0
+	}
+	for _, t := range s.Tests {
+		start := time.Now()
+		tr := TestResult{Name: t.It}
+		errs, err := runTest(ch, s, t, snaps)
+		if err != nil {
+			tr.Failures = append(tr.Failures, err.Error())
+		}
+		for i, err := range errs {
+			if err != nil {
+				tr.Failures = append(tr.Failures, fmt.Sprintf("asserts[%d] %s", i, err))
+			}
+		}
+		tr.Duration = time.Since(start)
+		result.Tests = append(result.Tests, tr)
+	}
+	if err := snaps.save(); err != nil {
+		result.Err = errors.Wrap(err, "unable to save the snapshots")
+	}
+	return result, snaps.written
+}
+
+// runTest renders the templates of a test and evaluates its assertions. It
+// returns the result of each assertion, or an error if the templates could
+// not be rendered.
+func runTest(ch *chart.Chart, s *Suite, t *Test, snaps *snapshots) ([]error, error) {
+	e, err := render(ch, s, t)
+	if err != nil {
+		return nil, err
+	}
+	errs := make([]error, len(t.Asserts))
+	snapshot := 0
+	for i, a := range t.Asserts {
+		if a.Snapshot != nil {
+			snapshot++
+			key := fmt.Sprintf("%s %d", t.It, snapshot)
+			e.snapshot = func(docs []document) error {
+				return snaps.compare(key, docs)
+			}
+		}
+		errs[i] = a.evaluate(e)
+	}
+	return errs, nil
+}
+
+// copyChart returns a copy of a chart and its dependencies that
+// chartutil.ProcessDependencies can change.
+func copyChart(c *chart.Chart) (*chart.Chart, error) {
+	out := *c
+	if c.Metadata != nil {
+		md := *c.Metadata
+		md.Dependencies = make([]*chart.Dependency, len(c.Metadata.Dependencies))
+		for i, d := range c.Metadata.Dependencies {
+			dep := *d
+			md.Dependencies[i] = &dep
+		}
+		out.Metadata = &md
+	}
+	values, err := copystructure.Copy(c.Values)
+	if err != nil {
+		return nil, err
+	}
+	out.Values, _ = values.(map[string]interface{})
+	deps := make([]*chart.Chart, 0, len(c.Dependencies()))
+	for _, d := range c.Dependencies() {
+		dep, err := copyChart(d)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+	out.SetDependencies(deps...)
+	return &out, nil
+}
+
+// templateNames returns the names of the templates of a chart and its
+// dependencies, as they are rendered.
+func templateNames(c *chart.Chart, prefix string) map[string]bool {
+	names := map[string]bool{}
+	for _, t := range c.Templates {
+		names[prefix+t.Name] = true
+	}
+	for _, d := range c.Dependencies() {
+		for name := range templateNames(d, prefix+"charts/"+d.Name()+"/") {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// render renders the templates of a test. Errors rendering are part of the
+// evaluation, for failedTemplate assertions.
+func render(original *chart.Chart, s *Suite, t *Test) (*evaluation, error) {
+	vals, err := s.values(t)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := s.capabilities(t)
+	if err != nil {
+		return nil, err
+	}
+	e := &evaluation{}
+	// Dependencies are enabled and import values as they would on install,
+	// which changes the chart, so each test has a copy of its own.
+	ch, err := copyChart(original)
+	if err != nil {
+		return nil, err
+	}
+	if err := chartutil.ProcessDependencies(ch, vals); err != nil {
+		e.err = err
+		return e, nil
+	}
+	values, err := chartutil.ToRenderValues(ch, vals, s.release(t), caps)
+	if err != nil {
+		e.err = err
+		return e, nil
+	}
+	out, err := engine.Render(ch, values)
+	if err != nil {
+		e.err = err
+		return e, nil
+	}
+
+	// The files rendered are named after the chart, like
+	// mychart/templates/deployment.yaml.
+	prefix := ch.Name() + "/"
+	var names []string
+	if templates := s.templates(t); len(templates) > 0 {
+		known := templateNames(original, prefix)
+		for _, tpl := range templates {
+			name := prefix + filepath.ToSlash(tpl)
+			if !known[name] {
+				return nil, errors.Errorf("template %s not found", tpl)
+			}
+			// The templates of disabled dependencies render nothing.
+			if _, ok := out[name]; ok {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for name := range out {
+			if strings.HasPrefix(name, prefix+"templates/") && !strings.HasSuffix(name, "NOTES.txt") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		manifests := releaseutil.SplitManifests(out[name])
+		keys := make([]string, 0, len(manifests))
+		for k := range manifests {
+			keys = append(keys, k)
+		}
+		sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+		for _, k := range keys {
+			var content map[string]interface{}
+			if err := yaml.Unmarshal([]byte(manifests[k]), &content); err != nil {
+				e.err = errors.Wrapf(err, "unable to parse %s", name)
+				return e, nil
+			}
+			if len(content) == 0 {
+				continue
+			}
+			e.docs = append(e.docs, document{template: strings.TrimPrefix(name, prefix), content: content})
+		}
+	}
+	return e, nil
+}
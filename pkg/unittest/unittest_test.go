@@ -0,0 +1,330 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unittest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/internal/third_party/dep/fs"
+)
+
+const testChart = "testdata/nginx"
+
+// chartWithSuite copies the test chart to a temporary directory, with an
+// additional suite.
+func chartWithSuite(t *testing.T, suite string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "nginx")
+	if err := fs.CopyDir(testChart, dir); err != nil {
+		t.Fatal(err)
+	}
+	if suite != "" {
+		if err := ioutil.WriteFile(filepath.Join(dir, TestsDir, "extra_test.yaml"), []byte(suite), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func suiteResult(t *testing.T, r *Result, name string) SuiteResult {
+	t.Helper()
+	for _, s := range r.Suites {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("suite %s not found", name)
+	return SuiteResult{}
+}
+
+func TestRun(t *testing.T) {
+	r, err := Run(testChart, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Chart != "nginx" {
+		t.Errorf("expected chart nginx, got %s", r.Chart)
+	}
+	var names []string
+	for _, s := range r.Suites {
+		names = append(names, s.Name)
+		if s.Err != nil {
+			t.Errorf("suite %s: %s", s.Name, s.Err)
+		}
+		for _, tr := range s.Tests {
+			for _, f := range tr.Failures {
+				t.Errorf("%s: %s: %s", s.Name, tr.Name, f)
+			}
+		}
+	}
+	if expect := []string{"deployment", "service"}; !reflect.DeepEqual(expect, names) {
+		t.Errorf("expected suites %v, got %v", expect, names)
+	}
+	if !r.Passed() {
+		t.Error("expected the tests to pass")
+	}
+	if r.SnapshotsWritten != 0 {
+		t.Errorf("expected no snapshots written, got %d", r.SnapshotsWritten)
+	}
+}
+
+func TestRun_Failures(t *testing.T) {
+	chart := chartWithSuite(t, `
+templates:
+  - templates/deployment.yaml
+tests:
+  - it: fails
+    asserts:
+      - equals:
+          path: spec.replicas
+          value: 2
+      - not: true
+        equals:
+          path: spec.replicas
+          value: 1
+      - matchRegex:
+          path: metadata.name
+          pattern: ^web-
+      - isKind:
+          of: Service
+      - hasDocuments:
+          count: 2
+      - notExists:
+          path: spec.template
+      - not: true
+        notExists:
+          path: spec.selector
+      - failedTemplate: {}
+      - equals:
+          path: metadata.name
+          value: release-name-nginx
+        documentIndex: 1
+      - template: templates/service.yaml
+        isKind:
+          of: Service
+  - it: fails to render
+    set:
+      image.tag: ""
+    asserts:
+      - isKind:
+          of: Deployment
+      - failedTemplate:
+          errorPattern: tag is optional
+  - it: renders a missing template
+    templates:
+      - templates/missing.yaml
+    asserts:
+      - hasDocuments:
+          count: 0
+`)
+
+	r, err := Run(chart, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Passed() {
+		t.Error("expected the tests to fail")
+	}
+	s := suiteResult(t, r, "extra")
+	if s.Err != nil {
+		t.Fatal(s.Err)
+	}
+
+	expect := [][]string{
+		{
+			`asserts[0] equals: templates/deployment.yaml: spec.replicas: expected 2, got 1`,
+			`asserts[1] not equals: templates/deployment.yaml: spec.replicas: expected not 1, got 1`,
+			`asserts[2] matchRegex: templates/deployment.yaml: metadata.name: expected to match "^web-", got "release-name-nginx"`,
+			`asserts[3] isKind: templates/deployment.yaml: expected kind Service, got Deployment`,
+			`asserts[4] hasDocuments: expected 2 documents, got 1`,
+			`asserts[5] notExists: templates/deployment.yaml: spec.template: expected no value, got {"spec":{"containers":[{"image":"nginx:stable","name":"nginx"}]}}`,
+			`asserts[6] not notExists: templates/deployment.yaml: spec.selector: expected a value, got nothing`,
+			`asserts[7] failedTemplate: expected rendering to fail, it succeeded`,
+			`asserts[8] equals: documentIndex 1 is out of range, 1 documents rendered`,
+			`asserts[9] isKind: no documents rendered`,
+		},
+		{
+			`asserts[0] isKind: rendering failed: execution error at (nginx/templates/deployment.yaml:2:4): image.tag is required`,
+			`asserts[1] failedTemplate: expected an error matching "tag is optional", got execution error at (nginx/templates/deployment.yaml:2:4): image.tag is required`,
+		},
+		{
+			`template templates/missing.yaml not found`,
+		},
+	}
+	if len(s.Tests) != len(expect) {
+		t.Fatalf("expected %d tests, got %d", len(expect), len(s.Tests))
+	}
+	for i, tr := range s.Tests {
+		if !reflect.DeepEqual(expect[i], tr.Failures) {
+			t.Errorf("%s: expected failures\n%s\ngot\n%s", tr.Name, strings.Join(expect[i], "\n"), strings.Join(tr.Failures, "\n"))
+		}
+	}
+}
+
+func TestRun_InvalidSuite(t *testing.T) {
+	for suite, expect := range map[string]string{
+		"tests:\n  - asserts: []\n":                     "tests[0]: 'it' is required",
+		"tests:\n  - it: x\n    asserts:\n      - {}\n": "tests[0].asserts[0]: assertion has no kind",
+		"tests:\n  - it: x\n    asserts:\n      - isKind: {of: Pod}\n        hasDocuments: {count: 1}\n": "tests[0].asserts[0]: assertion has more than one kind: hasDocuments, isKind",
+		"tests:\n  - it: x\n    asserts:\n      - not: true\n        snapshot: {}\n":                     "tests[0].asserts[0]: snapshot cannot be negated",
+		"tests:\n  - it: x\n    asserts:\n      - not: true\n        snapshot:\n":                        "tests[0].asserts[0]: snapshot cannot be negated",
+		"tests:\n  - it: x\n    asserts:\n      - equal: {}\n":                                           "unable to parse",
+	} {
+		r, err := Run(chartWithSuite(t, suite), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := suiteResult(t, r, "extra")
+		if s.Err == nil || !strings.HasPrefix(s.Err.Error(), expect) {
+			t.Errorf("expected error %q, got %v", expect, s.Err)
+		}
+	}
+}
+
+func TestRun_Snapshots(t *testing.T) {
+	chart := chartWithSuite(t, `
+templates:
+  - templates/deployment.yaml
+tests:
+  - it: matches
+    asserts:
+      - snapshot: {}
+      - snapshot: {}
+        template: templates/deployment.yaml
+`)
+	snapshots := filepath.Join(chart, TestsDir, snapshotDir, "extra_test.snap")
+
+	r, err := Run(chart, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Passed() || r.SnapshotsWritten != 2 {
+		t.Fatalf("expected the tests to pass and 2 snapshots written, got %+v", r)
+	}
+	data, err := ioutil.ReadFile(snapshots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "matches 1: |\n  apiVersion: apps/v1\n  kind: Deployment\n") {
+		t.Errorf("unexpected snapshots:\n%s", data)
+	}
+
+	// The snapshots are taken; the documents now differ.
+	if err := ioutil.WriteFile(filepath.Join(chart, "values.yaml"), []byte("replicaCount: 2\nimage: {repository: nginx, tag: stable}\nservice: {enabled: true, port: 80}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err = Run(chart, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := suiteResult(t, r, "extra")
+	if r.SnapshotsWritten != 0 || len(s.Tests[0].Failures) != 2 {
+		t.Fatalf("expected 2 failures and no snapshots written, got %+v", s)
+	}
+	expect := "asserts[0] snapshot: documents differ from the snapshot:\n--- snapshot\n+++ rendered\n"
+	if f := s.Tests[0].Failures[0]; !strings.HasPrefix(f, expect) || !strings.Contains(f, "\n-  replicas: 1\n+  replicas: 2\n") {
+		t.Errorf("expected a diff of the replicas, got\n%s", f)
+	}
+	if after, _ := ioutil.ReadFile(snapshots); string(after) != string(data) {
+		t.Error("expected the snapshots to be left alone")
+	}
+
+	r, err = Run(chart, Options{UpdateSnapshots: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Passed() || r.SnapshotsWritten != 2 {
+		t.Fatalf("expected the tests to pass and 2 snapshots written, got %+v", r)
+	}
+	if after, _ := ioutil.ReadFile(snapshots); !strings.Contains(string(after), "replicas: 2") {
+		t.Errorf("expected the snapshots to be updated, got\n%s", after)
+	}
+}
+
+func TestRun_Dependencies(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "web")
+	for name, data := range map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: web
+version: 0.1.0
+dependencies:
+  - name: cache
+    version: 0.1.0
+    condition: cache.enabled
+    import-values:
+      - child: settings
+        parent: cacheSettings
+`,
+		"values.yaml":                    "cache:\n  enabled: true\n",
+		"templates/service.yaml":         "kind: Service\nmetadata:\n  name: web\n{{- with .Values.cacheSettings }}\nspec:\n  port: {{ .port }}\n{{- end }}\n",
+		"charts/cache/Chart.yaml":        "apiVersion: v2\nname: cache\nversion: 0.1.0\n",
+		"charts/cache/values.yaml":       "settings:\n  port: 6379\n",
+		"charts/cache/templates/cm.yaml": "kind: ConfigMap\nmetadata:\n  name: cache\n",
+		"tests/cache_test.yaml": `templates:
+  - charts/cache/templates/cm.yaml
+tests:
+  - it: is disabled by its condition
+    set:
+      cache.enabled: false
+    asserts:
+      - hasDocuments:
+          count: 0
+  - it: is enabled by default
+    asserts:
+      - hasDocuments:
+          count: 1
+`,
+		"tests/service_test.yaml": `templates:
+  - templates/service.yaml
+tests:
+  - it: imports the port of the cache
+    asserts:
+      - equals:
+          path: spec.port
+          value: 6379
+`,
+	} {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := Run(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"cache", "service"} {
+		s := suiteResult(t, r, name)
+		if s.Err != nil {
+			t.Fatalf("%s: %s", name, s.Err)
+		}
+		for _, tr := range s.Tests {
+			if len(tr.Failures) > 0 {
+				t.Errorf("%s: expected %q to pass, got\n%s", name, tr.Name, strings.Join(tr.Failures, "\n"))
+			}
+		}
+	}
+}